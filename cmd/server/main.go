@@ -12,16 +12,20 @@ import (
 	"api-go-arquitetura/internal/api"
 	"api-go-arquitetura/internal/api/handlers"
 	"api-go-arquitetura/internal/api/middleware"
+	"api-go-arquitetura/internal/auth"
 	"api-go-arquitetura/internal/cache"
 	"api-go-arquitetura/internal/config"
 	"api-go-arquitetura/internal/database"
 	"api-go-arquitetura/internal/logger"
 	"api-go-arquitetura/internal/metrics"
+	"api-go-arquitetura/internal/ratelimit"
 	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/retention"
 	"api-go-arquitetura/internal/service"
 
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // @title API Go com Arquitetura
@@ -35,6 +39,15 @@ import (
 // @host localhost:8080
 // @basePath /api/v1
 // @schemes http
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name X-Admin-Token
 func main() {
 	// Carregar configurações
 	cfg := config.Load()
@@ -45,46 +58,68 @@ func main() {
 	}
 
 	logger.WithFields(map[string]interface{}{
-		"mongo_uri": cfg.MongoURI,
-		"database":  cfg.Database,
-		"port":      cfg.Port,
+		"repository": cfg.RepositoryType,
+		"mongo_uri":  cfg.MongoURI,
+		"database":   cfg.Database,
+		"port":       cfg.Port,
 	}).Info("Configurações carregadas")
 
-	// Conectar ao MongoDB com tratamento de erro robusto
-	opts := database.ConnectOptions{
-		URI:            cfg.MongoURI,
-		ConnectTimeout: cfg.ConnectTimeout,
-		MaxPoolSize:    cfg.MaxPoolSize,
-		MinPoolSize:    cfg.MinPoolSize,
-	}
+	// Criar repositório de acordo com o tipo configurado
+	var (
+		prodRepo repository.ProdutoRepository
+		client   *mongo.Client
+	)
+	if cfg.RepositoryType == "memory" {
+		prodRepo = repository.NewMemoryProdutoRepository()
+		logger.WithField("type", "memory").Warn("Repositório em memória inicializado (os dados serão perdidos ao encerrar)")
+	} else {
+		// Conectar ao MongoDB com tratamento de erro robusto
+		opts := database.ConnectOptions{
+			URI:            cfg.MongoURI,
+			ConnectTimeout: cfg.ConnectTimeout,
+			MaxPoolSize:    cfg.MaxPoolSize,
+			MinPoolSize:    cfg.MinPoolSize,
+		}
 	
-	client, err := database.Connect(opts)
-	if err != nil {
-		logger.WithField("error", err).Fatal("Erro ao conectar ao MongoDB")
-	}
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := database.Disconnect(ctx, client); err != nil {
-			logger.WithField("error", err).Error("Erro ao fechar conexão com MongoDB")
+		var err error
+		client, err = database.Connect(opts)
+		if err != nil {
+			logger.WithField("error", err).Fatal("Erro ao conectar ao MongoDB")
 		}
-	}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := database.Disconnect(ctx, client); err != nil {
+				logger.WithField("error", err).Error("Erro ao fechar conexão com MongoDB")
+			}
+		}()
 
-	// Obter coleção de produtos com tratamento de erro
-	col, err := database.GetCollection(client, cfg.Database, "produtos")
-	if err != nil {
-		logger.WithField("error", err).Fatal("Erro ao obter coleção")
-	}
+		// Obter coleção de produtos com tratamento de erro
+		col, err := database.GetCollection(client, cfg.Database, "produtos")
+		if err != nil {
+			logger.WithField("error", err).Fatal("Erro ao obter coleção")
+		}
 
-	// Criar índices otimizados
-	ctxIndex, cancelIndex := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelIndex()
-	if err := database.CreateIndexes(ctxIndex, client, cfg.Database, "produtos"); err != nil {
-		logger.WithField("error", err).Warn("Erro ao criar índices (continuando mesmo assim)")
-	}
+		// Criar índices otimizados
+		ctxIndex, cancelIndex := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelIndex()
+		if err := database.CreateIndexes(ctxIndex, client, cfg.Database, "produtos", cfg.SearchLanguage); err != nil {
+			logger.WithField("error", err).Warn("Erro ao criar índices (continuando mesmo assim)")
+		}
+
+		// Sincronizar o contador de IDs com os produtos já existentes
+		counters, err := database.GetCollection(client, cfg.Database, repository.CountersCollection)
+		if err != nil {
+			logger.WithField("error", err).Fatal("Erro ao obter coleção de contadores")
+		}
+		if err := repository.SeedIDGenerator(ctxIndex, col, counters, "produtos"); err != nil {
+			logger.WithField("error", err).Fatal("Erro ao inicializar contador de IDs")
+		}
 
-	// Criar repositório
-	prodRepo := repository.NewProdutoRepository(col)
+		// Criar repositório com alocação atômica de IDs
+		idGenerator := repository.NewMongoIDGenerator(counters, "produtos")
+		prodRepo = repository.NewProdutoRepositoryWithIDGenerator(col, idGenerator)
+	}
 
 	// Inicializar cache
 	var cacheInstance cache.Cache
@@ -108,12 +143,32 @@ func main() {
 	// Criar service e injetar o repositório e cache
 	prodService := service.NewProdutoServiceWithTTL(prodRepo, cacheInstance, cfg.CacheTTL)
 
+	// Iniciar job de retenção de produtos deletados (quando configurado)
+	var retentionWorker *retention.Worker
+	if cfg.SoftDeleteRetention > 0 {
+		retentionWorker = retention.NewWorker(prodRepo, retention.Options{
+			Retention: cfg.SoftDeleteRetention,
+			Interval:  cfg.RetentionInterval,
+			DryRun:    cfg.RetentionDryRun,
+		})
+		retentionWorker.Start()
+	}
+
 	// Criar handler e injetar o service
-	produtoHandler := handlers.NewProdutoHandler(prodService)
+	produtoHandler := handlers.NewProdutoHandlerWithOptions(prodService, handlers.HandlerOptions{
+		CacheControlItem: cfg.CacheControlItem,
+		CacheControlList: cfg.CacheControlList,
+		AllowRegexMatch:  cfg.SearchRegexEnabled,
+		ExportTimeout:    cfg.ExportTimeout,
+		ImportTimeout:    cfg.ImportTimeout,
+	})
 
-	// Criar health check handler com verificação de banco de dados
-	healthCheckFunc := func(ctx context.Context) error {
-		return database.HealthCheck(ctx, client)
+	// Criar health check handler com verificação de banco de dados (quando houver)
+	var healthCheckFunc func(ctx context.Context) error
+	if client != nil {
+		healthCheckFunc = func(ctx context.Context) error {
+			return database.HealthCheck(ctx, client)
+		}
 	}
 	healthCheckHandler := handlers.NewHealthCheckHandler(healthCheckFunc)
 
@@ -129,6 +184,117 @@ func main() {
 	// Configurar CORS
 	middleware.SetCORSConfig(&cfg)
 
+	// Configurar token das rotas administrativas
+	middleware.SetAdminToken(cfg.AdminToken)
+
+	// Configurar autenticação: API keys (no MongoDB ou, com o repositório em memória,
+	// apenas as provisionadas em AUTH_API_KEYS) e bearer tokens JWT
+	if cfg.AuthEnabled {
+		var apiKeyRepo repository.APIKeyRepository
+		if client != nil {
+			apiKeysCol, err := database.GetCollection(client, cfg.Database, repository.APIKeysCollection)
+			if err != nil {
+				logger.WithField("error", err).Fatal("Erro ao obter coleção de API keys")
+			}
+			ctxKeys, cancelKeys := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := repository.CreateAPIKeyIndexes(ctxKeys, apiKeysCol); err != nil {
+				logger.WithField("error", err).Warn("Erro ao criar índices de API keys (continuando mesmo assim)")
+			}
+			cancelKeys()
+			apiKeyRepo = repository.NewAPIKeyRepository(apiKeysCol)
+		} else {
+			apiKeyRepo = repository.NewMemoryAPIKeyRepository()
+		}
+
+		ctxKeys, cancelKeys := context.WithTimeout(context.Background(), 10*time.Second)
+		for _, key := range cfg.AuthAPIKeys {
+			if err := apiKeyRepo.Save(ctxKeys, key); err != nil {
+				logger.WithFields(map[string]interface{}{
+					"error":  err.Error(),
+					"key_id": key.ID,
+				}).Fatal("Erro ao provisionar API key")
+			}
+		}
+		cancelKeys()
+
+		middleware.SetAuthenticator(auth.NewAPIKeyAuthenticator(apiKeyRepo, cfg.AuthLastUsedInterval))
+		logger.WithFields(map[string]interface{}{
+			"provisioned_keys": len(cfg.AuthAPIKeys),
+		}).Info("Autenticação por API key habilitada")
+
+		// Bearer tokens JWT: RS256/ES256 pelo JWKS e HS256 pelo segredo (desenvolvimento)
+		if cfg.JWTEnabled() {
+			jwtOpts := auth.JWTOptions{
+				HS256Secret: []byte(cfg.JWTHS256Secret),
+				Issuer:      cfg.JWTIssuer,
+				Audience:    cfg.JWTAudience,
+				Leeway:      cfg.JWTLeeway,
+				RolesClaim:  cfg.JWTRolesClaim,
+				RoleScopes:  cfg.JWTRoleScopes,
+			}
+			var jwks *auth.JWKS
+			if cfg.JWTJWKSURL != "" {
+				jwks = auth.NewJWKSFromURL(cfg.JWTJWKSURL, cfg.JWTJWKSRefresh)
+			} else if cfg.JWTJWKSFile != "" {
+				jwks = auth.NewJWKSFromFile(cfg.JWTJWKSFile, cfg.JWTJWKSRefresh)
+			}
+			if jwks != nil {
+				// Carregar as chaves na inicialização; se falhar, o carregamento é
+				// repetido na validação dos tokens
+				ctxJWKS, cancelJWKS := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := jwks.Refresh(ctxJWKS); err != nil {
+					logger.WithField("error", err).Warn("Erro ao carregar JWKS (nova tentativa na primeira requisição)")
+				}
+				cancelJWKS()
+				jwtOpts.Keys = jwks
+			}
+			if cfg.JWTHS256Secret != "" {
+				logger.Warn("Tokens HS256 habilitados (JWT_HS256_SECRET): use apenas em desenvolvimento")
+			}
+			// Com JWKS, emissor e audiência são obrigatórios (ver Config.Validate)
+			if cfg.JWTIssuer == "" || cfg.JWTAudience == "" {
+				logger.Warn("JWT_ISSUER ou JWT_AUDIENCE não configurados: tokens HS256 de qualquer emissor ou audiência serão aceitos")
+			}
+			middleware.SetTokenAuthenticator(auth.NewJWTAuthenticator(jwtOpts))
+			logger.WithFields(map[string]interface{}{
+				"jwks_url":  cfg.JWTJWKSURL,
+				"jwks_file": cfg.JWTJWKSFile,
+				"issuer":    cfg.JWTIssuer,
+				"audience":  cfg.JWTAudience,
+			}).Info("Autenticação por JWT habilitada")
+		}
+	} else {
+		logger.Warn("Autenticação desabilitada (AUTH_ENABLED=false): rotas de produtos anônimas")
+	}
+
+	// Configurar rate limit (Redis compartilha os limites entre réplicas)
+	rateLimitStore := ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "redis" {
+		redisStore, err := ratelimit.NewRedisStore(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			logger.WithField("error", err).Warn("Erro ao conectar ao Redis, usando rate limit em memória (por réplica)")
+		} else {
+			rateLimitStore = redisStore
+		}
+	}
+	middleware.SetRateLimiter(rateLimitStore, ratelimit.Limit{
+		Requests: cfg.RateLimitRequests,
+		Period:   cfg.RateLimitPeriod,
+		Burst:    cfg.RateLimitBurst,
+	})
+	exemptNetworks, _ := ratelimit.ParseCIDRs(cfg.RateLimitExemptCIDRs) // Validado em cfg.Validate
+	middleware.SetRateLimitPolicies(cfg.RateLimitPolicies, exemptNetworks)
+	trustedProxies, _ := ratelimit.ParseCIDRs(cfg.TrustedProxies) // Validado em cfg.Validate
+	middleware.SetTrustedProxies(trustedProxies)
+	logger.WithFields(map[string]interface{}{
+		"store":    cfg.RateLimitStore,
+		"requests": cfg.RateLimitRequests,
+		"period":   cfg.RateLimitPeriod.String(),
+		"policies": len(cfg.RateLimitPolicies),
+		"exempt":   cfg.RateLimitExemptCIDRs,
+		"proxies":  cfg.TrustedProxies,
+	}).Info("Rate limit configurado")
+
 	// Aplicar middlewares
 	middleware.ApplyMiddlewares(e)
 
@@ -169,6 +335,11 @@ func main() {
 		logger.WithField("error", err).Fatal("Erro ao encerrar servidor")
 	}
 
+	// Encerrar job de retenção antes de desconectar do banco
+	if retentionWorker != nil {
+		retentionWorker.Stop()
+	}
+
 	logger.Info("Servidor encerrado com sucesso")
 	
 	// Fazer shutdown do logger (flush final para Loki)
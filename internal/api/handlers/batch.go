@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/utils"
+)
+
+// BatchProdutos executa um lote de operações sobre produtos
+// @Summary Executa operações em lote
+// @Description Cria, atualiza e remove produtos em uma única requisição, com o resultado de cada operação. Com atomic=true, a falha de uma operação desfaz todas as demais (status 424 nos itens desfeitos)
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param request body dto.BatchRequest true "Operações do lote (máximo de 1000)"
+// @Success 207 {object} dto.BatchResponse
+// @Failure 400 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/batch [post]
+// POST /api/v1/produtos/batch
+func (h *ProdutoHandler) BatchProdutos(c echo.Context) error {
+	var request dto.BatchRequest
+
+	// Decodificar JSON usando Echo
+	if err := c.Bind(&request); err != nil {
+		return utils.EchoBadRequestResponse(c, "Erro ao decodificar JSON: "+err.Error())
+	}
+
+	// A validação de cada operação (e o cancelamento do lote atômico com operações
+	// inválidas) é feita pelo service
+	operations := make([]repository.BulkOperation, len(request.Operations))
+	for i, item := range request.Operations {
+		operations[i] = toBulkOperation(item)
+	}
+	results, err := h.service.BulkWrite(c.Request().Context(), operations, request.Atomic)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	response := dto.BatchResponse{
+		Atomic:  request.Atomic,
+		Results: make([]dto.BatchItemResult, len(request.Operations)),
+	}
+	for i, item := range request.Operations {
+		result := dto.BatchItemResult{
+			Index: i,
+			Op:    item.Op,
+			ID:    item.ID,
+		}
+		if err := results[i].Err; err != nil {
+			apiErr := toAPIError(err)
+			result.Status = apiErr.Status
+			result.Error = apiErr
+			response.Failed++
+		} else {
+			result.Status = batchSuccessStatus(item.Op)
+			if item.Op != dto.BatchDelete {
+				result.ID = results[i].Produto.ID
+				result.Produto = produtoBody(c, results[i].Produto)
+			}
+			response.Succeeded++
+		}
+		response.Results[i] = result
+	}
+
+	setRepresentationHeaders(c)
+	return utils.EchoSuccessResponse(c, http.StatusMultiStatus, response)
+}
+
+// toBulkOperation converte uma operação do lote; as operações do lote têm os mesmos
+// nomes das de repository.BulkOperation
+func toBulkOperation(item dto.BatchOperationRequest) repository.BulkOperation {
+	op := repository.BulkOperation{
+		Type:            item.Op,
+		ID:              item.ID,
+		ExpectedVersion: item.Version,
+	}
+	if item.Produto != nil {
+		switch item.Op {
+		case dto.BatchCreate, dto.BatchUpdate:
+			op.Produto = item.Produto.ToModel()
+		case dto.BatchPatch:
+			op.Updates = item.Produto.ToMap()
+		}
+	}
+	return op
+}
+
+// batchSuccessStatus retorna o status da rota individual equivalente à operação
+func batchSuccessStatus(op string) int {
+	switch op {
+	case dto.BatchCreate:
+		return http.StatusCreated
+	case dto.BatchDelete:
+		return http.StatusNoContent
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/utils"
+)
+
+// Headers HTTP usados em requisições condicionais
+const (
+	headerETag            = "ETag"
+	headerLastModified    = "Last-Modified"
+	headerCacheControl    = "Cache-Control"
+	headerIfMatch         = "If-Match"
+	headerIfNoneMatch     = "If-None-Match"
+	headerIfModifiedSince = "If-Modified-Since"
+)
+
+// computeETag gera um ETag forte a partir da representação JSON da resposta
+func computeETag(body interface{}) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches verifica se algum ETag da lista recebida no header corresponde ao atual
+// If-None-Match usa comparação fraca (W/ é ignorado); If-Match usa comparação forte
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified avalia If-None-Match e If-Modified-Since de uma requisição GET
+// If-None-Match tem precedência: If-Modified-Since só é usado quando ele está ausente
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if ifNoneMatch := req.Header.Get(headerIfNoneMatch); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag, true)
+	}
+
+	ifModifiedSince := req.Header.Get(headerIfModifiedSince)
+	if ifModifiedSince == "" || lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	// Last-Modified é enviado com precisão de segundos
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// respondConditional envia a resposta com ETag, Last-Modified e Cache-Control,
+// retornando 304 Not Modified quando a representação do cliente ainda é atual
+// lastModified zero omite o header Last-Modified
+func respondConditional(c echo.Context, body interface{}, lastModified time.Time, cacheControl string) error {
+	etag, err := computeETag(body)
+	if err != nil {
+		return utils.EchoErrorResponse(c, errors.ErrInternalServer.WithDetails(err.Error()))
+	}
+
+	header := c.Response().Header()
+	header.Set(headerETag, etag)
+	if !lastModified.IsZero() {
+		header.Set(headerLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	if cacheControl != "" {
+		header.Set(headerCacheControl, cacheControl)
+	}
+
+	if notModified(c.Request(), etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return utils.EchoSuccessResponse(c, http.StatusOK, body)
+}
+
+// setETag adiciona o ETag da representação retornada por uma alteração
+func setETag(c echo.Context, response interface{}) {
+	if etag, err := computeETag(response); err == nil {
+		c.Response().Header().Set(headerETag, etag)
+	}
+}
+
+// expectedVersion valida o header If-Match contra a representação atual do produto
+// (na versão negociada, a mesma cujo ETag o cliente recebeu) e retorna a versão que a alteração deve exigir do repositório, garantindo que o
+// produto não mude entre esta verificação e a escrita
+// Sem o header (ou com "*") a alteração não é condicionada a uma versão
+func (h *ProdutoHandler) expectedVersion(c echo.Context, id int) (int, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get(headerIfMatch))
+	if ifMatch == "" || ifMatch == "*" {
+		return repository.AnyVersion, nil
+	}
+
+	// Leitura sem cache: a comparação precisa da representação atual
+	current, err := h.service.FindCurrent(c.Request().Context(), id)
+	if err != nil {
+		return 0, err
+	}
+	etag, err := computeETag(produtoBody(c, current))
+	if err != nil {
+		return 0, errors.ErrInternalServer.WithDetails(err.Error())
+	}
+	if !etagMatches(ifMatch, etag, false) {
+		return 0, errors.ErrPreconditionFailed.WithDetails("If-Match não corresponde à versão atual do produto")
+	}
+	return current.Version, nil
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/utils"
+	"api-go-arquitetura/internal/validator"
+)
+
+// Formatos de exportação
+const (
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatCSV    = "csv"
+)
+
+// exportFlushInterval é o número de produtos escritos entre dois envios (flush) da resposta
+const exportFlushInterval = 100
+
+// headerExportError é o trailer HTTP que informa a falha de uma exportação já iniciada
+const headerExportError = "X-Export-Error"
+
+// exportCSVColumns são as colunas do CSV exportado; id, nome, preco e descricao
+// são as mesmas aceitas na importação
+var exportCSVColumns = []string{"id", "nome", "preco", "descricao", "created_at", "updated_at", "deleted_at", "version"}
+
+// exportWriter escreve os produtos exportados em um formato
+type exportWriter interface {
+	Begin() error
+	Write(p model.Produto) error
+	Flush() error // Esvazia buffers próprios antes do flush da resposta
+	End() error
+}
+
+// ExportProdutos exporta todos os produtos filtrados, sem paginação
+// @Summary Exporta produtos em JSON, NDJSON ou CSV
+// @Description Envia os produtos à medida que são lidos do banco, sem carregá-los em memória. Aceita os mesmos filtros e ordenação da listagem. Se a leitura falhar depois do início da resposta, o trailer X-Export-Error informa o motivo (e, em JSON, o array fica sem o fechamento)
+// @Tags produtos
+// @Produce json
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Param format query string false "Formato da exportação" Enums(json, ndjson, csv) default(json)
+// @Param nome query string false "Filtro por nome (busca parcial, case-insensitive)"
+// @Param precoMin query number false "Preço mínimo"
+// @Param precoMax query number false "Preço máximo"
+// @Param deleted query string false "Produtos deletados: exclude (padrão), include ou only" Enums(exclude, include, only)
+// @Param filter query string false "Expressão de filtro RSQL/FIQL (ver GET /api/v1/produtos)"
+// @Param sort query string false "Campos para ordenação (ver GET /api/v1/produtos)" default(id)
+// @Success 200 {array} dto.ProdutoResponse
+// @Failure 400 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/export [get]
+// GET /api/v1/produtos/export?format=csv&precoMin=100&sort=preco:desc
+func (h *ProdutoHandler) ExportProdutos(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = ExportFormatJSON
+	}
+
+	var writer exportWriter
+	var contentType string
+	switch format {
+	case ExportFormatJSON:
+		writer, contentType = &jsonExportWriter{c: c}, echo.MIMEApplicationJSONCharsetUTF8
+	case ExportFormatNDJSON:
+		writer, contentType = &ndjsonExportWriter{c: c}, "application/x-ndjson"
+	case ExportFormatCSV:
+		writer, contentType = &csvExportWriter{writer: csv.NewWriter(c.Response())}, "text/csv; charset=utf-8"
+	default:
+		return utils.EchoBadRequestResponse(c, "valor inválido para format: "+format+". Valores permitidos: json, ndjson, csv")
+	}
+
+	filter, err := h.parseFilter(c)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+	sort := dto.GetSortFromQuery(c.QueryParam("sort"), c.QueryParam("order"))
+	if validationErrors := validator.Validate(&sort); len(validationErrors) > 0 {
+		return utils.EchoValidationErrorResponse(c, validationErrors)
+	}
+
+	// A resposta só é iniciada no primeiro produto: erros de validação e de consulta
+	// antes disso ainda são enviados como resposta de erro
+	response := c.Response()
+	controller := http.NewResponseController(response)
+	// A exportação pode durar mais que o WriteTimeout do servidor, contado desde a
+	// leitura da requisição
+	_ = controller.SetWriteDeadline(deadline(h.options.ExportTimeout))
+	begin := func() error {
+		header := response.Header()
+		header.Set(headerContentType, contentType)
+		header.Set("Content-Disposition", `attachment; filename="produtos.`+format+`"`)
+		header.Set(headerVary, headerAccept)
+		header.Set("Trailer", headerExportError)
+		response.WriteHeader(http.StatusOK)
+		return writer.Begin()
+	}
+
+	written := 0
+	err = h.service.Export(c.Request().Context(), filter, sort, func(p model.Produto) error {
+		if written == 0 {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(p); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			_ = controller.Flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if !response.Committed {
+			return utils.EchoErrorResponse(c, err)
+		}
+		// O status já foi enviado: a falha é informada no trailer
+		logger.WithFields(map[string]interface{}{
+			"error":   err.Error(),
+			"written": written,
+			"format":  format,
+		}).Error("Exportação de produtos interrompida")
+		_ = writer.Flush()
+		response.Header().Set(headerExportError, err.Error())
+		return nil
+	}
+
+	if written == 0 {
+		if err := begin(); err != nil {
+			return err
+		}
+	}
+	if err := writer.End(); err != nil {
+		return err
+	}
+	_ = controller.Flush()
+	return nil
+}
+
+// jsonExportWriter escreve um array JSON, na representação negociada (v1 ou v2)
+type jsonExportWriter struct {
+	c     echo.Context
+	first bool
+}
+
+func (w *jsonExportWriter) Begin() error {
+	w.first = true
+	_, err := io.WriteString(w.c.Response(), "[")
+	return err
+}
+
+func (w *jsonExportWriter) Write(p model.Produto) error {
+	data, err := json.Marshal(produtoBody(w.c, p))
+	if err != nil {
+		return err
+	}
+	if !w.first {
+		if _, err := io.WriteString(w.c.Response(), ","); err != nil {
+			return err
+		}
+	}
+	w.first = false
+	_, err = w.c.Response().Write(data)
+	return err
+}
+
+func (w *jsonExportWriter) Flush() error {
+	return nil
+}
+
+func (w *jsonExportWriter) End() error {
+	_, err := io.WriteString(w.c.Response(), "]\n")
+	return err
+}
+
+// ndjsonExportWriter escreve um objeto JSON por linha, na representação negociada
+type ndjsonExportWriter struct {
+	c       echo.Context
+	encoder *json.Encoder
+}
+
+func (w *ndjsonExportWriter) Begin() error {
+	w.encoder = json.NewEncoder(w.c.Response())
+	return nil
+}
+
+func (w *ndjsonExportWriter) Write(p model.Produto) error {
+	return w.encoder.Encode(produtoBody(w.c, p))
+}
+
+func (w *ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (w *ndjsonExportWriter) End() error {
+	return nil
+}
+
+// csvExportWriter escreve um CSV com cabeçalho (colunas de exportCSVColumns)
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func (w *csvExportWriter) Begin() error {
+	w.record = make([]string, len(exportCSVColumns))
+	return w.writer.Write(exportCSVColumns)
+}
+
+func (w *csvExportWriter) Write(p model.Produto) error {
+	w.record[0] = strconv.Itoa(p.ID)
+	w.record[1] = p.Nome
+	w.record[2] = strconv.FormatFloat(p.Preco, 'f', -1, 64)
+	w.record[3] = p.Descricao
+	w.record[4] = formatExportTime(p.CreatedAt)
+	w.record[5] = formatExportTime(p.UpdatedAt)
+	w.record[6] = ""
+	if p.DeletedAt != nil {
+		w.record[6] = formatExportTime(*p.DeletedAt)
+	}
+	w.record[7] = strconv.Itoa(p.Version)
+	return w.writer.Write(w.record)
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvExportWriter) End() error {
+	return w.Flush()
+}
+
+// deadline retorna o prazo a partir de agora (zero = sem prazo)
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// formatExportTime formata a data como no JSON (RFC3339 com frações de segundo)
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/importer"
+	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/utils"
+	"api-go-arquitetura/internal/validator"
+)
+
+// ImportProdutos importa produtos de um arquivo CSV ou NDJSON
+// @Summary Importa produtos de CSV ou NDJSON
+// @Description Lê o arquivo linha a linha (sem carregá-lo inteiro em memória), valida cada linha com as regras da criação de produto e grava em lotes. Linhas com id fazem upsert: atualizam o produto com esse id ou, se ele não existir, o criam com esse id (um produto deletado é rejeitado com PRODUTO_NOT_FOUND). Linhas sem id sempre criam um novo produto; para reimportar uma exportação sem duplicar produtos, mantenha a coluna id. Em dryRun, os ids são verificados da mesma forma, sem gravar. CSV: cabeçalho com nome, preco e, opcionalmente, descricao e id. NDJSON: um objeto {"id", "nome", "preco", "descricao"} por linha
+// @Tags produtos
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param dryRun query bool false "Apenas valida o arquivo, sem gravar" default(false)
+// @Success 200 {object} dto.ImportReport
+// @Failure 400 {object} dto.ImportReport "Arquivo interrompido por erro de leitura (error indica o motivo)"
+// @Failure 415 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/import [post]
+// POST /api/v1/produtos/import?dryRun=true
+func (h *ProdutoHandler) ImportProdutos(c echo.Context) error {
+	dryRun := false
+	if value := c.QueryParam("dryRun"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return utils.EchoBadRequestResponse(c, "valor inválido para dryRun: "+value)
+		}
+	}
+
+	// A leitura do arquivo pode durar mais que o ReadTimeout do servidor, contado
+	// desde o início da requisição
+	_ = http.NewResponseController(c.Response()).SetReadDeadline(deadline(h.options.ImportTimeout))
+
+	reader, err := importer.NewReader(c.Request().Header.Get(headerContentType), c.Request().Body)
+	if err != nil {
+		if stderrors.Is(err, importer.ErrUnsupportedMediaType) {
+			return utils.EchoErrorResponse(c, errors.ErrUnsupportedMediaType.WithDetailsf("Use %s ou %s", importer.MediaTypeCSV, importer.MediaTypeNDJSON))
+		}
+		return utils.EchoBadRequestResponse(c, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	report := dto.ImportReport{DryRun: dryRun, Errors: []dto.ImportRowError{}}
+
+	// Linhas válidas aguardando gravação: apenas um lote fica em memória
+	lines := make([]int, 0, dto.ImportBatchSize)
+	operations := make([]repository.BulkOperation, 0, dto.ImportBatchSize)
+	flush := func() error {
+		if len(operations) == 0 {
+			return nil
+		}
+		var results []repository.BulkResult
+		var err error
+		if dryRun {
+			results, err = h.service.DryRunBulkWrite(ctx, operations)
+		} else {
+			results, err = h.service.BulkWrite(ctx, operations, false)
+		}
+		if err != nil {
+			return err
+		}
+		for i, result := range results {
+			if result.Err != nil {
+				report.Reject(lines[i], toAPIError(result.Err))
+				continue
+			}
+			report.Accepted++
+			if result.Created {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+		}
+		lines = lines[:0]
+		operations = operations[:0]
+		return nil
+	}
+
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// As linhas anteriores ao erro são gravadas e fazem parte do relatório
+			if flushErr := flush(); flushErr != nil {
+				return respondImportError(c, report, flushErr)
+			}
+			return respondImportError(c, report, errors.ErrInvalidInput.WithDetails(err.Error()))
+		}
+
+		report.Total++
+		if row.Err != nil {
+			report.Reject(row.Line, errors.ErrInvalidInput.WithDetails(row.Err.Error()))
+			continue
+		}
+		if validationErrors := validator.Validate(&row.Produto); len(validationErrors) > 0 {
+			report.Reject(row.Line, errors.ErrValidation.WithDetailsf("Erros de validação: %v", validationErrors))
+			continue
+		}
+
+		op := repository.BulkOperation{Type: repository.BulkCreate, Produto: row.Produto.ToModel()}
+		if row.ID != 0 {
+			op.Type = repository.BulkUpsert
+			op.ID = row.ID
+		}
+		lines = append(lines, row.Line)
+		operations = append(operations, op)
+		if len(operations) == dto.ImportBatchSize {
+			if err := flush(); err != nil {
+				return respondImportError(c, report, err)
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return respondImportError(c, report, err)
+	}
+	return utils.EchoSuccessResponse(c, http.StatusOK, report)
+}
+
+// respondImportError envia o relatório de uma importação interrompida, com o status do erro
+func respondImportError(c echo.Context, report dto.ImportReport, err error) error {
+	report.Error = toAPIError(err)
+	return utils.EchoJSONResponse(c, report.Error.Status, report)
+}
+
+// toAPIError converte o erro para APIError (erros desconhecidos são erros internos)
+func toAPIError(err error) *errors.APIError {
+	if apiErr := errors.AsAPIError(err); apiErr != nil {
+		return apiErr
+	}
+	return errors.WrapError(err, errors.ErrInternalServer)
+}
@@ -11,6 +11,7 @@ import (
 
 	"api-go-arquitetura/internal/dto"
 	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/model"
 	"api-go-arquitetura/internal/service"
 	"api-go-arquitetura/internal/utils"
 	"api-go-arquitetura/internal/validator"
@@ -19,12 +20,44 @@ import (
 // ProdutoHandler gerencia os handlers de produto
 type ProdutoHandler struct {
 	service service.ProdutoService
+	options HandlerOptions
+}
+
+// HandlerOptions configura o comportamento HTTP do ProdutoHandler
+type HandlerOptions struct {
+	CacheControlItem string // Cache-Control de GET /produtos/{id}
+	CacheControlList string // Cache-Control de GET /produtos
+	AllowRegexMatch  bool   // Permite filtros com match=regex
+
+	// Prazo de escrita da exportação, que substitui o WriteTimeout do servidor
+	// (0 = sem prazo)
+	ExportTimeout time.Duration
+	// Prazo de leitura do arquivo importado, que substitui o ReadTimeout do servidor
+	// (0 = sem prazo)
+	ImportTimeout time.Duration
+}
+
+// DefaultHandlerOptions retorna as opções padrão: clientes podem armazenar as
+// respostas, mas devem revalidá-las (If-None-Match) antes de reutilizar
+func DefaultHandlerOptions() HandlerOptions {
+	return HandlerOptions{
+		CacheControlItem: "no-cache",
+		CacheControlList: "no-cache",
+		ExportTimeout:    30 * time.Minute,
+		ImportTimeout:    30 * time.Minute,
+	}
 }
 
 // NewProdutoHandler cria uma nova instância do ProdutoHandler
 func NewProdutoHandler(svc service.ProdutoService) *ProdutoHandler {
+	return NewProdutoHandlerWithOptions(svc, DefaultHandlerOptions())
+}
+
+// NewProdutoHandlerWithOptions cria uma nova instância do ProdutoHandler com opções customizadas
+func NewProdutoHandlerWithOptions(svc service.ProdutoService, options HandlerOptions) *ProdutoHandler {
 	return &ProdutoHandler{
 		service: svc,
+		options: options,
 	}
 }
 
@@ -36,17 +69,31 @@ func NewProdutoHandler(svc service.ProdutoService) *ProdutoHandler {
 // @Produce json
 // @Param page query int false "Número da página (padrão: 1)" default(1)
 // @Param pageSize query int false "Tamanho da página (padrão: 10, máximo: 100)" default(10)
+// @Param cursor query string false "Cursor opaco (pagination.nextCursor da resposta anterior); substitui page"
+// @Param after query string false "Sinônimo de cursor"
 // @Param nome query string false "Filtro por nome (busca parcial, case-insensitive)"
 // @Param precoMin query number false "Preço mínimo"
 // @Param precoMax query number false "Preço máximo"
 // @Param descricao query string false "Filtro por descrição (busca parcial, case-insensitive)"
-// @Param sort query string false "Campo para ordenação (id, nome, preco, descricao, created_at, updated_at)" default(id)
-// @Param order query string false "Ordem de ordenação (asc, desc)" default(asc)
-// @Success 200 {object} dto.PaginatedResponse
-// @Failure 400 {object} errors.APIError
+// @Param match query string false "Modo de comparação de nome/descricao: contains (padrão, sem diferenciar maiúsculas), prefix, exact ou regex (se habilitado)" Enums(contains, prefix, exact, regex)
+// @Param deleted query string false "Produtos deletados: exclude (padrão), include ou only (lixeira). Com updatedSince o padrão é include" Enums(exclude, include, only)
+// @Param createdFrom query string false "Criados a partir desta data, inclusive (RFC3339)"
+// @Param createdTo query string false "Criados até esta data, inclusive (RFC3339)"
+// @Param updatedSince query string false "Alterados a partir desta data, inclusive (RFC3339). Inclui produtos deletados (com deleted_at) para sincronização incremental; use o maior updated_at recebido na próxima chamada (updated_at está presente apenas na representação v2)"
+// @Param filter query string false "Expressão de filtro RSQL/FIQL, combinada com os demais filtros. Operadores: ==, !=, =gt=, =ge=, =lt=, =le=, =in=, =out=, =like= (curinga *, no máximo 3 por valor); ; é E e , é OU (ex: preco=gt=100;nome=like=note*,descricao==x). Campos: id, nome, preco, descricao, created_at, updated_at, version"
+// @Param sort query string false "Campos para ordenação, em ordem de prioridade, no formato campo[:ordem] separados por vírgula (ex: preco:desc,nome:asc). Campos: id, nome, preco, descricao, created_at, updated_at. O id é sempre usado como último critério de desempate" default(id)
+// @Param order query string false "Ordem dos campos informados sem ordem explícita (asc, desc)" default(asc)
+// @Param fields query string false "Campos da resposta, separados por vírgula (ex: id,nome,preco); os demais são omitidos. Campos: id, nome, preco, descricao, deleted_at e, na representação v2, created_at, updated_at e version"
+// @Param facets query string false "Facetas calculadas sobre todos os produtos filtrados, separadas por vírgula (preco: menor e maior preço e contagem por faixa; nome: os 10 nomes mais frequentes e suas contagens)" Enums(preco, nome)
+// @Param If-None-Match header string false "ETag de uma resposta anterior (retorna 304 se não houve mudança)"
+// @Param Accept header string false "application/vnd.produto.v2+json seleciona a representação v2 (mesma de /api/v2/produtos)"
+// @Success 200 {object} dto.PaginatedProdutoListResponse
+// @Header 200 {string} ETag "ETag forte da resposta"
+// @Success 304
+// @Failure 400 {object} errors.APIError "Parâmetros inválidos; erros na expressão filter incluem position"
 // @Failure 500 {object} errors.APIError
 // @Router /api/v1/produtos [get]
-// GET /api/v1/produtos?page=1&pageSize=10&nome=notebook&precoMin=1000&precoMax=5000&sort=preco&order=desc
+// GET /api/v1/produtos?page=1&pageSize=10&nome=notebook&precoMin=1000&precoMax=5000&sort=preco:desc,nome:asc
 func (h *ProdutoHandler) GetProdutos(c echo.Context) error {
 	ctx := c.Request().Context()
 	
@@ -54,14 +101,17 @@ func (h *ProdutoHandler) GetProdutos(c echo.Context) error {
 	pagination := dto.PaginationRequest{
 		Page:     getIntQueryEcho(c, "page", 1),
 		PageSize: getIntQueryEcho(c, "pageSize", 10),
+		Cursor:   c.QueryParam("cursor"),
+	}
+	// "after" é aceito como sinônimo de "cursor"
+	if pagination.Cursor == "" {
+		pagination.Cursor = c.QueryParam("after")
 	}
 
 	// Parse de filtros
-	filter := dto.FilterRequest{
-		Nome:      getStringQueryEcho(c, "nome"),
-		PrecoMin:  getFloatQueryEcho(c, "precoMin"),
-		PrecoMax:  getFloatQueryEcho(c, "precoMax"),
-		Descricao: getStringQueryEcho(c, "descricao"),
+	filter, err := h.parseFilter(c)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
 	}
 
 	// Parse de ordenação
@@ -75,8 +125,20 @@ func (h *ProdutoHandler) GetProdutos(c echo.Context) error {
 		return utils.EchoValidationErrorResponse(c, validationErrors)
 	}
 
+	// Parse de facetas (opcionais)
+	facets := dto.GetFacetsFromQuery(c.QueryParam("facets"))
+
+	// Parse de campos (sparse fieldset)
+	fields, err := parseFields(c)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	v2 := isV2(c)
+
 	// Se não há filtros e paginação padrão, usar método antigo para compatibilidade
-	if filter.IsEmpty() && pagination.Page == 1 && pagination.PageSize == 10 && sort.Field == "" {
+	// A v2 não tem o formato antigo: a resposta é sempre paginada
+	if !v2 && filter.IsEmpty() && pagination.Page == 1 && pagination.PageSize == 10 && sort.IsEmpty() && !pagination.IsCursor() && facets.IsEmpty() && fields.IsEmpty() {
 		// Verificar se há parâmetros de query explícitos
 		if c.QueryParam("page") == "" && c.QueryParam("pageSize") == "" && c.QueryParam("sort") == "" {
 			// Usar método antigo (sem paginação)
@@ -85,21 +147,232 @@ func (h *ProdutoHandler) GetProdutos(c echo.Context) error {
 				return utils.EchoErrorResponse(c, errors.WrapError(err, errors.ErrDatabase))
 			}
 			response := dto.ToProdutoListResponse(produtos)
-			return utils.EchoSuccessResponse(c, http.StatusOK, response)
+			setRepresentationHeaders(c)
+			return respondConditional(c, response, time.Time{}, h.options.CacheControlList)
 		}
 	}
 
 	// Usar método paginado
-	produtos, paginationResp, err := h.service.FindAllPaginated(ctx, pagination, filter, sort)
+	produtos, paginationResp, err := h.service.FindAllPaginated(ctx, pagination, filter, sort, fields)
 	if err != nil {
+		// Erros da API (ex: parâmetros inválidos) mantêm o status original
+		if errors.IsAPIError(err) {
+			return utils.EchoErrorResponse(c, err)
+		}
 		return utils.EchoErrorResponse(c, errors.WrapError(err, errors.ErrDatabase))
 	}
 
-	// Converter models para DTOs
-	produtosDTO := dto.FromModelList(produtos)
-	response := dto.ToPaginatedResponse(produtosDTO, paginationResp)
+	// Facetas são calculadas sobre todos os produtos filtrados, não apenas a página
+	var facetsResp *model.Facets
+	if !facets.IsEmpty() {
+		facetsResp, err = h.service.Facets(ctx, facets, filter, nil)
+		if err != nil {
+			return utils.EchoErrorResponse(c, err)
+		}
+	}
 
-	return utils.EchoSuccessResponse(c, http.StatusOK, response)
+	// Converter models para DTOs na representação negociada
+	var body interface{}
+	var sparse []dto.SparseProdutoResponse
+	if v2 {
+		produtosDTO := dto.FromModelListV2(produtos)
+		body = dto.PaginatedProdutoListResponseV2{
+			Produtos:   produtosDTO,
+			Pagination: paginationResp,
+			Facets:     facetsResp,
+		}
+		if !fields.IsEmpty() {
+			sparse, err = fields.SelectListV2(produtosDTO)
+		}
+	} else {
+		produtosDTO := dto.FromModelList(produtos)
+		response := dto.ToPaginatedResponse(produtosDTO, paginationResp)
+		response.Facets = facetsResp
+		body = response
+		if !fields.IsEmpty() {
+			sparse, err = fields.SelectList(produtosDTO)
+		}
+	}
+	if err != nil {
+		return utils.EchoErrorResponse(c, errors.WrapError(err, errors.ErrInternalServer))
+	}
+
+	// Apenas os campos solicitados, sem os demais
+	if !fields.IsEmpty() {
+		body = dto.PaginatedSparseProdutoListResponse{
+			Produtos:   sparse,
+			Pagination: paginationResp,
+			Facets:     facetsResp,
+		}
+	}
+
+	// Listas não enviam Last-Modified: remover um produto não altera o updated_at
+	// dos demais, então apenas o ETag detecta todas as mudanças
+	setRepresentationHeaders(c)
+	return respondConditional(c, body, time.Time{}, h.options.CacheControlList)
+}
+
+// parseFields obtém e valida os campos solicitados (parâmetro fields)
+func parseFields(c echo.Context) (dto.FieldsRequest, error) {
+	fields := dto.GetFieldsFromQuery(c.QueryParam("fields"))
+	if err := fields.Validate(); err != nil {
+		return dto.FieldsRequest{}, errors.ErrInvalidInput.WithDetails(err.Error())
+	}
+	if !isV2(c) {
+		if err := fields.ValidateV1(); err != nil {
+			return dto.FieldsRequest{}, errors.ErrInvalidInput.WithDetails(err.Error())
+		}
+	}
+	return fields, nil
+}
+
+// parseFilter obtém os filtros da listagem a partir dos parâmetros de query
+func (h *ProdutoHandler) parseFilter(c echo.Context) (dto.FilterRequest, error) {
+	filter := dto.FilterRequest{
+		Nome:       getStringQueryEcho(c, "nome"),
+		PrecoMin:   getFloatQueryEcho(c, "precoMin"),
+		PrecoMax:   getFloatQueryEcho(c, "precoMax"),
+		Descricao:  getStringQueryEcho(c, "descricao"),
+		Deleted:    c.QueryParam("deleted"),
+		Match:      c.QueryParam("match"),
+		Expression: c.QueryParam("filter"),
+	}
+
+	// Expressões regulares do cliente podem gerar consultas custosas: só com habilitação explícita
+	if filter.Match == dto.MatchRegex && !h.options.AllowRegexMatch {
+		return dto.FilterRequest{}, errors.ErrInvalidInput.WithDetails("match=regex está desabilitado neste servidor")
+	}
+
+	// Datas inválidas são rejeitadas (e não ignoradas): na sincronização incremental,
+	// ignorar updatedSince retornaria o catálogo inteiro
+	dates := []struct {
+		key    string
+		target **time.Time
+	}{
+		{"createdFrom", &filter.CreatedFrom},
+		{"createdTo", &filter.CreatedTo},
+		{"updatedSince", &filter.UpdatedSince},
+	}
+	for _, d := range dates {
+		value, err := getTimeQueryEcho(c, d.key)
+		if err != nil {
+			return dto.FilterRequest{}, errors.ErrInvalidInput.WithDetails(err.Error())
+		}
+		*d.target = value
+	}
+	return filter, nil
+}
+
+// GetProdutosStats retorna estatísticas agregadas dos produtos
+// @Summary Estatísticas de produtos
+// @Description Retorna a contagem e a soma, média, mínimo, máximo e percentis estimados (p50, p90, p99) do preço dos produtos que correspondem aos filtros
+// @Tags produtos
+// @Produce json
+// @Param nome query string false "Filtro por nome (busca parcial, case-insensitive)"
+// @Param precoMin query number false "Preço mínimo"
+// @Param precoMax query number false "Preço máximo"
+// @Param descricao query string false "Filtro por descrição (busca parcial, case-insensitive)"
+// @Param match query string false "Modo de comparação de nome/descricao: contains (padrão, sem diferenciar maiúsculas), prefix, exact ou regex (se habilitado)" Enums(contains, prefix, exact, regex)
+// @Param deleted query string false "Produtos deletados: exclude (padrão), include ou only (lixeira). Com updatedSince o padrão é include" Enums(exclude, include, only)
+// @Param createdFrom query string false "Criados a partir desta data, inclusive (RFC3339)"
+// @Param createdTo query string false "Criados até esta data, inclusive (RFC3339)"
+// @Param updatedSince query string false "Alterados a partir desta data, inclusive (RFC3339)"
+// @Param filter query string false "Expressão de filtro RSQL/FIQL, combinada com os demais filtros. Operadores: ==, !=, =gt=, =ge=, =lt=, =le=, =in=, =out=, =like= (curinga *, no máximo 3 por valor); ; é E e , é OU (ex: preco=gt=100;nome=like=note*,descricao==x). Campos: id, nome, preco, descricao, created_at, updated_at, version"
+// @Param If-None-Match header string false "ETag de uma resposta anterior (retorna 304 se não houve mudança)"
+// @Success 200 {object} model.ProdutoStats
+// @Header 200 {string} ETag "ETag forte da resposta"
+// @Success 304
+// @Failure 400 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/stats [get]
+// GET /api/v1/produtos/stats?precoMin=100&precoMax=5000
+func (h *ProdutoHandler) GetProdutosStats(c echo.Context) error {
+	filter, err := h.parseFilter(c)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	stats, err := h.service.Stats(c.Request().Context(), filter)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	return respondConditional(c, stats, time.Time{}, h.options.CacheControlList)
+}
+
+// SearchProdutos executa a busca textual de produtos ordenada por relevância
+// @Summary Busca textual de produtos
+// @Description Busca produtos por nome e descrição usando o índice de texto (com stemming no idioma configurado), ordenando pelos mais relevantes. Aceita os mesmos filtros e a mesma paginação por página da listagem
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param q query string true "Termos buscados (máximo 200 caracteres); use \"frase exata\" ou -termo para excluir"
+// @Param page query int false "Número da página (padrão: 1)" default(1)
+// @Param pageSize query int false "Tamanho da página (padrão: 10, máximo: 100)" default(10)
+// @Param precoMin query number false "Preço mínimo"
+// @Param precoMax query number false "Preço máximo"
+// @Param deleted query string false "Produtos deletados: exclude (padrão), include ou only (lixeira)" Enums(exclude, include, only)
+// @Param filter query string false "Expressão de filtro RSQL/FIQL, combinada com os demais filtros. Operadores: ==, !=, =gt=, =ge=, =lt=, =le=, =in=, =out=, =like= (curinga *, no máximo 3 por valor); ; é E e , é OU (ex: preco=gt=100;nome=like=note*,descricao==x). Campos: id, nome, preco, descricao, created_at, updated_at, version"
+// @Param facets query string false "Facetas calculadas sobre todos os resultados da busca, separadas por vírgula" Enums(preco, nome)
+// @Param If-None-Match header string false "ETag de uma resposta anterior (retorna 304 se não houve mudança)"
+// @Success 200 {object} dto.PaginatedSearchResponse
+// @Header 200 {string} ETag "ETag forte da resposta"
+// @Success 304
+// @Failure 400 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/search [get]
+// GET /api/v1/produtos/search?q=notebook&page=1&pageSize=10
+func (h *ProdutoHandler) SearchProdutos(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	search := dto.SearchRequest{Query: c.QueryParam("q")}
+
+	// Parse de parâmetros de paginação (o cursor é repassado para ser rejeitado
+	// pelo serviço: a ordem por relevância só permite paginação por página)
+	pagination := dto.PaginationRequest{
+		Page:     getIntQueryEcho(c, "page", 1),
+		PageSize: getIntQueryEcho(c, "pageSize", 10),
+		Cursor:   c.QueryParam("cursor"),
+	}
+
+	// Parse de filtros
+	filter := dto.FilterRequest{
+		PrecoMin:   getFloatQueryEcho(c, "precoMin"),
+		PrecoMax:   getFloatQueryEcho(c, "precoMax"),
+		Deleted:    c.QueryParam("deleted"),
+		Expression: c.QueryParam("filter"),
+	}
+
+	results, paginationResp, err := h.service.Search(ctx, search, pagination, filter)
+	if err != nil {
+		// Erros da API (ex: parâmetros inválidos) mantêm o status original
+		if errors.IsAPIError(err) {
+			return utils.EchoErrorResponse(c, err)
+		}
+		return utils.EchoErrorResponse(c, errors.WrapError(err, errors.ErrDatabase))
+	}
+
+	// Facetas são calculadas sobre todos os resultados da busca, não apenas a página
+	var facetsResp *model.Facets
+	if facets := dto.GetFacetsFromQuery(c.QueryParam("facets")); !facets.IsEmpty() {
+		facetsResp, err = h.service.Facets(ctx, facets, filter, &search)
+		if err != nil {
+			return utils.EchoErrorResponse(c, err)
+		}
+	}
+
+	var body interface{}
+	if isV2(c) {
+		response := dto.FromSearchResultsV2(results, paginationResp)
+		response.Facets = facetsResp
+		body = response
+	} else {
+		response := dto.FromSearchResults(results, paginationResp)
+		response.Facets = facetsResp
+		body = response
+	}
+	setRepresentationHeaders(c)
+	return respondConditional(c, body, time.Time{}, h.options.CacheControlList)
 }
 
 // getIntQueryEcho obtém um parâmetro de query como int usando Echo
@@ -137,7 +410,22 @@ func getFloatQueryEcho(c echo.Context, key string) *float64 {
 	return &result
 }
 
+// getTimeQueryEcho obtém um parâmetro de query como data RFC3339 usando Echo (retorna nil se vazio)
+func getTimeQueryEcho(c echo.Context, key string) (*time.Time, error) {
+	value := c.QueryParam(key)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s deve estar no formato RFC3339 (ex: 2024-01-15T10:30:00Z)", key)
+	}
+	return &result, nil
+}
+
 // GetProduto obtém um produto por ID
+// Com ?fields=id,nome a resposta contém apenas os campos solicitados
+// Em /api/v2 (ou com Accept: application/vnd.produto.v2+json) inclui metadados e links
 // GET /api/produtos/{id}
 func (h *ProdutoHandler) GetProduto(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -145,6 +433,11 @@ func (h *ProdutoHandler) GetProduto(c echo.Context) error {
 		return utils.EchoErrorResponse(c, errors.ErrInvalidID)
 	}
 
+	fields, err := parseFields(c)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
 	ctx := c.Request().Context()
 	produto, err := h.service.FindByID(ctx, id)
 	if err != nil {
@@ -154,10 +447,21 @@ func (h *ProdutoHandler) GetProduto(c echo.Context) error {
 		return utils.EchoErrorResponse(c, errors.ErrProdutoNotFound)
 	}
 
-	// Converter model para DTO
-	response := dto.FromModel(produto)
+	// Converter model para DTO na representação negociada
+	response := produtoBody(c, produto)
 
-	return utils.EchoSuccessResponse(c, http.StatusOK, response)
+	if fields.IsEmpty() {
+		setRepresentationHeaders(c)
+		return respondConditional(c, response, produto.UpdatedAt, h.options.CacheControlItem)
+	}
+
+	// O ETag corresponde à representação parcial: para If-Match use o ETag da resposta completa
+	sparse, err := fields.Select(response)
+	if err != nil {
+		return utils.EchoErrorResponse(c, errors.WrapError(err, errors.ErrInternalServer))
+	}
+	setRepresentationHeaders(c)
+	return respondConditional(c, sparse, produto.UpdatedAt, h.options.CacheControlItem)
 }
 
 // CreateProduto cria um novo produto
@@ -184,9 +488,11 @@ func (h *ProdutoHandler) CreateProduto(c echo.Context) error {
 		return utils.EchoErrorResponse(c, err)
 	}
 
-	// Converter model para DTO de resposta
-	response := dto.FromModel(created)
+	// Converter model para DTO de resposta na representação negociada
+	response := produtoBody(c, created)
 
+	setETag(c, response)
+	setRepresentationHeaders(c)
 	return utils.EchoSuccessResponse(c, http.StatusCreated, response)
 }
 
@@ -213,15 +519,23 @@ func (h *ProdutoHandler) UpdateProduto(c echo.Context) error {
 	// Converter DTO para model
 	produto := request.ToModel()
 
+	// Versão esperada (controle de concorrência otimista)
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
 	ctx := c.Request().Context()
-	updated, err := h.service.Update(ctx, id, produto)
+	updated, err := h.service.Update(ctx, id, produto, expectedVersion)
 	if err != nil {
 		return utils.EchoErrorResponse(c, err)
 	}
 
-	// Converter model para DTO de resposta
-	response := dto.FromModel(updated)
+	// Converter model para DTO de resposta na representação negociada
+	response := produtoBody(c, updated)
 
+	setETag(c, response)
+	setRepresentationHeaders(c)
 	return utils.EchoSuccessResponse(c, http.StatusOK, response)
 }
 
@@ -248,15 +562,23 @@ func (h *ProdutoHandler) PatchProduto(c echo.Context) error {
 	// Converter DTO para map
 	updates := request.ToMap()
 
+	// Versão esperada (controle de concorrência otimista)
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
 	ctx := c.Request().Context()
-	updated, err := h.service.Patch(ctx, id, updates)
+	updated, err := h.service.Patch(ctx, id, updates, expectedVersion)
 	if err != nil {
 		return utils.EchoErrorResponse(c, err)
 	}
 
-	// Converter model para DTO de resposta
-	response := dto.FromModel(updated)
+	// Converter model para DTO de resposta na representação negociada
+	response := produtoBody(c, updated)
 
+	setETag(c, response)
+	setRepresentationHeaders(c)
 	return utils.EchoSuccessResponse(c, http.StatusOK, response)
 }
 
@@ -268,8 +590,76 @@ func (h *ProdutoHandler) DeleteProduto(c echo.Context) error {
 		return utils.EchoErrorResponse(c, errors.ErrInvalidID)
 	}
 
+	// Versão esperada (controle de concorrência otimista)
+	expectedVersion, err := h.expectedVersion(c, id)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	ctx := c.Request().Context()
+	if err := h.service.Delete(ctx, id, expectedVersion); err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RestoreProduto restaura um produto deletado (soft delete)
+// @Summary Restaura um produto deletado
+// @Description Desfaz o soft delete de um produto, tornando-o visível novamente
+// @Tags produtos
+// @Produce json
+// @Param id path int true "ID do produto"
+// @Success 200 {object} dto.ProdutoResponse
+// @Failure 400 {object} errors.APIError
+// @Failure 404 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/{id}/restore [post]
+// POST /api/v1/produtos/{id}/restore
+func (h *ProdutoHandler) RestoreProduto(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.EchoErrorResponse(c, errors.ErrInvalidID)
+	}
+
+	ctx := c.Request().Context()
+	restored, err := h.service.Restore(ctx, id)
+	if err != nil {
+		return utils.EchoErrorResponse(c, err)
+	}
+
+	// Converter model para DTO de resposta na representação negociada
+	response := produtoBody(c, restored)
+
+	setETag(c, response)
+	setRepresentationHeaders(c)
+	return utils.EchoSuccessResponse(c, http.StatusOK, response)
+}
+
+// PurgeProduto remove um produto definitivamente (rota administrativa)
+// @Summary Remove um produto definitivamente
+// @Description Remove o produto do banco de dados, inclusive se já estiver deletado (soft delete). Com a autenticação habilitada, requer o escopo produtos:admin (API key ou bearer token); desabilitada, requer o header X-Admin-Token
+// @Tags produtos
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Security AdminToken
+// @Param id path int true "ID do produto"
+// @Success 204
+// @Failure 400 {object} errors.APIError
+// @Failure 401 {object} errors.APIError
+// @Failure 403 {object} errors.APIError
+// @Failure 404 {object} errors.APIError
+// @Failure 500 {object} errors.APIError
+// @Router /api/v1/produtos/{id}/purge [delete]
+// DELETE /api/v1/produtos/{id}/purge
+func (h *ProdutoHandler) PurgeProduto(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return utils.EchoErrorResponse(c, errors.ErrInvalidID)
+	}
+
 	ctx := c.Request().Context()
-	if err := h.service.Delete(ctx, id); err != nil {
+	if err := h.service.Purge(ctx, id); err != nil {
 		return utils.EchoErrorResponse(c, err)
 	}
 
@@ -4,91 +4,35 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson"
 
+	"api-go-arquitetura/internal/cache"
 	"api-go-arquitetura/internal/dto"
-	apiErrors "api-go-arquitetura/internal/errors"
 	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
 	"api-go-arquitetura/internal/service"
 )
 
-// MockProdutoService é um mock do ProdutoService para testes
-type MockProdutoService struct {
-	produtos []model.Produto
-	nextID   int
-}
-
-func NewMockProdutoService() *MockProdutoService {
-	return &MockProdutoService{
-		produtos: make([]model.Produto, 0),
-		nextID:   1,
-	}
-}
-
-func (m *MockProdutoService) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
-	produto.ID = m.nextID
-	m.nextID++
-	m.produtos = append(m.produtos, produto)
-	return produto, nil
-}
-
-func (m *MockProdutoService) FindAll(ctx context.Context) ([]model.Produto, error) {
-	return m.produtos, nil
-}
-
-func (m *MockProdutoService) FindByID(ctx context.Context, id int) (model.Produto, error) {
-	for _, p := range m.produtos {
-		if p.ID == id {
-			return p, nil
-		}
-	}
-	return model.Produto{}, apiErrors.ErrProdutoNotFound
-}
-
-func (m *MockProdutoService) Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error) {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			produto.ID = id
-			m.produtos[i] = produto
-			return produto, nil
-		}
-	}
-	return model.Produto{}, apiErrors.ErrProdutoNotFound
-}
-
-func (m *MockProdutoService) Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error) {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			if nome, ok := updates["nome"].(string); ok {
-				p.Nome = nome
-			}
-			if preco, ok := updates["preco"].(float64); ok {
-				p.Preco = preco
-			}
-			m.produtos[i] = p
-			return p, nil
-		}
-	}
-	return model.Produto{}, apiErrors.ErrProdutoNotFound
-}
-
-func (m *MockProdutoService) Delete(ctx context.Context, id int) error {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			m.produtos = append(m.produtos[:i], m.produtos[i+1:]...)
-			return nil
-		}
-	}
-	return apiErrors.ErrProdutoNotFound
+// newTestService cria um ProdutoService real sobre o repositório em memória
+func newTestService() service.ProdutoService {
+	return service.NewProdutoService(repository.NewMemoryProdutoRepository(), nil)
 }
 
 func TestProdutoHandler_CreateProduto(t *testing.T) {
-	mockService := NewMockProdutoService()
-	handler := NewProdutoHandler(mockService)
+	svc := newTestService()
+	handler := NewProdutoHandler(svc)
 	e := echo.New()
 
 	t.Run("deve criar produto com dados válidos", func(t *testing.T) {
@@ -145,8 +89,8 @@ func TestProdutoHandler_CreateProduto(t *testing.T) {
 }
 
 func TestProdutoHandler_GetProduto(t *testing.T) {
-	mockService := NewMockProdutoService()
-	handler := NewProdutoHandler(mockService)
+	svc := newTestService()
+	handler := NewProdutoHandler(svc)
 	e := echo.New()
 
 	// Criar produto de teste
@@ -155,7 +99,7 @@ func TestProdutoHandler_GetProduto(t *testing.T) {
 		Preco:     3500.00,
 		Descricao: "Notebook de alta performance",
 	}
-	created, _ := mockService.Create(context.Background(), produto)
+	created, _ := svc.Create(context.Background(), produto)
 
 	t.Run("deve retornar produto existente", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/api/produtos/1", nil)
@@ -216,16 +160,16 @@ func TestProdutoHandler_GetProduto(t *testing.T) {
 }
 
 func TestProdutoHandler_GetProdutos(t *testing.T) {
-	mockService := NewMockProdutoService()
-	handler := NewProdutoHandler(mockService)
+	svc := newTestService()
+	handler := NewProdutoHandler(svc)
 	e := echo.New()
 
 	// Criar produtos de teste
-	mockService.Create(context.Background(), model.Produto{
+	svc.Create(context.Background(), model.Produto{
 		Nome:  "Notebook",
 		Preco: 3500.00,
 	})
-	mockService.Create(context.Background(), model.Produto{
+	svc.Create(context.Background(), model.Produto{
 		Nome:  "Mouse",
 		Preco: 50.00,
 	})
@@ -259,3 +203,1128 @@ func TestProdutoHandler_GetProdutos(t *testing.T) {
 	})
 }
 
+
+func TestProdutoHandler_GetProdutos_Deleted(t *testing.T) {
+	svc := newTestService()
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	ativo, _ := svc.Create(context.Background(), model.Produto{Nome: "Notebook", Preco: 3500.00})
+	deletado, _ := svc.Create(context.Background(), model.Produto{Nome: "Mouse", Preco: 50.00})
+	svc.Delete(context.Background(), deletado.ID, repository.AnyVersion)
+
+	t.Run("deve listar apenas produtos deletados com deleted=only", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?deleted=only", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := handler.GetProdutos(c); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+
+		var response dto.PaginatedProdutoListResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Erro ao decodificar resposta: %v", err)
+		}
+		if len(response.Produtos) != 1 || response.Produtos[0].ID != deletado.ID {
+			t.Fatalf("Esperado apenas o produto %d, obtido %+v", deletado.ID, response.Produtos)
+		}
+		if response.Produtos[0].DeletedAt == nil {
+			t.Error("deleted_at deveria ser exposto para produtos deletados")
+		}
+	})
+
+	t.Run("não deve listar produtos deletados por padrão", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/produtos", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := handler.GetProdutos(c); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+
+		var response dto.ProdutoListResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Erro ao decodificar resposta: %v", err)
+		}
+		if response.Total != 1 || response.Produtos[0].ID != ativo.ID {
+			t.Errorf("Esperado apenas o produto %d, obtido %+v", ativo.ID, response.Produtos)
+		}
+	})
+
+	t.Run("deve retornar erro para modo inválido", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?deleted=todos", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handler.GetProdutos(c)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+// getProduto executa GET /api/v1/produtos/{id} com os headers informados
+func getProduto(e *echo.Echo, handler *ProdutoHandler, id string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/api/v1/produtos/"+id, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	handler.GetProduto(c)
+	return rec
+}
+
+func TestProdutoHandler_IfMatch(t *testing.T) {
+	svc := newTestService()
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	svc.Create(context.Background(), model.Produto{Nome: "Notebook", Preco: 3500.00})
+	originalETag := getProduto(e, handler, "1", nil).Header().Get("ETag")
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"preco": 3000.00})
+		req := httptest.NewRequest("PATCH", "/api/v1/produtos/1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		handler.PatchProduto(c)
+		return rec
+	}
+
+	t.Run("deve aplicar alteração quando If-Match confere", func(t *testing.T) {
+		rec := patch(originalETag)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		etag := rec.Header().Get("ETag")
+		if etag == "" || etag == originalETag {
+			t.Errorf("ETag deveria mudar após a alteração, obtido %q", etag)
+		}
+		if current := getProduto(e, handler, "1", nil).Header().Get("ETag"); current != etag {
+			t.Errorf("ETag do GET (%q) deveria ser igual ao da alteração (%q)", current, etag)
+		}
+	})
+
+	t.Run("deve retornar 412 quando If-Match está desatualizado", func(t *testing.T) {
+		rec := patch(originalETag)
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusPreconditionFailed, rec.Code)
+		}
+	})
+
+	t.Run("deve retornar 412 para ETag fraco", func(t *testing.T) {
+		current := getProduto(e, handler, "1", nil).Header().Get("ETag")
+		rec := patch("W/" + current)
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusPreconditionFailed, rec.Code)
+		}
+	})
+
+	t.Run("deve aplicar alteração sem If-Match ou com *", func(t *testing.T) {
+		for _, ifMatch := range []string{"", "*"} {
+			if rec := patch(ifMatch); rec.Code != http.StatusOK {
+				t.Errorf("If-Match %q: status esperado %d, obtido %d", ifMatch, http.StatusOK, rec.Code)
+			}
+		}
+	})
+}
+
+func TestProdutoHandler_IfMatchIgnoresCache(t *testing.T) {
+	repo := repository.NewMemoryProdutoRepository()
+	svc := service.NewProdutoService(repo, cache.NewMemoryCache())
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+	ctx := context.Background()
+
+	created, _ := svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00})
+	staleETag := getProduto(e, handler, "1", nil).Header().Get("ETag") // Produto fica em cache
+
+	// Alteração feita por outra instância: o cache desta continua com a versão anterior
+	current, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 3200.00}, repository.AnyVersion)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	currentETag, _ := computeETag(dto.FromModel(current))
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/v1/produtos/1", strings.NewReader(`{"preco": 3000}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		handler.PatchProduto(c)
+		return rec
+	}
+
+	if rec := patch(staleETag); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("ETag em cache (desatualizado): status esperado %d, obtido %d", http.StatusPreconditionFailed, rec.Code)
+	}
+	if rec := patch(currentETag); rec.Code != http.StatusOK {
+		t.Errorf("ETag atual: status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestProdutoHandler_ConditionalGet(t *testing.T) {
+	svc := newTestService()
+	handler := NewProdutoHandlerWithOptions(svc, HandlerOptions{
+		CacheControlItem: "private, max-age=60",
+		CacheControlList: "no-cache",
+	})
+	e := echo.New()
+
+	svc.Create(context.Background(), model.Produto{Nome: "Notebook", Preco: 3500.00})
+
+	first := getProduto(e, handler, "1", nil)
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+
+	t.Run("deve enviar ETag, Last-Modified e Cache-Control", func(t *testing.T) {
+		if etag == "" || lastModified == "" {
+			t.Errorf("ETag e Last-Modified deveriam estar presentes: %q, %q", etag, lastModified)
+		}
+		if cc := first.Header().Get("Cache-Control"); cc != "private, max-age=60" {
+			t.Errorf("Cache-Control esperado %q, obtido %q", "private, max-age=60", cc)
+		}
+	})
+
+	t.Run("deve retornar 304 quando If-None-Match confere", func(t *testing.T) {
+		rec := getProduto(e, handler, "1", map[string]string{"If-None-Match": `"outro", W/` + etag})
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusNotModified, rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Error("Resposta 304 não deveria ter corpo")
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Error("Resposta 304 deveria repetir o ETag")
+		}
+	})
+
+	t.Run("deve retornar 304 quando If-Modified-Since não é anterior ao Last-Modified", func(t *testing.T) {
+		rec := getProduto(e, handler, "1", map[string]string{"If-Modified-Since": lastModified})
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusNotModified, rec.Code)
+		}
+	})
+
+	t.Run("If-None-Match tem precedência sobre If-Modified-Since", func(t *testing.T) {
+		rec := getProduto(e, handler, "1", map[string]string{
+			"If-None-Match":     `"outro"`,
+			"If-Modified-Since": lastModified,
+		})
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("deve retornar 200 após alteração do produto", func(t *testing.T) {
+		svc.Patch(context.Background(), 1, map[string]interface{}{"preco": 3000.00}, repository.AnyVersion)
+		rec := getProduto(e, handler, "1", map[string]string{"If-None-Match": etag})
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("lista deve retornar 304 quando If-None-Match confere", func(t *testing.T) {
+		list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/v1/produtos?page=1", nil)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			handler.GetProdutos(e.NewContext(req, rec))
+			return rec
+		}
+
+		first := list("")
+		if first.Header().Get("Last-Modified") != "" {
+			t.Error("Listas não deveriam enviar Last-Modified")
+		}
+		if rec := list(first.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusNotModified, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_GetProdutos_Match(t *testing.T) {
+	svc := newTestService()
+	svc.Create(context.Background(), model.Produto{Nome: "Notebook", Preco: 3500.00})
+	e := echo.New()
+
+	list := func(handler *ProdutoHandler, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.GetProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("match=regex deve ser rejeitado quando desabilitado", func(t *testing.T) {
+		rec := list(NewProdutoHandler(svc), "nome=%5Enote&match=regex")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("match=regex deve ser aceito quando habilitado", func(t *testing.T) {
+		options := DefaultHandlerOptions()
+		options.AllowRegexMatch = true
+		rec := list(NewProdutoHandlerWithOptions(svc, options), "nome=%5Enote&match=regex")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedProdutoListResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 1 {
+			t.Errorf("Esperado 1 produto, obtidos %d", len(response.Produtos))
+		}
+	})
+
+	t.Run("match inválido deve retornar 400", func(t *testing.T) {
+		rec := list(NewProdutoHandler(svc), "nome=note&match=fuzzy")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("caracteres especiais não devem gerar erro", func(t *testing.T) {
+		rec := list(NewProdutoHandler(svc), "nome=%28%5B")
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestProdutoHandler_SearchProdutos(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Acompanha notebook"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00, Descricao: "Notebook leve"})
+	svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00, Descricao: "Teclado mecânico"})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos/search?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.SearchProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("deve ordenar por relevância", func(t *testing.T) {
+		rec := search("q=notebooks")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedSearchResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 2 || response.Produtos[0].ID != 2 || response.Produtos[1].ID != 1 {
+			t.Fatalf("Resultados esperados [2 1], obtidos %+v", response.Produtos)
+		}
+		if response.Produtos[0].Score <= response.Produtos[1].Score {
+			t.Errorf("Score do primeiro resultado deveria ser maior: %+v", response.Produtos)
+		}
+		if response.Pagination.TotalItems != 2 {
+			t.Errorf("Total esperado 2, obtido %d", response.Pagination.TotalItems)
+		}
+	})
+
+	t.Run("deve paginar e aplicar filtros", func(t *testing.T) {
+		rec := search("q=notebook&precoMax=100&pageSize=1")
+		var response dto.PaginatedSearchResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 1 || response.Produtos[0].ID != 1 {
+			t.Errorf("Resultado esperado [1], obtido %+v", response.Produtos)
+		}
+	})
+
+	t.Run("deve retornar 400 sem q", func(t *testing.T) {
+		if rec := search("q=%20"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("deve retornar 400 com cursor", func(t *testing.T) {
+		if rec := search("q=notebook&cursor=abc"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_Facets(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00, Descricao: "Notebook leve"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook Gamer", Preco: 7500.00, Descricao: "Notebook com placa de vídeo"})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	get := func(path string, h echo.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		h(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("lista deve incluir facetas de todos os produtos filtrados", func(t *testing.T) {
+		rec := get("/api/v1/produtos?facets=preco&pageSize=1", handler.GetProdutos)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedProdutoListResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 1 {
+			t.Errorf("Esperado 1 produto na página, obtidos %d", len(response.Produtos))
+		}
+		if response.Facets == nil || response.Facets.Preco == nil {
+			t.Fatal("Facetas esperadas na resposta")
+		}
+		preco := response.Facets.Preco
+		if preco.Count != 3 || preco.Min != 50.00 || preco.Max != 7500.00 {
+			t.Errorf("Resumo inesperado: %+v", preco)
+		}
+	})
+
+	t.Run("lista sem facets não deve incluir o bloco", func(t *testing.T) {
+		rec := get("/api/v1/produtos?page=1", handler.GetProdutos)
+		var response map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&response)
+		if _, ok := response["facets"]; ok {
+			t.Error("Bloco facets não deveria estar presente")
+		}
+	})
+
+	t.Run("busca deve incluir facetas dos resultados", func(t *testing.T) {
+		rec := get("/api/v1/produtos/search?q=notebook&facets=preco", handler.SearchProdutos)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedSearchResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if response.Facets == nil || response.Facets.Preco == nil || response.Facets.Preco.Count != 2 {
+			t.Errorf("Facetas inesperadas: %+v", response.Facets)
+		}
+	})
+
+	t.Run("faceta de nome deve contar os produtos por nome", func(t *testing.T) {
+		rec := get("/api/v1/produtos?facets=nome&precoMin=100", handler.GetProdutos)
+		var response dto.PaginatedProdutoListResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if response.Facets == nil || response.Facets.Nome == nil || response.Facets.Preco != nil {
+			t.Fatalf("Apenas a faceta de nome esperada, obtida %+v", response.Facets)
+		}
+		if values := response.Facets.Nome.Values; len(values) != 2 || values[0] != (model.AttributeCount{Value: "Notebook", Count: 1}) {
+			t.Errorf("Contagens inesperadas: %+v", values)
+		}
+	})
+
+	t.Run("faceta inválida deve retornar 400", func(t *testing.T) {
+		if rec := get("/api/v1/produtos?facets=cor", handler.GetProdutos); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_GetProdutosStats(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00})
+	svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	stats := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos/stats?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.GetProdutosStats(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("deve aplicar os filtros da listagem", func(t *testing.T) {
+		rec := stats("precoMax=1000")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response model.ProdutoStats
+		json.NewDecoder(rec.Body).Decode(&response)
+		if response.Count != 2 || response.Preco.Sum != 200.00 || response.Preco.Avg != 100.00 {
+			t.Errorf("Estatísticas inesperadas: %+v", response)
+		}
+	})
+
+	t.Run("deve retornar 304 com If-None-Match", func(t *testing.T) {
+		first := stats("")
+		req := httptest.NewRequest("GET", "/api/v1/produtos/stats", nil)
+		req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+		rec := httptest.NewRecorder()
+		handler.GetProdutosStats(e.NewContext(req, rec))
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusNotModified, rec.Code)
+		}
+	})
+
+	t.Run("filtro inválido deve retornar 400", func(t *testing.T) {
+		if rec := stats("deleted=todos"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_GetProdutos_UpdatedSince(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00})
+	removido, _ := svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.GetProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	since := time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+	if err := svc.Delete(ctx, removido.ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao deletar produto: %v", err)
+	}
+
+	t.Run("deve incluir produtos deletados como marcas de remoção", func(t *testing.T) {
+		// Sincronização incremental usa a representação v2, que contém updated_at
+		req := httptest.NewRequest("GET", "/api/v1/produtos?updatedSince="+since, nil)
+		req.Header.Set("Accept", dto.MediaTypeProdutoV2)
+		rec := httptest.NewRecorder()
+		handler.GetProdutos(e.NewContext(req, rec))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedProdutoListResponseV2
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 2 {
+			t.Fatalf("Esperados 2 produtos, obtidos %d", len(response.Produtos))
+		}
+		if response.Produtos[1].DeletedAt == nil {
+			t.Error("Produto deletado deveria ter deleted_at")
+		}
+		if response.Produtos[0].UpdatedAt.IsZero() {
+			t.Error("updated_at deveria estar presente na resposta")
+		}
+	})
+
+	t.Run("data inválida deve retornar 400", func(t *testing.T) {
+		if rec := list("updatedSince=ontem"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("intervalo invertido deve retornar 400", func(t *testing.T) {
+		rec := list("createdFrom=2024-02-01T00:00:00Z&createdTo=2024-01-01T00:00:00Z")
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_GetProdutos_FilterExpression(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00, Descricao: "Notebook leve"})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	list := func(expression string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?filter="+url.QueryEscape(expression), nil)
+		rec := httptest.NewRecorder()
+		handler.GetProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("deve aplicar a expressão", func(t *testing.T) {
+		rec := list("preco=gt=100;nome=like=note*")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response dto.PaginatedProdutoListResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 1 || response.Produtos[0].Nome != "Notebook" {
+			t.Errorf("Esperado apenas o Notebook, obtido %+v", response.Produtos)
+		}
+	})
+
+	t.Run("erros devem informar a posição", func(t *testing.T) {
+		tests := []struct {
+			expression string
+			position   int
+		}{
+			{"preco=gt=100;nome", 17}, // Sintaxe
+			{"preco=gt=abc", 9},       // Tipo do valor
+			{"deleted_at==x", 0},      // Campo fora da lista
+		}
+		for _, tt := range tests {
+			rec := list(tt.expression)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("%s: status esperado %d, obtido %d", tt.expression, http.StatusBadRequest, rec.Code)
+			}
+			var response struct {
+				Code     string `json:"code"`
+				Position *int   `json:"position"`
+			}
+			json.NewDecoder(rec.Body).Decode(&response)
+			if response.Position == nil || *response.Position != tt.position {
+				t.Errorf("%s: posição esperada %d, obtida %v", tt.expression, tt.position, response.Position)
+			}
+		}
+	})
+}
+
+func TestProdutoHandler_Fields(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	created, _ := svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"})
+	svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00, Descricao: "Teclado mecânico"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00, Descricao: "Notebook leve"})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.GetProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("lista deve conter apenas os campos solicitados", func(t *testing.T) {
+		rec := list("fields=id,nome")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Produtos []map[string]interface{} `json:"produtos"`
+		}
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response.Produtos) != 3 {
+			t.Fatalf("Esperados 3 produtos, obtidos %d", len(response.Produtos))
+		}
+		for _, p := range response.Produtos {
+			if len(p) != 2 || p["id"] == nil || p["nome"] == nil {
+				t.Errorf("Esperados apenas id e nome, obtido %v", p)
+			}
+		}
+	})
+
+	t.Run("cursor deve funcionar sem o campo da ordenação", func(t *testing.T) {
+		var response struct {
+			Produtos   []map[string]interface{} `json:"produtos"`
+			Pagination dto.PaginationResponse   `json:"pagination"`
+		}
+		json.NewDecoder(list("fields=nome&sort=preco:desc&pageSize=2").Body).Decode(&response)
+		if response.Pagination.NextCursor == "" {
+			t.Fatal("NextCursor esperado")
+		}
+		json.NewDecoder(list("fields=nome&sort=preco:desc&pageSize=2&cursor=" + response.Pagination.NextCursor).Body).Decode(&response)
+		if len(response.Produtos) != 1 || response.Produtos[0]["nome"] != "Mouse" {
+			t.Errorf("Próxima página esperada [Mouse], obtida %v", response.Produtos)
+		}
+	})
+
+	t.Run("cursor com operador injetado deve retornar 400", func(t *testing.T) {
+		crafted, err := dto.Cursor{
+			Keys:       []string{"preco", "id"},
+			Directions: []int{-1, 1},
+			Values:     []interface{}{bson.M{"$ne": nil}, 0},
+		}.Encode()
+		if err != nil {
+			t.Fatalf("Erro ao codificar cursor: %v", err)
+		}
+		if rec := list("sort=preco:desc&cursor=" + crafted); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("detalhe deve conter apenas os campos solicitados", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/produtos/1?fields=preco", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(created.ID))
+		handler.GetProduto(c)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var response map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&response)
+		if len(response) != 1 || response["preco"] != 50.00 {
+			t.Errorf("Esperado apenas preco, obtido %v", response)
+		}
+	})
+
+	t.Run("campo inválido deve retornar 400", func(t *testing.T) {
+		if rec := list("fields=id,senha"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("campo exclusivo da v2 deve retornar 400 na v1", func(t *testing.T) {
+		if rec := list("fields=id,updated_at"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_RepresentationV2(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	created, _ := svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"})
+	handler := NewProdutoHandler(svc)
+
+	// Mesmos grupos de api.NewRouter
+	e := echo.New()
+	v1 := e.Group("/api/v1")
+	v1.GET("/produtos", handler.GetProdutos)
+	v1.GET("/produtos/:id", handler.GetProduto)
+	v2 := e.Group("/api/v2", RepresentationV2())
+	v2.GET("/produtos", handler.GetProdutos)
+	v2.GET("/produtos/:id", handler.GetProduto)
+	v2.PATCH("/produtos/:id", handler.PatchProduto)
+
+	serve := func(method, path, accept string, headers map[string]string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	path := "/produtos/" + strconv.Itoa(created.ID)
+
+	t.Run("v1 deve manter a representação atual", func(t *testing.T) {
+		rec := serve("GET", "/api/v1"+path, "", nil, nil)
+		// Representação v1 original: sem timestamps nem versão (deleted_at apenas em produtos deletados)
+		expected := []byte(fmt.Sprintf(`{"id":%d,"nome":"Mouse","preco":50,"descricao":"Mouse sem fio"}`, created.ID))
+		if got := bytes.TrimSpace(rec.Body.Bytes()); !bytes.Equal(got, expected) {
+			t.Errorf("Corpo esperado %s, obtido %s", expected, got)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+			t.Errorf("Content-Type esperado application/json, obtido %q", ct)
+		}
+		if vary := rec.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Vary esperado Accept, obtido %q", vary)
+		}
+	})
+
+	t.Run("v2 deve incluir deleted_at e links", func(t *testing.T) {
+		rec := serve("GET", "/api/v2"+path, "", nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != dto.MediaTypeProdutoV2 {
+			t.Errorf("Content-Type esperado %s, obtido %q", dto.MediaTypeProdutoV2, ct)
+		}
+		var response map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&response)
+		if deletedAt, ok := response["deleted_at"]; !ok || deletedAt != nil {
+			t.Errorf("deleted_at esperado null, obtido %v", response)
+		}
+		links, _ := response["links"].(map[string]interface{})
+		if links["self"] != "/api/v2"+path || links["collection"] != "/api/v2/produtos" {
+			t.Errorf("Links inesperados: %v", links)
+		}
+	})
+
+	t.Run("Accept deve negociar a v2 nas rotas v1", func(t *testing.T) {
+		negotiated := serve("GET", "/api/v1"+path, "application/json;q=0.5, "+dto.MediaTypeProdutoV2, nil, nil)
+		routed := serve("GET", "/api/v2"+path, "", nil, nil)
+		if negotiated.Body.String() != routed.Body.String() {
+			t.Errorf("Corpo negociado %s diferente do de /api/v2 %s", negotiated.Body.String(), routed.Body.String())
+		}
+		if ct := negotiated.Header().Get("Content-Type"); ct != dto.MediaTypeProdutoV2 {
+			t.Errorf("Content-Type esperado %s, obtido %q", dto.MediaTypeProdutoV2, ct)
+		}
+
+		rejected := serve("GET", "/api/v1"+path, dto.MediaTypeProdutoV2+";q=0, application/json", nil, nil)
+		if ct := rejected.Header().Get("Content-Type"); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+			t.Errorf("q=0 não deveria selecionar a v2, Content-Type obtido %q", ct)
+		}
+	})
+
+	t.Run("lista v2 deve ser sempre paginada", func(t *testing.T) {
+		var response dto.PaginatedProdutoListResponseV2
+		json.NewDecoder(serve("GET", "/api/v2/produtos", "", nil, nil).Body).Decode(&response)
+		if response.Pagination.TotalItems != 1 || len(response.Produtos) != 1 || response.Produtos[0].Links.Self != "/api/v2"+path {
+			t.Errorf("Resposta paginada v2 inesperada: %+v", response)
+		}
+	})
+
+	t.Run("If-Match deve aceitar o ETag da representação v2", func(t *testing.T) {
+		etag := serve("GET", "/api/v2"+path, "", nil, nil).Header().Get("ETag")
+		body, _ := json.Marshal(map[string]interface{}{"preco": 45.00})
+		rec := serve("PATCH", "/api/v2"+path, "", map[string]string{"If-Match": etag}, body)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("ETag") != serve("GET", "/api/v2"+path, "", nil, nil).Header().Get("ETag") {
+			t.Error("ETag da alteração deveria ser igual ao do GET v2")
+		}
+	})
+}
+
+func TestProdutoHandler_BatchProdutos(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	mouse, _ := svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00})
+	teclado, _ := svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	batch := func(body string) (*httptest.ResponseRecorder, dto.BatchResponse) {
+		req := httptest.NewRequest("POST", "/api/v1/produtos/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.BatchProdutos(e.NewContext(req, rec))
+		var response dto.BatchResponse
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		return rec, response
+	}
+	statuses := func(response dto.BatchResponse) []int {
+		result := make([]int, 0, len(response.Results))
+		for _, r := range response.Results {
+			result = append(result, r.Status)
+		}
+		return result
+	}
+
+	t.Run("deve retornar o resultado de cada operação", func(t *testing.T) {
+		rec, response := batch(`{"operations": [
+			{"op": "create", "produto": {"nome": "Webcam", "preco": 300}},
+			{"op": "patch", "id": ` + strconv.Itoa(mouse.ID) + `, "produto": {"preco": 45}},
+			{"op": "create", "produto": {"nome": "", "preco": 10}},
+			{"op": "delete", "id": 9999},
+			{"op": "update", "id": ` + strconv.Itoa(teclado.ID) + `, "version": 99, "produto": {"nome": "Teclado", "preco": 120}},
+			{"op": "rename", "id": 1}
+		]}`)
+		if rec.Code != http.StatusMultiStatus {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusMultiStatus, rec.Code, rec.Body.String())
+		}
+		expected := []int{201, 200, 422, 404, 412, 400}
+		if got := statuses(response); len(got) != len(expected) {
+			t.Fatalf("Status dos itens esperados %v, obtidos %v", expected, got)
+		} else {
+			for i := range expected {
+				if got[i] != expected[i] {
+					t.Errorf("Status dos itens esperados %v, obtidos %v", expected, got)
+					break
+				}
+			}
+		}
+		if response.Succeeded != 2 || response.Failed != 4 {
+			t.Errorf("Esperados 2 sucessos e 4 falhas, obtidos %d e %d", response.Succeeded, response.Failed)
+		}
+		if response.Results[0].ID == 0 || response.Results[0].Produto == nil {
+			t.Errorf("Create deveria retornar o produto criado: %+v", response.Results[0])
+		}
+		if response.Results[3].Error == nil || response.Results[3].Error.Code != "PRODUTO_NOT_FOUND" {
+			t.Errorf("Esperado código PRODUTO_NOT_FOUND, obtido %+v", response.Results[3].Error)
+		}
+		if produto, _ := svc.FindByID(ctx, mouse.ID); produto.Preco != 45 {
+			t.Errorf("Patch do lote deveria ser aplicado, preço %.2f", produto.Preco)
+		}
+	})
+
+	t.Run("lote atômico deve ser desfeito quando uma operação falha", func(t *testing.T) {
+		before, _ := svc.FindAll(ctx)
+		rec, response := batch(`{"atomic": true, "operations": [
+			{"op": "create", "produto": {"nome": "Monitor", "preco": 1500}},
+			{"op": "delete", "id": 9999}
+		]}`)
+		if rec.Code != http.StatusMultiStatus {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusMultiStatus, rec.Code, rec.Body.String())
+		}
+		if got := statuses(response); len(got) != 2 || got[0] != http.StatusFailedDependency || got[1] != http.StatusNotFound {
+			t.Errorf("Status dos itens esperados [424 404], obtidos %v", got)
+		}
+		if after, _ := svc.FindAll(ctx); len(after) != len(before) {
+			t.Errorf("Nenhum produto deveria ser criado: %d antes, %d depois", len(before), len(after))
+		}
+	})
+
+	t.Run("lote atômico com operação inválida não deve ser executado", func(t *testing.T) {
+		_, response := batch(`{"atomic": true, "operations": [
+			{"op": "delete", "id": ` + strconv.Itoa(teclado.ID) + `},
+			{"op": "patch", "id": ` + strconv.Itoa(teclado.ID) + `, "produto": {"preco": -1}}
+		]}`)
+		if got := statuses(response); len(got) != 2 || got[0] != http.StatusFailedDependency || got[1] != http.StatusUnprocessableEntity {
+			t.Errorf("Status dos itens esperados [424 422], obtidos %v", got)
+		}
+		if _, err := svc.FindByID(ctx, teclado.ID); err != nil {
+			t.Errorf("Produto não deveria ser deletado: %v", err)
+		}
+	})
+
+	t.Run("deve rejeitar lote vazio", func(t *testing.T) {
+		if rec, _ := batch(`{"operations": []}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_ImportProdutos(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	mouse, _ := svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00})
+	deletado, _ := svc.Create(ctx, model.Produto{Nome: "Cabo", Preco: 10.00})
+	svc.Delete(ctx, deletado.ID, repository.AnyVersion)
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	importFile := func(query, contentType, body string) (*httptest.ResponseRecorder, dto.ImportReport) {
+		req := httptest.NewRequest("POST", "/api/v1/produtos/import?"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		handler.ImportProdutos(e.NewContext(req, rec))
+		var report dto.ImportReport
+		json.Unmarshal(rec.Body.Bytes(), &report)
+		return rec, report
+	}
+
+	csv := "nome,preco,descricao,id\n" +
+		"Notebook,3500,Leve,\n" +
+		",10,Sem nome,\n" +
+		"Mouse,45,Atualizado," + strconv.Itoa(mouse.ID) + "\n" +
+		"Teclado,abc,,\n" +
+		"Monitor,1500,,9999\n" +
+		"Cabo,12,," + strconv.Itoa(deletado.ID) + "\n"
+
+	var dryRunReport dto.ImportReport
+	t.Run("dryRun deve validar sem gravar", func(t *testing.T) {
+		rec, report := importFile("dryRun=true", "text/csv", csv)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if !report.DryRun || report.Total != 6 || report.Accepted != 3 || report.Created != 2 || report.Updated != 1 || report.Rejected != 3 {
+			t.Errorf("Relatório inesperado: %+v", report)
+		}
+		if produtos, _ := svc.FindAll(ctx); len(produtos) != 1 {
+			t.Errorf("Nenhum produto deveria ser gravado, total %d", len(produtos))
+		}
+		dryRunReport = report
+	})
+
+	t.Run("CSV deve criar, fazer upsert e relatar as linhas rejeitadas", func(t *testing.T) {
+		rec, report := importFile("", "text/csv", csv)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		if report.Total != 6 || report.Accepted != 3 || report.Created != 2 || report.Updated != 1 || report.Rejected != 3 {
+			t.Errorf("Relatório inesperado: %+v", report)
+		}
+		lines := make([]int, 0, len(report.Errors))
+		for _, rowErr := range report.Errors {
+			lines = append(lines, rowErr.Line)
+		}
+		if len(lines) != 3 || lines[0] != 3 || lines[1] != 5 || lines[2] != 7 {
+			t.Errorf("Linhas rejeitadas esperadas [3 5 7], obtidas %v", lines)
+		}
+		if rowErr := report.Errors[2].Error; rowErr.Code != "PRODUTO_NOT_FOUND" || !strings.Contains(rowErr.Details, "deletado") {
+			t.Errorf("Esperado PRODUTO_NOT_FOUND para produto deletado, obtido %+v", rowErr)
+		}
+
+		// O dryRun relata o mesmo resultado da importação
+		report.DryRun = true
+		if !reflect.DeepEqual(report, dryRunReport) {
+			t.Errorf("Relatório do dryRun diverge da importação:\n%+v\n%+v", dryRunReport, report)
+		}
+
+		if produto, err := svc.FindByID(ctx, 9999); err != nil || produto.Nome != "Monitor" {
+			t.Errorf("O produto 9999 deveria ser criado: %+v (%v)", produto, err)
+		}
+		if produto, _ := svc.FindByID(ctx, mouse.ID); produto.Preco != 45 || produto.Descricao != "Atualizado" {
+			t.Errorf("Produto deveria ser atualizado: %+v", produto)
+		}
+	})
+
+	t.Run("reimportar não deve duplicar linhas com id", func(t *testing.T) {
+		_, report := importFile("", "text/csv", csv)
+		if report.Accepted != 3 || report.Created != 1 || report.Updated != 2 {
+			t.Errorf("Relatório inesperado: %+v", report)
+		}
+		if produtos, _ := svc.FindAll(ctx); len(produtos) != 4 {
+			t.Errorf("Esperados 4 produtos (Notebook duas vezes, Mouse e Monitor), obtidos %d", len(produtos))
+		}
+	})
+
+	t.Run("NDJSON deve ser importado", func(t *testing.T) {
+		body := `{"nome": "Webcam", "preco": 300}` + "\n" + `{"nome": "Cabo"}` + "\n"
+		_, report := importFile("", "application/x-ndjson", body)
+		if report.Accepted != 1 || report.Created != 1 || report.Rejected != 1 || report.Errors[0].Line != 2 {
+			t.Errorf("Relatório inesperado: %+v", report)
+		}
+	})
+
+	t.Run("deve estender o ReadTimeout do servidor", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(echo.New())
+		server.Config.ReadTimeout = 50 * time.Millisecond
+		server.Config.Handler.(*echo.Echo).POST("/import", handler.ImportProdutos)
+		server.Start()
+		defer server.Close()
+
+		// Arquivo enviado mais devagar que o ReadTimeout
+		body, writer := io.Pipe()
+		go func() {
+			io.WriteString(writer, "nome,preco\nCaneta,5\n")
+			time.Sleep(100 * time.Millisecond)
+			io.WriteString(writer, "Lápis,2\n")
+			writer.Close()
+		}()
+		resp, err := http.Post(server.URL+"/import", "text/csv", body)
+		if err != nil {
+			t.Fatalf("Erro na requisição: %v", err)
+		}
+		defer resp.Body.Close()
+		var report dto.ImportReport
+		json.NewDecoder(resp.Body).Decode(&report)
+		if resp.StatusCode != http.StatusOK || report.Created != 2 {
+			t.Errorf("Esperados 2 produtos criados, obtido %d: %+v", resp.StatusCode, report)
+		}
+	})
+
+	t.Run("deve rejeitar Content-Type não suportado e cabeçalho inválido", func(t *testing.T) {
+		if rec, _ := importFile("", "application/json", "[]"); rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusUnsupportedMediaType, rec.Code)
+		}
+		if rec, _ := importFile("", "text/csv", "nome,sku\n"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestProdutoHandler_ExportProdutos(t *testing.T) {
+	svc := newTestService()
+	ctx := context.Background()
+	svc.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"})
+	svc.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150.00, Descricao: "Teclado, mecânico"})
+	svc.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00})
+	handler := NewProdutoHandler(svc)
+	e := echo.New()
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/produtos/export?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ExportProdutos(e.NewContext(req, rec))
+		return rec
+	}
+
+	t.Run("JSON deve respeitar filtros e ordenação", func(t *testing.T) {
+		rec := export("precoMax=1000&sort=preco:desc")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status esperado %d, obtido %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+		}
+		var produtos []dto.ProdutoResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &produtos); err != nil {
+			t.Fatalf("JSON inválido: %v", err)
+		}
+		if len(produtos) != 2 || produtos[0].Nome != "Teclado" || produtos[1].Nome != "Mouse" {
+			t.Errorf("Esperados [Teclado Mouse], obtidos %+v", produtos)
+		}
+	})
+
+	t.Run("JSON sem resultados deve ser um array vazio", func(t *testing.T) {
+		if body := strings.TrimSpace(export("precoMin=10000").Body.String()); body != "[]" {
+			t.Errorf("Esperado [], obtido %s", body)
+		}
+	})
+
+	t.Run("NDJSON deve ter um produto por linha", func(t *testing.T) {
+		rec := export("format=ndjson")
+		if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type inesperado: %q", ct)
+		}
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Esperadas 3 linhas, obtidas %d", len(lines))
+		}
+		var first dto.ProdutoResponse
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.Nome != "Mouse" {
+			t.Errorf("Primeira linha inesperada: %s (%v)", lines[0], err)
+		}
+	})
+
+	t.Run("CSV exportado deve ser reimportável", func(t *testing.T) {
+		rec := export("format=csv&nome=teclado")
+		if !strings.HasPrefix(rec.Body.String(), "id,nome,preco,descricao,created_at,updated_at,deleted_at,version\n") {
+			t.Fatalf("Cabeçalho CSV inesperado: %s", rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), `,Teclado,150,"Teclado, mecânico",`) {
+			t.Errorf("Linha CSV inesperada: %s", rec.Body.String())
+		}
+
+		req := httptest.NewRequest("POST", "/api/v1/produtos/import", strings.NewReader(rec.Body.String()))
+		req.Header.Set("Content-Type", "text/csv")
+		importRec := httptest.NewRecorder()
+		handler.ImportProdutos(e.NewContext(req, importRec))
+		var report dto.ImportReport
+		json.Unmarshal(importRec.Body.Bytes(), &report)
+		if report.Updated != 1 || report.Rejected != 0 {
+			t.Errorf("Reimportação deveria atualizar o produto: %s", importRec.Body.String())
+		}
+	})
+
+	t.Run("deve estender o WriteTimeout do servidor", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(echo.New())
+		server.Config.WriteTimeout = 50 * time.Millisecond
+		server.Config.Handler.(*echo.Echo).GET("/export", func(c echo.Context) error {
+			// Exportação mais longa que o WriteTimeout
+			time.Sleep(100 * time.Millisecond)
+			return handler.ExportProdutos(c)
+		})
+		server.Start()
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/export?format=ndjson")
+		if err != nil {
+			t.Fatalf("Erro na requisição: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Resposta interrompida: %v", err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(body)), "\n"); len(lines) != 3 {
+			t.Errorf("Esperadas 3 linhas, obtidas %d: %s", len(lines), body)
+		}
+	})
+
+	t.Run("deve rejeitar formato e filtros inválidos", func(t *testing.T) {
+		for _, query := range []string{"format=xlsx", "format=csv&deleted=todos", "sort=inexistente"} {
+			rec := export(query)
+			if rec.Code == http.StatusOK {
+				t.Errorf("%s: resposta de erro esperada, obtido %d", query, rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+				t.Errorf("%s: erro deveria ser JSON, Content-Type %q", query, ct)
+			}
+		}
+	})
+}
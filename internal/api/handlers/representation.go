@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/model"
+)
+
+// contextKeyRepresentation guarda no contexto do Echo a representação fixada pelo grupo de rotas
+const contextKeyRepresentation = "produto_representation"
+
+// Headers HTTP usados na negociação de conteúdo
+const (
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
+	headerVary        = "Vary"
+)
+
+// RepresentationV2 fixa a representação v2 dos produtos para todas as rotas do grupo
+// (usado em /api/v2), independentemente do header Accept
+func RepresentationV2() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(contextKeyRepresentation, dto.MediaTypeProdutoV2)
+			return next(c)
+		}
+	}
+}
+
+// isV2 verifica se a resposta deve usar a representação v2: pelo grupo de rotas ou,
+// nas rotas /api/v1 e /api, pelo header Accept: application/vnd.produto.v2+json
+// Sem o header (ou com outros media types) a representação v1 é mantida
+func isV2(c echo.Context) bool {
+	if representation, ok := c.Get(contextKeyRepresentation).(string); ok {
+		return representation == dto.MediaTypeProdutoV2
+	}
+	return acceptsMediaType(c.Request().Header.Get(headerAccept), dto.MediaTypeProdutoV2)
+}
+
+// acceptsMediaType verifica se o header Accept inclui o media type (com q diferente de zero)
+// Curingas como */* não selecionam a v2: ela precisa ser pedida explicitamente
+func acceptsMediaType(accept, mediaType string) bool {
+	for _, item := range strings.Split(accept, ",") {
+		params := strings.Split(item, ";")
+		if !strings.EqualFold(strings.TrimSpace(params[0]), mediaType) {
+			continue
+		}
+		rejected := false
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				value = strings.TrimRight(strings.TrimSpace(value), "0")
+				rejected = value == "" || value == "0." || value == "0"
+			}
+		}
+		if !rejected {
+			return true
+		}
+	}
+	return false
+}
+
+// setRepresentationHeaders ajusta os headers de uma resposta de sucesso com produtos:
+// Content-Type da v2 quando negociada e Vary: Accept nas rotas em que o header
+// Accept escolhe a representação, para que caches não misturem as versões
+// Deve ser chamada apenas antes de respostas de sucesso: erros continuam em application/json
+func setRepresentationHeaders(c echo.Context) {
+	header := c.Response().Header()
+	if _, fixed := c.Get(contextKeyRepresentation).(string); !fixed {
+		header.Add(headerVary, headerAccept)
+	}
+	if isV2(c) {
+		header.Set(headerContentType, dto.MediaTypeProdutoV2)
+	}
+}
+
+// produtoBody converte o produto para a representação negociada
+func produtoBody(c echo.Context, p model.Produto) interface{} {
+	if isV2(c) {
+		return dto.FromModelV2(p)
+	}
+	return dto.FromModel(p)
+}
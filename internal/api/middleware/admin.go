@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/auth"
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/utils"
+)
+
+// AdminTokenHeader é o header HTTP usado para autenticar operações administrativas
+const AdminTokenHeader = "X-Admin-Token"
+
+var adminToken string
+
+// SetAdminToken configura o token exigido pelas rotas administrativas
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// AdminMiddleware restringe a rota a quem apresentar o token administrativo
+// Sem token configurado, as rotas administrativas ficam desabilitadas (403)
+func AdminMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if adminToken == "" {
+				return utils.EchoErrorResponse(c, errors.ErrForbidden.WithDetails("Operações administrativas desabilitadas (ADMIN_TOKEN não configurado)"))
+			}
+
+			token := c.Request().Header.Get(AdminTokenHeader)
+			if token == "" {
+				return utils.EchoErrorResponse(c, errors.ErrUnauthorized.WithDetailsf("Header %s é obrigatório", AdminTokenHeader))
+			}
+
+			// Comparação em tempo constante para não vazar o token por timing
+			if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				logger.WithFields(map[string]interface{}{
+					"path":       c.Request().URL.Path,
+					"method":     c.Request().Method,
+					"request_id": GetRequestID(c),
+				}).Warn("Token administrativo inválido")
+				return utils.EchoErrorResponse(c, errors.ErrForbidden.WithDetails("Token administrativo inválido"))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin restringe uma rota administrativa: com a autenticação habilitada, ao
+// escopo produtos:admin; desabilitada, ao token administrativo (ver AdminMiddleware)
+func RequireAdmin() echo.MiddlewareFunc {
+	scope := RequireScope(auth.ScopeProdutosAdmin)
+	token := AdminMiddleware()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withScope, withToken := scope(next), token(next)
+		return func(c echo.Context) error {
+			if authEnabled() {
+				return withScope(c)
+			}
+			return withToken(c)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/auth"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	repo := repository.NewMemoryAPIKeyRepository()
+	for _, key := range []model.APIKey{
+		{ID: "escrita", KeyHash: auth.HashAPIKey("chave-escrita"), Scopes: []string{auth.ScopeProdutosWrite}},
+		{ID: "admin", KeyHash: auth.HashAPIKey("chave-admin"), Scopes: []string{auth.ScopeProdutosAdmin}},
+	} {
+		if err := repo.Save(context.Background(), key); err != nil {
+			t.Fatalf("Erro ao salvar chave: %v", err)
+		}
+	}
+	defer SetAuthenticator(nil)
+	defer SetAdminToken("")
+
+	e := echo.New()
+	e.Use(AuthMiddleware())
+	e.DELETE("/produtos/1/purge", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, RequireAdmin())
+
+	purge := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/produtos/1/purge", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("com autenticação, exige apenas o escopo admin", func(t *testing.T) {
+		SetAuthenticator(auth.NewAPIKeyAuthenticator(repo, 0))
+		SetAdminToken("")
+		tests := []struct {
+			name     string
+			header   string
+			value    string
+			wantCode int
+		}{
+			{"chave admin sem X-Admin-Token", APIKeyHeader, "chave-admin", http.StatusNoContent},
+			{"chave sem escopo admin", APIKeyHeader, "chave-escrita", http.StatusForbidden},
+			{"X-Admin-Token sem credencial", AdminTokenHeader, "token", http.StatusUnauthorized},
+		}
+		for _, tt := range tests {
+			if code := purge(tt.header, tt.value); code != tt.wantCode {
+				t.Errorf("%s: status esperado %d, obtido %d", tt.name, tt.wantCode, code)
+			}
+		}
+	})
+
+	t.Run("sem autenticação, exige o X-Admin-Token", func(t *testing.T) {
+		SetAuthenticator(nil)
+		SetAdminToken("token")
+		if code := purge(AdminTokenHeader, "token"); code != http.StatusNoContent {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusNoContent, code)
+		}
+		if code := purge("", ""); code != http.StatusUnauthorized {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusUnauthorized, code)
+		}
+	})
+}
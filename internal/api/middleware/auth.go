@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/auth"
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/utils"
+)
+
+// Esquemas do header Authorization
+const (
+	authSchemeAPIKey = "ApiKey"
+	authSchemeBearer = "Bearer"
+)
+
+// authErrorKey guarda a falha de autenticação até uma rota exigir escopo
+const authErrorKey contextKey = "auth_error"
+
+var (
+	apiKeyAuthenticator auth.Authenticator
+	tokenAuthenticator  auth.Authenticator
+)
+
+// SetAuthenticator configura o autenticador de API keys (nil desabilita as API keys)
+func SetAuthenticator(authenticator auth.Authenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// SetTokenAuthenticator configura o autenticador de bearer tokens (nil desabilita os tokens)
+func SetTokenAuthenticator(authenticator auth.Authenticator) {
+	tokenAuthenticator = authenticator
+}
+
+// authEnabled informa se algum autenticador está configurado
+func authEnabled() bool {
+	return apiKeyAuthenticator != nil || tokenAuthenticator != nil
+}
+
+// AuthMiddleware identifica o cliente pela API key (header X-API-Key ou
+// Authorization: ApiKey <chave>) ou pelo token JWT (Authorization: Bearer <token>),
+// registrando-o no contexto
+// Requisições sem credencial seguem anônimas e credenciais inválidas só são
+// rejeitadas pelas rotas que exigem escopo (ver RequireScope), para que rotas
+// públicas (ex: /health) continuem acessíveis e o rate limit ainda se aplique
+func AuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !authEnabled() {
+				return next(c)
+			}
+
+			authenticator, credential, invalidMessage := credentialFromRequest(c)
+			if credential == "" {
+				return next(c)
+			}
+
+			principal, err := authenticator.Authenticate(c.Request().Context(), credential)
+			if err != nil {
+				if !stderrors.Is(err, auth.ErrInvalidCredentials) {
+					logger.WithFields(map[string]interface{}{
+						"error":      err.Error(),
+						"request_id": GetRequestID(c),
+					}).Error("Erro ao verificar credenciais")
+					err = errors.ErrInternalServer.WithDetails("Não foi possível verificar as credenciais")
+				} else {
+					logger.WithFields(map[string]interface{}{
+						"reason":     err.Error(),
+						"request_id": GetRequestID(c),
+					}).Debug("Credenciais rejeitadas")
+					err = errors.ErrUnauthorized.WithDetails(invalidMessage)
+				}
+				c.Set(string(authErrorKey), err)
+				return next(c)
+			}
+
+			c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), principal)))
+			SetSubject(c, principal.Subject)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope restringe a rota a clientes autenticados com o escopo informado
+// Com a autenticação desabilitada, a rota continua anônima
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !authEnabled() {
+				return next(c)
+			}
+
+			if err, ok := c.Get(string(authErrorKey)).(error); ok {
+				setAuthenticateHeader(c)
+				return utils.EchoErrorResponse(c, err)
+			}
+
+			principal, ok := auth.PrincipalFromContext(c.Request().Context())
+			if !ok {
+				setAuthenticateHeader(c)
+				return utils.EchoErrorResponse(c, errors.ErrUnauthorized.WithDetails("Credenciais ausentes"))
+			}
+			if !principal.HasScope(scope) {
+				return utils.EchoErrorResponse(c, errors.ErrForbidden.WithDetailsf("Escopo %s necessário", scope))
+			}
+			return next(c)
+		}
+	}
+}
+
+// GetPrincipal extrai o cliente autenticado do contexto da requisição
+func GetPrincipal(c echo.Context) (auth.Principal, bool) {
+	return auth.PrincipalFromContext(c.Request().Context())
+}
+
+// credentialFromRequest extrai a credencial da requisição e o autenticador
+// responsável por ela, com a mensagem para credenciais inválidas; esquemas sem
+// autenticador configurado são tratados como ausência de credencial
+func credentialFromRequest(c echo.Context) (auth.Authenticator, string, string) {
+	if apiKeyAuthenticator != nil {
+		if key := apiKeyFromRequest(c); key != "" {
+			return apiKeyAuthenticator, key, "API key inválida ou revogada"
+		}
+	}
+	if tokenAuthenticator != nil {
+		scheme, token, found := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+		if found && strings.EqualFold(scheme, authSchemeBearer) {
+			if token = strings.TrimSpace(token); token != "" {
+				return tokenAuthenticator, token, "Token inválido ou expirado"
+			}
+		}
+	}
+	return nil, "", ""
+}
+
+// apiKeyFromRequest extrai a API key dos headers da requisição
+func apiKeyFromRequest(c echo.Context) string {
+	if key := c.Request().Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	scheme, credential, found := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+	if found && strings.EqualFold(scheme, authSchemeAPIKey) {
+		return strings.TrimSpace(credential)
+	}
+	return ""
+}
+
+// setAuthenticateHeader informa os esquemas de autenticação aceitos (401)
+func setAuthenticateHeader(c echo.Context) {
+	header := c.Response().Header()
+	if tokenAuthenticator != nil {
+		header.Add(echo.HeaderWWWAuthenticate, authSchemeBearer)
+	}
+	if apiKeyAuthenticator != nil {
+		header.Add(echo.HeaderWWWAuthenticate, authSchemeAPIKey)
+	}
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/auth"
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	repo := repository.NewMemoryAPIKeyRepository()
+	for _, key := range []model.APIKey{
+		{ID: "leitura", KeyHash: auth.HashAPIKey("chave-leitura"), Scopes: []string{auth.ScopeProdutosRead}},
+		{ID: "escrita", KeyHash: auth.HashAPIKey("chave-escrita"), Scopes: []string{auth.ScopeProdutosWrite}},
+	} {
+		if err := repo.Save(context.Background(), key); err != nil {
+			t.Fatalf("Erro ao salvar chave: %v", err)
+		}
+	}
+	SetAuthenticator(auth.NewAPIKeyAuthenticator(repo, 0))
+	defer SetAuthenticator(nil)
+
+	e := echo.New()
+	e.Use(AuthMiddleware())
+	ok := func(c echo.Context) error {
+		return c.String(http.StatusOK, GetSubject(c))
+	}
+	e.GET("/health", ok)
+	e.GET("/produtos", ok, RequireScope(auth.ScopeProdutosRead))
+	e.POST("/produtos", ok, RequireScope(auth.ScopeProdutosWrite))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   string
+		value    string
+		wantCode int
+		wantErr  string
+	}{
+		{"rota pública sem credencial", http.MethodGet, "/health", "", "", http.StatusOK, ""},
+		{"rota pública com chave inválida", http.MethodGet, "/health", APIKeyHeader, "errada", http.StatusOK, ""},
+		{"sem credencial", http.MethodGet, "/produtos", "", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"chave inválida", http.MethodGet, "/produtos", APIKeyHeader, "errada", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"chave com escopo", http.MethodGet, "/produtos", APIKeyHeader, "chave-leitura", http.StatusOK, ""},
+		{"header Authorization", http.MethodGet, "/produtos", echo.HeaderAuthorization, "ApiKey chave-leitura", http.StatusOK, ""},
+		{"esquema desconhecido", http.MethodGet, "/produtos", echo.HeaderAuthorization, "Basic chave-leitura", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"escopo insuficiente", http.MethodPost, "/produtos", APIKeyHeader, "chave-leitura", http.StatusForbidden, "FORBIDDEN"},
+		{"escopo superior", http.MethodGet, "/produtos", APIKeyHeader, "chave-escrita", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Status esperado %d, obtido %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if tt.wantErr == "" {
+				return
+			}
+			var apiErr errors.APIError
+			if err := json.Unmarshal(rec.Body.Bytes(), &apiErr); err != nil || apiErr.Code != tt.wantErr {
+				t.Errorf("Erro esperado %s, obtido %s (%v)", tt.wantErr, rec.Body.String(), err)
+			}
+			if tt.wantCode == http.StatusUnauthorized && rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+				t.Error("Header WWW-Authenticate ausente")
+			}
+		})
+	}
+
+	t.Run("deve registrar o sujeito autenticado", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/produtos", nil)
+		req.Header.Set(APIKeyHeader, "chave-leitura")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Body.String() != "api_key:leitura" {
+			t.Errorf("Sujeito inesperado: %q", rec.Body.String())
+		}
+	})
+
+	t.Run("deve autenticar bearer tokens JWT", func(t *testing.T) {
+		secret := []byte("segredo-de-desenvolvimento-com-32-bytes!")
+		SetTokenAuthenticator(auth.NewJWTAuthenticator(auth.JWTOptions{HS256Secret: secret}))
+		defer SetTokenAuthenticator(nil)
+
+		sign := func(claims map[string]interface{}) string {
+			header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+			payload, _ := json.Marshal(claims)
+			signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(signed))
+			return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		}
+		exp := time.Now().Add(time.Hour).Unix()
+
+		for _, tt := range []struct {
+			name     string
+			token    string
+			wantCode int
+		}{
+			{"token com escopo", sign(map[string]interface{}{"sub": "usuario-1", "exp": exp, "scope": auth.ScopeProdutosWrite}), http.StatusOK},
+			{"token sem escopo", sign(map[string]interface{}{"sub": "usuario-1", "exp": exp}), http.StatusForbidden},
+			{"token expirado", sign(map[string]interface{}{"sub": "usuario-1", "exp": time.Now().Add(-time.Hour).Unix(), "scope": auth.ScopeProdutosWrite}), http.StatusUnauthorized},
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/produtos", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("%s: status esperado %d, obtido %d: %s", tt.name, tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK && rec.Body.String() != "usuario-1" {
+				t.Errorf("%s: sujeito inesperado: %q", tt.name, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("deve manter as rotas anônimas com a autenticação desabilitada", func(t *testing.T) {
+		SetAuthenticator(nil)
+		defer SetAuthenticator(auth.NewAPIKeyAuthenticator(repo, 0))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/produtos", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status esperado 200, obtido %d", rec.Code)
+		}
+	})
+}
@@ -5,13 +5,18 @@ import (
 )
 
 // ApplyMiddlewares aplica a cadeia de middlewares ao Echo
-// Ordem: RequestID -> Metrics -> Logging -> Recovery -> CORS -> RateLimit
+// Ordem: RequestID -> Metrics -> Logging -> Recovery -> CORS -> Auth -> RateLimit
+// Auth vem antes do RateLimit para que as políticas possam limitar por API key ou sujeito
 func ApplyMiddlewares(e *echo.Echo) {
+	// IP do cliente usado pelo rate limit e pelos logs (ver IPExtractor)
+	e.IPExtractor = IPExtractor()
+
 	// Echo já tem middlewares built-in, então vamos usar a ordem correta
 	e.Use(RequestIDMiddleware())
 	e.Use(MetricsMiddleware())
 	e.Use(LoggingMiddleware())
 	e.Use(RecoveryMiddleware())
 	e.Use(CORSMiddleware())
+	e.Use(AuthMiddleware())
 	e.Use(RateLimitMiddleware())
 }
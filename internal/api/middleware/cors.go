@@ -24,7 +24,7 @@ func CORSMiddleware() echo.MiddlewareFunc {
 			if corsConfig == nil {
 				c.Response().Header().Set("Access-Control-Allow-Origin", "*")
 				c.Response().Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-				c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-API-Key")
 			} else {
 				// Configurar origem
 				origin := c.Request().Header.Get("Origin")
@@ -42,7 +42,7 @@ func CORSMiddleware() echo.MiddlewareFunc {
 				if len(corsConfig.CORSAllowedHeaders) > 0 {
 					c.Response().Header().Set("Access-Control-Allow-Headers", strings.Join(corsConfig.CORSAllowedHeaders, ", "))
 				} else {
-					c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+					c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-API-Key")
 				}
 
 				// Configurar credenciais
@@ -51,6 +51,10 @@ func CORSMiddleware() echo.MiddlewareFunc {
 				}
 			}
 
+			// Permitir que clientes leiam o ETag (controle de concorrência otimista)
+			// e os headers de rate limit
+			c.Response().Header().Set("Access-Control-Expose-Headers", "ETag, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+
 			// Responder a requisições OPTIONS
 			if c.Request().Method == http.MethodOptions {
 				return c.NoContent(http.StatusNoContent)
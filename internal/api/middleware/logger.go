@@ -45,6 +45,10 @@ func LoggingMiddleware() echo.MiddlewareFunc {
 			if requestID != "" {
 				responseFields["request_id"] = requestID
 			}
+			// Sujeito autenticado (registrado pelo AuthMiddleware, que roda depois deste)
+			if subject := GetSubject(c); subject != "" {
+				responseFields["subject"] = subject
+			}
 			logger.WithFields(responseFields).Info("Request completed")
 			
 			return err
@@ -1,61 +1,152 @@
 package middleware
 
 import (
-	"net/http"
-	"strings"
-	"sync"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/errors"
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/ratelimit"
+	"api-go-arquitetura/internal/utils"
 )
 
-// clientLimiter controla tokens por cliente IP
-type clientLimiter struct {
-	tokens     int
-	lastRefill time.Time
-}
+// Headers de rate limit enviados em todas as respostas limitadas
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset" // Segundos até o limite estar completo novamente
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// defaultRateLimitPolicy é o nome da política aplicada quando nenhuma outra se aplica
+const defaultRateLimitPolicy = "default"
 
 var (
-	limiterMu    sync.Mutex
-	clients      = map[string]*clientLimiter{}
-	maxTokens    = 60 // requests
-	refillPeriod = time.Minute
+	rateLimitStore    = ratelimit.NewMemoryStore()
+	rateLimit         = ratelimit.Limit{Requests: 60, Period: time.Minute}
+	rateLimitPolicies []ratelimit.Policy
+	rateLimitExempt   []*net.IPNet
+	trustedProxies    []*net.IPNet
 )
 
-// RateLimitMiddleware aplica limitação simples por IP (maxTokens por refillPeriod)
+// SetRateLimiter configura o store (memória ou Redis) e o limite padrão por IP
+func SetRateLimiter(store ratelimit.Store, limit ratelimit.Limit) {
+	rateLimitStore = store
+	rateLimit = limit
+}
+
+// SetRateLimitPolicies configura as políticas por rota, método e identidade
+// (avaliadas em ordem; sem política aplicável vale o limite padrão por IP) e as
+// redes isentas de limite
+func SetRateLimitPolicies(policies []ratelimit.Policy, exempt []*net.IPNet) {
+	rateLimitPolicies = policies
+	rateLimitExempt = exempt
+}
+
+// SetTrustedProxies configura os proxies (ex: load balancer) cujo X-Forwarded-For
+// identifica o cliente; sem proxies confiáveis, vale o IP da conexão
+func SetTrustedProxies(proxies []*net.IPNet) {
+	trustedProxies = proxies
+}
+
+// IPExtractor retorna o extrator do IP do cliente (c.RealIP) conforme os proxies
+// confiáveis. Headers de IP (X-Forwarded-For, X-Real-IP) enviados diretamente pelo
+// cliente são ignorados: do contrário, bastaria forjá-los para obter a isenção das
+// redes isentas ou trocar de contador de rate limit a cada requisição
+func IPExtractor() echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// RateLimitMiddleware limita as requisições conforme a política da rota
+// Cada política tem limites próprios: o mesmo cliente tem contadores separados
+// em políticas diferentes
+// Se o store falhar (ex: Redis indisponível), a requisição é aceita sem limite
 func RateLimitMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			ip := c.RealIP()
-			// remover porta se presente
-			if strings.Contains(ip, ":") {
-				parts := strings.Split(ip, ":")
-				ip = strings.Join(parts[:len(parts)-1], ":")
+			if ratelimit.ContainsIP(rateLimitExempt, ip) {
+				return next(c)
 			}
 
-			limiterMu.Lock()
-			cl, ok := clients[ip]
+			route := c.Path()
+			if route == "" {
+				route = c.Request().URL.Path
+			}
+			policy, ok := ratelimit.FindPolicy(rateLimitPolicies, c.Request().Method, route)
 			if !ok {
-				cl = &clientLimiter{tokens: maxTokens, lastRefill: time.Now()}
-				clients[ip] = cl
+				policy = ratelimit.Policy{Name: defaultRateLimitPolicy, Key: ratelimit.KeyIP, Limit: rateLimit}
 			}
-			// refill
-			now := time.Now()
-			elapsed := now.Sub(cl.lastRefill)
-			if elapsed >= refillPeriod {
-				cl.tokens = maxTokens
-				cl.lastRefill = now
+			if policy.Exempt {
+				return next(c)
 			}
 
-			if cl.tokens <= 0 {
-				limiterMu.Unlock()
-				return c.JSON(http.StatusTooManyRequests, map[string]string{
-					"error": "rate limit exceeded",
-				})
+			key := policy.Name + ":" + rateLimitIdentity(c, policy.Key, ip)
+			result, err := rateLimitStore.Allow(c.Request().Context(), key, policy.Limit)
+			if err != nil {
+				logger.WithFields(map[string]interface{}{
+					"error":      err.Error(),
+					"policy":     policy.Name,
+					"request_id": GetRequestID(c),
+				}).Warn("Erro ao verificar rate limit, requisição aceita sem limite")
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+			if !result.Allowed {
+				retryAfter := ceilSeconds(result.RetryAfter)
+				header.Set(HeaderRetryAfter, strconv.Itoa(retryAfter))
+				return utils.EchoErrorResponse(c, errors.ErrTooManyRequests.WithDetailsf("Tente novamente em %d segundo(s)", retryAfter))
 			}
-			cl.tokens--
-			limiterMu.Unlock()
 			return next(c)
 		}
 	}
 }
+
+// rateLimitIdentity identifica o cliente conforme a chave da política
+// Com autenticação, a API key é identificada pelo ID da chave validada (chaves
+// inválidas valem como IP, para que trocar de chave não escape do limite); sem ela,
+// a chave apresentada entra no store apenas como hash. Sem a identidade pedida, vale o IP
+func rateLimitIdentity(c echo.Context, key, ip string) string {
+	switch key {
+	case ratelimit.KeySubject:
+		if subject := GetSubject(c); subject != "" {
+			return "subject:" + subject
+		}
+	case ratelimit.KeyAPIKey:
+		if principal, ok := GetPrincipal(c); ok && principal.KeyID != "" {
+			return "api_key_id:" + principal.KeyID
+		}
+		if apiKey := apiKeyFromRequest(c); apiKey != "" && !authEnabled() {
+			sum := sha256.Sum256([]byte(apiKey))
+			return "api_key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + ip
+}
+
+// ceilSeconds arredonda a duração para cima, em segundos inteiros
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"api-go-arquitetura/internal/ratelimit"
+)
+
+func TestRateLimitMiddleware_ClientIP(t *testing.T) {
+	exempt, _ := ratelimit.ParseCIDRs([]string{"10.0.0.0/8"})
+	proxies, _ := ratelimit.ParseCIDRs([]string{"192.0.2.0/24"})
+	defer func() {
+		SetRateLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Requests: 60, Period: time.Minute})
+		SetRateLimitPolicies(nil, nil)
+		SetTrustedProxies(nil)
+	}()
+
+	// newServer aplica a configuração como ApplyMiddlewares, com limite de 1 requisição
+	newServer := func(trusted []*net.IPNet) *echo.Echo {
+		SetRateLimiter(ratelimit.NewMemoryStore(), ratelimit.Limit{Requests: 1, Period: time.Hour})
+		SetRateLimitPolicies(nil, exempt)
+		SetTrustedProxies(trusted)
+		e := echo.New()
+		e.IPExtractor = IPExtractor()
+		e.Use(RateLimitMiddleware())
+		e.GET("/produtos", func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})
+		return e
+	}
+	get := func(e *echo.Echo, remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/produtos", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+			req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("headers forjados não devem conceder a isenção", func(t *testing.T) {
+		e := newServer(nil)
+		get(e, "203.0.113.7:5000", "10.0.0.1")
+		if code := get(e, "203.0.113.7:5000", "10.0.0.1"); code != http.StatusTooManyRequests {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("headers forjados não devem trocar o contador", func(t *testing.T) {
+		e := newServer(nil)
+		get(e, "203.0.113.7:5000", "198.51.100.1")
+		if code := get(e, "203.0.113.7:5000", "198.51.100.2"); code != http.StatusTooManyRequests {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("X-Forwarded-For de proxy confiável identifica o cliente", func(t *testing.T) {
+		e := newServer(proxies)
+		for i := 0; i < 2; i++ {
+			if code := get(e, "192.0.2.10:5000", "10.0.0.1"); code != http.StatusOK {
+				t.Errorf("Cliente isento atrás do proxy: status esperado %d, obtido %d", http.StatusOK, code)
+			}
+		}
+
+		// O proxy acrescenta o IP real ao X-Forwarded-For forjado pelo cliente
+		get(e, "192.0.2.10:5000", "10.0.0.1, 203.0.113.7")
+		if code := get(e, "192.0.2.10:5000", "10.0.0.1, 203.0.113.7"); code != http.StatusTooManyRequests {
+			t.Errorf("Status esperado %d, obtido %d", http.StatusTooManyRequests, code)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// SubjectKey é a chave usada para armazenar o sujeito autenticado no contexto
+	SubjectKey contextKey = "subject"
+	// APIKeyHeader é o nome do header HTTP usado para enviar a API key
+	APIKeyHeader = "X-API-Key"
+)
+
+// SetSubject registra o sujeito autenticado no contexto da requisição
+func SetSubject(c echo.Context, subject string) {
+	ctx := context.WithValue(c.Request().Context(), SubjectKey, subject)
+	c.SetRequest(c.Request().WithContext(ctx))
+}
+
+// GetSubject extrai o sujeito autenticado do contexto da requisição
+func GetSubject(c echo.Context) string {
+	if subject, ok := c.Request().Context().Value(SubjectKey).(string); ok {
+		return subject
+	}
+	return ""
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"api-go-arquitetura/internal/api/handlers"
+	"api-go-arquitetura/internal/api/middleware"
+	"api-go-arquitetura/internal/auth"
 
 	"github.com/labstack/echo/v4"
 )
@@ -10,24 +12,62 @@ import (
 func NewRouter(produtoHandler *handlers.ProdutoHandler, healthCheckHandler *handlers.HealthCheckHandler) *echo.Echo {
 	e := echo.New()
 
+	// Escopos exigidos pelas rotas de produtos (sem efeito com AUTH_ENABLED=false)
+	read := middleware.RequireScope(auth.ScopeProdutosRead)
+	write := middleware.RequireScope(auth.ScopeProdutosWrite)
+	// Rotas administrativas: escopo produtos:admin ou, sem autenticação, o X-Admin-Token
+	admin := middleware.RequireAdmin()
+
 	// Rotas versionadas para produtos (v1)
 	v1 := e.Group("/api/v1")
-	v1.GET("/produtos", produtoHandler.GetProdutos)
-	v1.GET("/produtos/:id", produtoHandler.GetProduto)
-	v1.POST("/produtos", produtoHandler.CreateProduto)
-	v1.PUT("/produtos/:id", produtoHandler.UpdateProduto)
-	v1.PATCH("/produtos/:id", produtoHandler.PatchProduto)
-	v1.DELETE("/produtos/:id", produtoHandler.DeleteProduto)
+	v1.GET("/produtos", produtoHandler.GetProdutos, read)
+	v1.GET("/produtos/search", produtoHandler.SearchProdutos, read)
+	v1.GET("/produtos/stats", produtoHandler.GetProdutosStats, read)
+	v1.GET("/produtos/export", produtoHandler.ExportProdutos, read)
+	v1.GET("/produtos/:id", produtoHandler.GetProduto, read)
+	v1.POST("/produtos", produtoHandler.CreateProduto, write)
+	v1.POST("/produtos/batch", produtoHandler.BatchProdutos, write)
+	v1.POST("/produtos/import", produtoHandler.ImportProdutos, write)
+	v1.PUT("/produtos/:id", produtoHandler.UpdateProduto, write)
+	v1.PATCH("/produtos/:id", produtoHandler.PatchProduto, write)
+	v1.DELETE("/produtos/:id", produtoHandler.DeleteProduto, write)
+	v1.POST("/produtos/:id/restore", produtoHandler.RestoreProduto, write)
+	v1.DELETE("/produtos/:id/purge", produtoHandler.PurgeProduto, admin)
+
+	// Rotas da API v2: mesmos handlers, com a representação v2 dos produtos
+	// (timestamps, versão e links). Em /api/v1 e /api a v2 também pode ser
+	// negociada pelo header Accept: application/vnd.produto.v2+json
+	v2 := e.Group("/api/v2", handlers.RepresentationV2())
+	v2.GET("/produtos", produtoHandler.GetProdutos, read)
+	v2.GET("/produtos/search", produtoHandler.SearchProdutos, read)
+	v2.GET("/produtos/stats", produtoHandler.GetProdutosStats, read)
+	v2.GET("/produtos/export", produtoHandler.ExportProdutos, read)
+	v2.GET("/produtos/:id", produtoHandler.GetProduto, read)
+	v2.POST("/produtos", produtoHandler.CreateProduto, write)
+	v2.POST("/produtos/batch", produtoHandler.BatchProdutos, write)
+	v2.POST("/produtos/import", produtoHandler.ImportProdutos, write)
+	v2.PUT("/produtos/:id", produtoHandler.UpdateProduto, write)
+	v2.PATCH("/produtos/:id", produtoHandler.PatchProduto, write)
+	v2.DELETE("/produtos/:id", produtoHandler.DeleteProduto, write)
+	v2.POST("/produtos/:id/restore", produtoHandler.RestoreProduto, write)
+	v2.DELETE("/produtos/:id/purge", produtoHandler.PurgeProduto, admin)
 
 	// Manter compatibilidade com rotas antigas (redirecionar para v1)
 	// Isso permite uma transição suave para o versionamento
 	legacy := e.Group("/api")
-	legacy.GET("/produtos", produtoHandler.GetProdutos)
-	legacy.GET("/produtos/:id", produtoHandler.GetProduto)
-	legacy.POST("/produtos", produtoHandler.CreateProduto)
-	legacy.PUT("/produtos/:id", produtoHandler.UpdateProduto)
-	legacy.PATCH("/produtos/:id", produtoHandler.PatchProduto)
-	legacy.DELETE("/produtos/:id", produtoHandler.DeleteProduto)
+	legacy.GET("/produtos", produtoHandler.GetProdutos, read)
+	legacy.GET("/produtos/search", produtoHandler.SearchProdutos, read)
+	legacy.GET("/produtos/stats", produtoHandler.GetProdutosStats, read)
+	legacy.GET("/produtos/export", produtoHandler.ExportProdutos, read)
+	legacy.GET("/produtos/:id", produtoHandler.GetProduto, read)
+	legacy.POST("/produtos", produtoHandler.CreateProduto, write)
+	legacy.POST("/produtos/batch", produtoHandler.BatchProdutos, write)
+	legacy.POST("/produtos/import", produtoHandler.ImportProdutos, write)
+	legacy.PUT("/produtos/:id", produtoHandler.UpdateProduto, write)
+	legacy.PATCH("/produtos/:id", produtoHandler.PatchProduto, write)
+	legacy.DELETE("/produtos/:id", produtoHandler.DeleteProduto, write)
+	legacy.POST("/produtos/:id/restore", produtoHandler.RestoreProduto, write)
+	legacy.DELETE("/produtos/:id/purge", produtoHandler.PurgeProduto, admin)
 
 	// Rota de health check (não versionada)
 	if healthCheckHandler != nil {
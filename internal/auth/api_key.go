@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+)
+
+// DefaultTouchInterval é o intervalo mínimo padrão entre dois registros de
+// last_used_at da mesma chave (evita uma escrita no banco a cada requisição)
+const DefaultTouchInterval = time.Minute
+
+// HashAPIKey calcula o hash armazenado de uma API key
+// As chaves são segredos aleatórios longos, então um hash rápido é suficiente
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyAuthenticator autentica clientes pelas API keys do repositório
+type APIKeyAuthenticator struct {
+	repo          repository.APIKeyRepository
+	touchInterval time.Duration
+	now           func() time.Time
+
+	mu          sync.Mutex
+	lastTouched map[string]time.Time
+}
+
+// NewAPIKeyAuthenticator cria o autenticador; last_used_at é registrado no máximo
+// uma vez por touchInterval para cada chave (0 registra todos os usos)
+func NewAPIKeyAuthenticator(repo repository.APIKeyRepository, touchInterval time.Duration) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		repo:          repo,
+		touchInterval: touchInterval,
+		now:           time.Now,
+		lastTouched:   make(map[string]time.Time),
+	}
+}
+
+// Authenticate valida a API key e registra o seu uso
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	if credential == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	key, err := a.repo.FindByHash(ctx, HashAPIKey(credential))
+	if err != nil {
+		if err.Error() == "not found" {
+			return Principal{}, ErrInvalidCredentials
+		}
+		return Principal{}, err
+	}
+	if key.IsRevoked() {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	a.touch(ctx, key.ID)
+
+	return Principal{
+		Subject: "api_key:" + key.ID,
+		KeyID:   key.ID,
+		Name:    key.Name,
+		Scopes:  key.Scopes,
+	}, nil
+}
+
+// touch registra last_used_at, respeitando o intervalo mínimo
+// Falhas são apenas registradas em log: não impedem a autenticação
+func (a *APIKeyAuthenticator) touch(ctx context.Context, id string) {
+	now := a.now()
+	a.mu.Lock()
+	if last, ok := a.lastTouched[id]; ok && now.Sub(last) < a.touchInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.lastTouched[id] = now
+	a.mu.Unlock()
+
+	if err := a.repo.TouchLastUsed(ctx, id, now); err != nil {
+		logger.WithFields(map[string]interface{}{
+			"error":  err.Error(),
+			"key_id": id,
+		}).Warn("Erro ao registrar último uso da API key")
+	}
+}
+
+// ParseAPIKeys lê as API keys de provisionamento, no formato
+// "id:chave:escopo1 escopo2", separadas por vírgula
+// (ex: "ci:s3cr3t:produtos:read,admin:0utr4:produtos:admin")
+func ParseAPIKeys(value string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("API key inválida: use o formato id:chave:escopos")
+		}
+		scopes := strings.Fields(parts[2])
+		if len(scopes) == 0 {
+			return nil, fmt.Errorf("API key %s sem escopos", parts[0])
+		}
+		for _, scope := range scopes {
+			if !IsValidScope(scope) {
+				return nil, fmt.Errorf("API key %s: escopo inválido: %s", parts[0], scope)
+			}
+		}
+		keys = append(keys, model.APIKey{
+			ID:        parts[0],
+			Name:      parts[0],
+			KeyHash:   HashAPIKey(parts[1]),
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+		})
+	}
+	return keys, nil
+}
@@ -0,0 +1,85 @@
+// Package auth autentica os clientes da API e define os escopos de acesso
+//
+// Os escopos de produtos são hierárquicos: produtos:admin inclui produtos:write,
+// que inclui produtos:read
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// Escopos de acesso aos produtos
+const (
+	ScopeProdutosRead  = "produtos:read"
+	ScopeProdutosWrite = "produtos:write"
+	ScopeProdutosAdmin = "produtos:admin"
+)
+
+// impliedScopes lista os escopos incluídos em cada escopo
+var impliedScopes = map[string][]string{
+	ScopeProdutosWrite: {ScopeProdutosRead},
+	ScopeProdutosAdmin: {ScopeProdutosWrite, ScopeProdutosRead},
+}
+
+// ErrInvalidCredentials indica credenciais inexistentes, revogadas ou inválidas
+var ErrInvalidCredentials = errors.New("credenciais inválidas")
+
+// IsValidScope informa se o escopo é conhecido
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeProdutosRead, ScopeProdutosWrite, ScopeProdutosAdmin:
+		return true
+	}
+	return false
+}
+
+// Principal é o cliente autenticado
+type Principal struct {
+	Subject string   // Identificador estável do cliente (api_key:<id> ou a claim sub do JWT)
+	KeyID   string   // ID da API key usada, quando autenticado por API key
+	Name    string   // Nome descritivo
+	Scopes  []string // Escopos concedidos
+}
+
+// HasScope informa se o cliente tem o escopo, diretamente ou por hierarquia
+func (p Principal) HasScope(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+		for _, implied := range impliedScopes[granted] {
+			if implied == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticator valida uma credencial e retorna o cliente autenticado
+// Credenciais desconhecidas ou revogadas retornam ErrInvalidCredentials; outros
+// erros indicam falha ao verificá-las (ex: banco indisponível)
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (Principal, error)
+}
+
+type contextKey struct{}
+
+// WithPrincipal adiciona o cliente autenticado ao contexto
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// PrincipalFromContext extrai o cliente autenticado do contexto
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(Principal)
+	return principal, ok
+}
+
+// SubjectFromContext retorna o sujeito autenticado do contexto (vazio se anônimo),
+// para uso em logs e trilhas de auditoria fora da camada HTTP
+func SubjectFromContext(ctx context.Context) string {
+	principal, _ := PrincipalFromContext(ctx)
+	return principal.Subject
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+)
+
+// lastUsedRepository registra as chamadas de TouchLastUsed
+type lastUsedRepository struct {
+	repository.APIKeyRepository
+	touches int
+}
+
+func (r *lastUsedRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	r.touches++
+	return r.APIKeyRepository.TouchLastUsed(ctx, id, usedAt)
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	tests := []struct {
+		granted []string
+		scope   string
+		want    bool
+	}{
+		{[]string{ScopeProdutosRead}, ScopeProdutosRead, true},
+		{[]string{ScopeProdutosRead}, ScopeProdutosWrite, false},
+		{[]string{ScopeProdutosWrite}, ScopeProdutosRead, true},
+		{[]string{ScopeProdutosWrite}, ScopeProdutosAdmin, false},
+		{[]string{ScopeProdutosAdmin}, ScopeProdutosWrite, true},
+		{[]string{ScopeProdutosAdmin}, ScopeProdutosRead, true},
+		{nil, ScopeProdutosRead, false},
+	}
+	for _, tt := range tests {
+		if got := (Principal{Scopes: tt.granted}).HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v.HasScope(%s) = %v, esperado %v", tt.granted, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	repo := &lastUsedRepository{APIKeyRepository: repository.NewMemoryAPIKeyRepository()}
+	revokedAt := time.Now()
+	for _, key := range []model.APIKey{
+		{ID: "ci", Name: "CI", KeyHash: HashAPIKey("segredo-ci"), Scopes: []string{ScopeProdutosRead}},
+		{ID: "antiga", KeyHash: HashAPIKey("segredo-antigo"), Scopes: []string{ScopeProdutosAdmin}, RevokedAt: &revokedAt},
+	} {
+		if err := repo.Save(ctx, key); err != nil {
+			t.Fatalf("Erro ao salvar chave: %v", err)
+		}
+	}
+
+	now := time.Unix(1000, 0)
+	authenticator := NewAPIKeyAuthenticator(repo, time.Minute)
+	authenticator.now = func() time.Time { return now }
+
+	t.Run("deve autenticar chaves válidas", func(t *testing.T) {
+		principal, err := authenticator.Authenticate(ctx, "segredo-ci")
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if principal.Subject != "api_key:ci" || principal.KeyID != "ci" || principal.Name != "CI" || !principal.HasScope(ScopeProdutosRead) {
+			t.Errorf("Principal inesperado: %+v", principal)
+		}
+	})
+
+	t.Run("deve rejeitar chaves desconhecidas e revogadas", func(t *testing.T) {
+		for _, credential := range []string{"", "segredo-errado", "segredo-antigo"} {
+			if _, err := authenticator.Authenticate(ctx, credential); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("%q: esperado ErrInvalidCredentials, obtido %v", credential, err)
+			}
+		}
+	})
+
+	t.Run("deve registrar last_used_at no máximo uma vez por intervalo", func(t *testing.T) {
+		repo.touches = 0
+		now = now.Add(time.Hour)
+		for i := 0; i < 3; i++ {
+			if _, err := authenticator.Authenticate(ctx, "segredo-ci"); err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+		}
+		now = now.Add(time.Minute)
+		if _, err := authenticator.Authenticate(ctx, "segredo-ci"); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if repo.touches != 2 {
+			t.Errorf("Esperados 2 registros de uso, obtidos %d", repo.touches)
+		}
+
+		key, err := repo.FindByHash(ctx, HashAPIKey("segredo-ci"))
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if key.LastUsedAt == nil || !key.LastUsedAt.Equal(now) {
+			t.Errorf("last_used_at inesperado: %v", key.LastUsedAt)
+		}
+	})
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys(" ci:segredo:produtos:read ,admin:outro:produtos:admin produtos:read,")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Esperadas 2 chaves, obtidas %d", len(keys))
+	}
+	if keys[0].ID != "ci" || keys[0].KeyHash != HashAPIKey("segredo") || len(keys[0].Scopes) != 1 {
+		t.Errorf("Primeira chave inesperada: %+v", keys[0])
+	}
+	if keys[1].ID != "admin" || len(keys[1].Scopes) != 2 {
+		t.Errorf("Segunda chave inesperada: %+v", keys[1])
+	}
+
+	if keys, err := ParseAPIKeys(""); err != nil || len(keys) != 0 {
+		t.Errorf("Valor vazio deveria resultar em nenhuma chave: %v, %v", keys, err)
+	}
+	for _, value := range []string{"ci", "ci:segredo", "ci:segredo:", ":segredo:produtos:read", "ci:segredo:produtos:delete"} {
+		if _, err := ParseAPIKeys(value); err == nil {
+			t.Errorf("%q deveria ser rejeitado", value)
+		}
+	}
+}
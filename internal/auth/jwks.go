@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/logger"
+)
+
+// Limites do JWKS
+const (
+	DefaultJWKSRefresh  = 15 * time.Minute // Validade padrão do JWKS em cache
+	jwksMinRefresh      = time.Minute      // Intervalo mínimo entre recargas
+	jwksMaxSize         = 1 << 20          // Tamanho máximo do documento JWKS
+	jwksFetchTimeout    = 10 * time.Second
+	minRSAModulusLength = 2048
+)
+
+// KeySet fornece as chaves públicas de verificação dos tokens (RS256/ES256)
+type KeySet interface {
+	// Key retorna a chave do kid compatível com o algoritmo; kid vazio só é aceito
+	// quando o conjunto tem uma única chave
+	Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error)
+}
+
+// jwk é uma chave do documento JWKS (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey é uma chave carregada do JWKS
+type publicKey struct {
+	key crypto.PublicKey
+	alg string // Algoritmo compatível (RS256 ou ES256)
+}
+
+// JWKS mantém em cache as chaves de um documento JWKS (arquivo local ou URL)
+// O documento é recarregado quando o cache expira e, para suportar a rotação de
+// chaves, quando um token usa um kid desconhecido; em ambos os casos no máximo uma
+// vez por minuto. Se a recarga falhar, as chaves em cache continuam sendo usadas
+type JWKS struct {
+	fetch   func(ctx context.Context) ([]byte, error)
+	source  string
+	refresh time.Duration
+	now     func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]publicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+
+	fetchMu sync.Mutex // Serializa as recargas
+}
+
+// NewJWKSFromURL cria um JWKS carregado por HTTP (ex: endpoint jwks_uri do provedor OIDC)
+func NewJWKSFromURL(url string, refresh time.Duration) *JWKS {
+	client := &http.Client{Timeout: jwksFetchTimeout}
+	return newJWKS(url, refresh, func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("JWKS retornou status %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, jwksMaxSize))
+	})
+}
+
+// NewJWKSFromFile cria um JWKS lido de um arquivo local
+func NewJWKSFromFile(path string, refresh time.Duration) *JWKS {
+	return newJWKS(path, refresh, func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+func newJWKS(source string, refresh time.Duration, fetch func(ctx context.Context) ([]byte, error)) *JWKS {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	return &JWKS{
+		fetch:   fetch,
+		source:  source,
+		refresh: refresh,
+		now:     time.Now,
+		keys:    make(map[string]publicKey),
+	}
+}
+
+// Refresh recarrega o documento JWKS
+func (s *JWKS) Refresh(ctx context.Context) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	return s.refreshLocked(ctx)
+}
+
+// refreshFor recarrega o documento JWKS para o kid, exceto se outra chamada o
+// recarregou (ou tentou) enquanto esta aguardava a vez: as requisições concorrentes
+// com o mesmo kid desconhecido geram uma única recarga
+func (s *JWKS) refreshFor(ctx context.Context, kid string) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	if _, found, stale, canRetry := s.lookup(kid); (found && !stale) || !canRetry {
+		return nil
+	}
+	return s.refreshLocked(ctx)
+}
+
+// refreshLocked recarrega o documento JWKS; deve ser chamado com fetchMu adquirido
+func (s *JWKS) refreshLocked(ctx context.Context) error {
+	s.mu.Lock()
+	s.lastAttempt = s.now()
+	s.mu.Unlock()
+
+	data, err := s.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao carregar JWKS de %s: %w", s.source, err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("JWKS de %s inválido: %w", s.source, err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = s.now()
+	s.mu.Unlock()
+	return nil
+}
+
+// Key retorna a chave do kid, recarregando o JWKS quando necessário
+func (s *JWKS) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	// Sem a chave, aguarda a recarga em andamento (se houver); com a chave em um
+	// cache expirado, recarrega apenas se permitido
+	key, found, stale, canRetry := s.lookup(kid)
+	if !found || (stale && canRetry) {
+		if err := s.refreshFor(ctx, kid); err != nil {
+			s.mu.RLock()
+			empty := len(s.keys) == 0
+			s.mu.RUnlock()
+			if empty {
+				return nil, err
+			}
+			logger.WithField("error", err.Error()).Warn("Erro ao recarregar JWKS, usando as chaves em cache")
+		}
+		key, found, _, _ = s.lookup(kid)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w: chave %q desconhecida", ErrInvalidCredentials, kid)
+	}
+	if key.alg != alg {
+		return nil, fmt.Errorf("%w: chave %q não é compatível com %s", ErrInvalidCredentials, kid, alg)
+	}
+	return key.key, nil
+}
+
+// lookup busca a chave no cache e informa se o cache expirou e se uma recarga é permitida
+func (s *JWKS) lookup(kid string) (key publicKey, found, stale, canRetry bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	stale = s.fetchedAt.IsZero() || now.Sub(s.fetchedAt) >= s.refresh
+	canRetry = now.Sub(s.lastAttempt) >= jwksMinRefresh
+
+	if kid == "" {
+		if len(s.keys) == 1 {
+			for _, k := range s.keys {
+				return k, true, stale, canRetry
+			}
+		}
+		return publicKey{}, false, stale, canRetry
+	}
+	key, found = s.keys[kid]
+	return key, found, stale, canRetry
+}
+
+// parseJWKS lê as chaves de assinatura suportadas; chaves de outros tipos ou
+// usos (ex: criptografia) são ignoradas, assim como chaves inválidas (registradas
+// no log), para que uma chave ruim publicada na rotação não bloqueie as demais
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]publicKey, len(doc.Keys))
+	var invalid error // Última chave inválida, para o erro sem chaves utilizáveis
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key publicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAKey(k)
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			key, err = parseECKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			invalid = fmt.Errorf("chave %q: %w", k.Kid, err)
+			logger.WithFields(map[string]interface{}{
+				"kid":   k.Kid,
+				"error": err.Error(),
+			}).Warn("Chave inválida no JWKS ignorada")
+			continue
+		}
+		if k.Alg != "" && k.Alg != key.alg {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		if invalid != nil {
+			return nil, fmt.Errorf("nenhuma chave RS256 ou ES256 válida (%w)", invalid)
+		}
+		return nil, fmt.Errorf("nenhuma chave RS256 ou ES256")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jwk) (publicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("n inválido: %w", err)
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return publicKey{}, fmt.Errorf("e inválido")
+	}
+	if n.BitLen() < minRSAModulusLength {
+		return publicKey{}, fmt.Errorf("chave RSA menor que %d bits", minRSAModulusLength)
+	}
+	return publicKey{key: &rsa.PublicKey{N: n, E: int(e.Int64())}, alg: algRS256}, nil
+}
+
+func parseECKey(k jwk) (publicKey, error) {
+	if k.Crv != "P-256" {
+		return publicKey{}, fmt.Errorf("curva não suportada: %s", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != 32 {
+		return publicKey{}, fmt.Errorf("x inválido")
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil || len(y) != 32 {
+		return publicKey{}, fmt.Errorf("y inválido")
+	}
+	// Rejeitar pontos fora da curva
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return publicKey{}, fmt.Errorf("ponto inválido: %w", err)
+	}
+	return publicKey{
+		key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)},
+		alg: algES256,
+	}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("valor vazio")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
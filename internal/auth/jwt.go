@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Algoritmos de assinatura aceitos
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+	algES256 = "ES256"
+)
+
+// MinHS256SecretLength é o tamanho mínimo do segredo HS256, em bytes
+const MinHS256SecretLength = 32
+
+// DefaultJWTLeeway é a tolerância padrão de relógio na verificação de exp e nbf
+const DefaultJWTLeeway = 30 * time.Second
+
+// JWTOptions configura a validação dos tokens
+type JWTOptions struct {
+	Keys        KeySet // Chaves RS256/ES256 (nil desabilita esses algoritmos)
+	HS256Secret []byte // Segredo HS256, para desenvolvimento (vazio desabilita HS256)
+	Issuer      string // Valor exigido em iss (vazio não verifica)
+	Audience    string // Valor exigido em aud (vazio não verifica)
+	Leeway      time.Duration
+	// RolesClaim é o caminho da claim de papéis, com pontos para claims aninhadas
+	// (ex: "roles" ou "realm_access.roles")
+	RolesClaim string
+	// RoleScopes mapeia papéis para escopos; os escopos das claims scope e scp
+	// são concedidos diretamente
+	RoleScopes map[string][]string
+}
+
+// JWTAuthenticator autentica clientes por bearer tokens JWT
+type JWTAuthenticator struct {
+	opts JWTOptions
+	now  func() time.Time
+}
+
+// NewJWTAuthenticator cria o autenticador de tokens JWT
+func NewJWTAuthenticator(opts JWTOptions) *JWTAuthenticator {
+	return &JWTAuthenticator{opts: opts, now: time.Now}
+}
+
+// jwtHeader é o cabeçalho JOSE do token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims são as claims registradas verificadas (RFC 7519)
+type jwtClaims struct {
+	Subject   string       `json:"sub"`
+	Issuer    string       `json:"iss"`
+	Audience  stringList   `json:"aud"`
+	ExpiresAt *numericDate `json:"exp"`
+	NotBefore *numericDate `json:"nbf"`
+	Scope     string       `json:"scope"`
+	Scp       stringList   `json:"scp"`
+	Name      string       `json:"name"`
+}
+
+// stringList aceita uma string (valores separados por espaço) ou uma lista de strings
+type stringList []string
+
+func (a *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = strings.Fields(single)
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// numericDate é uma data em segundos desde a época (pode ter frações)
+type numericDate struct {
+	time.Time
+}
+
+func (d *numericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return fmt.Errorf("data inválida")
+	}
+	whole, frac := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(frac*1e9))
+	return nil
+}
+
+// Authenticate valida a assinatura e as claims do token
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	parts := strings.Split(credential, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: token malformado", ErrInvalidCredentials)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: cabeçalho inválido", ErrInvalidCredentials)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: assinatura malformada", ErrInvalidCredentials)
+	}
+	if err := a.verify(ctx, header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return Principal{}, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: payload malformado", ErrInvalidCredentials)
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: claims inválidas", ErrInvalidCredentials)
+	}
+	if err := a.validateClaims(claims); err != nil {
+		return Principal{}, err
+	}
+
+	scopes, err := a.scopes(payload, claims)
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{
+		Subject: claims.Subject,
+		Name:    claims.Name,
+		Scopes:  scopes,
+	}, nil
+}
+
+// verify verifica a assinatura com o algoritmo do cabeçalho
+// O algoritmo define a origem da chave: HS256 usa apenas o segredo e RS256/ES256
+// apenas o JWKS, evitando a confusão de algoritmos (ex: chave pública usada como segredo HMAC)
+func (a *JWTAuthenticator) verify(ctx context.Context, header jwtHeader, signed, signature []byte) error {
+	digest := sha256.Sum256(signed)
+
+	switch header.Alg {
+	case algHS256:
+		if len(a.opts.HS256Secret) == 0 {
+			break
+		}
+		mac := hmac.New(sha256.New, a.opts.HS256Secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: assinatura inválida", ErrInvalidCredentials)
+		}
+		return nil
+
+	case algRS256, algES256:
+		if a.opts.Keys == nil {
+			break
+		}
+		key, err := a.opts.Keys.Key(ctx, header.Kid, header.Alg)
+		if err != nil {
+			return err
+		}
+		if header.Alg == algRS256 {
+			pub, ok := key.(*rsa.PublicKey)
+			if !ok || rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+				return fmt.Errorf("%w: assinatura inválida", ErrInvalidCredentials)
+			}
+			return nil
+		}
+		// ES256: assinatura com r e s concatenados (32 bytes cada)
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return fmt.Errorf("%w: assinatura inválida", ErrInvalidCredentials)
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return fmt.Errorf("%w: assinatura inválida", ErrInvalidCredentials)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: algoritmo não aceito: %q", ErrInvalidCredentials, header.Alg)
+}
+
+// validateClaims verifica sub, exp, nbf, iss e aud
+func (a *JWTAuthenticator) validateClaims(claims jwtClaims) error {
+	now := a.now()
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: claim sub ausente", ErrInvalidCredentials)
+	}
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: claim exp ausente", ErrInvalidCredentials)
+	}
+	if now.After(claims.ExpiresAt.Add(a.opts.Leeway)) {
+		return fmt.Errorf("%w: token expirado", ErrInvalidCredentials)
+	}
+	if claims.NotBefore != nil && now.Add(a.opts.Leeway).Before(claims.NotBefore.Time) {
+		return fmt.Errorf("%w: token ainda não é válido", ErrInvalidCredentials)
+	}
+	if a.opts.Issuer != "" && claims.Issuer != a.opts.Issuer {
+		return fmt.Errorf("%w: emissor não aceito", ErrInvalidCredentials)
+	}
+	if a.opts.Audience != "" && !containsString(claims.Audience, a.opts.Audience) {
+		return fmt.Errorf("%w: audiência não aceita", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+// scopes reúne os escopos das claims scope e scp e os dos papéis mapeados
+func (a *JWTAuthenticator) scopes(payload []byte, claims jwtClaims) ([]string, error) {
+	var scopes []string
+	add := func(scope string) {
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, scope := range strings.Fields(claims.Scope) {
+		add(scope)
+	}
+	for _, scope := range claims.Scp {
+		add(scope)
+	}
+
+	if a.opts.RolesClaim == "" || len(a.opts.RoleScopes) == 0 {
+		return scopes, nil
+	}
+	roles, err := rolesFromClaims(payload, a.opts.RolesClaim)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		for _, scope := range a.opts.RoleScopes[role] {
+			add(scope)
+		}
+	}
+	return scopes, nil
+}
+
+// rolesFromClaims lê os papéis do caminho informado (string ou lista de strings)
+func rolesFromClaims(payload []byte, path string) ([]string, error) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: claims inválidas", ErrInvalidCredentials)
+	}
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		value = object[name]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v), nil
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, item := range v {
+			if role, ok := item.(string); ok {
+				roles = append(roles, role)
+			}
+		}
+		return roles, nil
+	}
+	return nil, nil
+}
+
+// ParseRoleScopes lê o mapeamento de papéis para escopos, no formato
+// "papel=escopo1 escopo2", separado por vírgula (ex: "admin=produtos:admin,leitor=produtos:read")
+func ParseRoleScopes(value string) (map[string][]string, error) {
+	roleScopes := make(map[string][]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, scopesValue, found := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		scopes := strings.Fields(scopesValue)
+		if !found || role == "" || len(scopes) == 0 {
+			return nil, fmt.Errorf("mapeamento inválido: %s (use papel=escopos)", entry)
+		}
+		for _, scope := range scopes {
+			if !IsValidScope(scope) {
+				return nil, fmt.Errorf("papel %s: escopo inválido: %s", role, scope)
+			}
+		}
+		roleScopes[role] = append(roleScopes[role], scopes...)
+	}
+	return roleScopes, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("segredo-de-desenvolvimento-com-32-bytes!")
+
+// signToken monta um token JWT assinado com a chave informada
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Erro ao codificar token: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Erro ao assinar token: %v", err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("Erro ao assinar token: %v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case nil:
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// jwksDocument monta o documento JWKS das chaves públicas
+func jwksDocument(t *testing.T, keys map[string]interface{}) []byte {
+	t.Helper()
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	var list []map[string]string
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			list = append(list, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+				"n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			x, y := make([]byte, 32), make([]byte, 32)
+			k.X.FillBytes(x)
+			k.Y.FillBytes(y)
+			list = append(list, map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(x), "y": b64(y)})
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": list})
+	if err != nil {
+		t.Fatalf("Erro ao codificar JWKS: %v", err)
+	}
+	return data
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Erro ao gerar chave RSA: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Erro ao gerar chave EC: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksDocument(t, map[string]interface{}{"rsa-1": &rsaKey.PublicKey, "ec-1": &ecKey.PublicKey}), 0o600); err != nil {
+		t.Fatalf("Erro ao gravar JWKS: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	authenticator := NewJWTAuthenticator(JWTOptions{
+		Keys:        NewJWKSFromFile(path, time.Hour),
+		HS256Secret: testSecret,
+		Issuer:      "https://auth.exemplo.com",
+		Audience:    "api-produtos",
+		Leeway:      30 * time.Second,
+		RolesClaim:  "realm_access.roles",
+		RoleScopes:  map[string][]string{"editor": {ScopeProdutosWrite}},
+	})
+	authenticator.now = func() time.Time { return now }
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":   "usuario-1",
+			"iss":   "https://auth.exemplo.com",
+			"aud":   []string{"outra-api", "api-produtos"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "openid produtos:read",
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	t.Run("deve aceitar tokens RS256, ES256 e HS256", func(t *testing.T) {
+		for name, token := range map[string]string{
+			"RS256": signToken(t, "RS256", "rsa-1", rsaKey, claims(nil)),
+			"ES256": signToken(t, "ES256", "ec-1", ecKey, claims(nil)),
+			"HS256": signToken(t, "HS256", "", testSecret, claims(nil)),
+		} {
+			principal, err := authenticator.Authenticate(ctx, token)
+			if err != nil {
+				t.Errorf("%s: erro inesperado: %v", name, err)
+				continue
+			}
+			if principal.Subject != "usuario-1" || !principal.HasScope(ScopeProdutosRead) || principal.HasScope(ScopeProdutosWrite) {
+				t.Errorf("%s: principal inesperado: %+v", name, principal)
+			}
+		}
+	})
+
+	t.Run("deve mapear papéis para escopos", func(t *testing.T) {
+		token := signToken(t, "RS256", "rsa-1", rsaKey, claims(map[string]interface{}{
+			"scope":        nil,
+			"realm_access": map[string]interface{}{"roles": []string{"editor", "outro"}},
+		}))
+		principal, err := authenticator.Authenticate(ctx, token)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if !principal.HasScope(ScopeProdutosWrite) || principal.HasScope(ScopeProdutosAdmin) {
+			t.Errorf("Escopos inesperados: %v", principal.Scopes)
+		}
+	})
+
+	t.Run("deve aceitar exp dentro da tolerância", func(t *testing.T) {
+		token := signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()}))
+		if _, err := authenticator.Authenticate(ctx, token); err != nil {
+			t.Errorf("Erro inesperado: %v", err)
+		}
+	})
+
+	t.Run("deve rejeitar tokens inválidos", func(t *testing.T) {
+		otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		tests := map[string]string{
+			"expirado":                 signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})),
+			"sem exp":                  signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"exp": nil})),
+			"nbf no futuro":            signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"nbf": now.Add(time.Minute).Unix()})),
+			"emissor diferente":        signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"iss": "https://outro"})),
+			"audiência diferente":      signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"aud": "outra-api"})),
+			"sem sub":                  signToken(t, "HS256", "", testSecret, claims(map[string]interface{}{"sub": nil})),
+			"segredo diferente":        signToken(t, "HS256", "", []byte("outro-segredo-com-mais-de-32-bytes!!"), claims(nil)),
+			"assinado por outra chave": signToken(t, "ES256", "ec-1", otherKey, claims(nil)),
+			"algoritmo none":           signToken(t, "none", "", nil, claims(nil)),
+			"kid de outro tipo":        signToken(t, "ES256", "rsa-1", ecKey, claims(nil)),
+			"kid desconhecido":         signToken(t, "RS256", "rsa-2", rsaKey, claims(nil)),
+			"malformado":               "abc.def",
+		}
+		for name, token := range tests {
+			if _, err := authenticator.Authenticate(ctx, token); !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("%s: esperado ErrInvalidCredentials, obtido %v", name, err)
+			}
+		}
+	})
+
+	t.Run("não deve aceitar HS256 sem segredo configurado", func(t *testing.T) {
+		withoutSecret := NewJWTAuthenticator(JWTOptions{Keys: NewJWKSFromFile(path, time.Hour)})
+		withoutSecret.now = authenticator.now
+		token := signToken(t, "HS256", "", []byte{}, claims(nil))
+		if _, err := withoutSecret.Authenticate(ctx, token); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Esperado ErrInvalidCredentials, obtido %v", err)
+		}
+	})
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	newKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	documents := [][]byte{
+		jwksDocument(t, map[string]interface{}{"antiga": &oldKey.PublicKey}),
+		jwksDocument(t, map[string]interface{}{"nova": &newKey.PublicKey}),
+	}
+	fetches := 0
+	fail := false
+	jwks := newJWKS("teste", time.Hour, func(ctx context.Context) ([]byte, error) {
+		if fail {
+			return nil, errors.New("indisponível")
+		}
+		doc := documents[fetches]
+		if fetches < len(documents)-1 {
+			fetches++
+		}
+		return doc, nil
+	})
+	now := time.Unix(1700000000, 0)
+	jwks.now = func() time.Time { return now }
+
+	if _, err := jwks.Key(ctx, "antiga", algES256); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	// kid novo antes do intervalo mínimo: sem recarga
+	now = now.Add(10 * time.Second)
+	if _, err := jwks.Key(ctx, "nova", algES256); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Esperado ErrInvalidCredentials antes do intervalo mínimo, obtido %v", err)
+	}
+
+	// Após o intervalo mínimo, o kid desconhecido recarrega o JWKS
+	now = now.Add(time.Minute)
+	if _, err := jwks.Key(ctx, "nova", algES256); err != nil {
+		t.Errorf("A chave nova deveria ser encontrada após a recarga: %v", err)
+	}
+
+	// Falha na recarga mantém as chaves em cache
+	fail = true
+	now = now.Add(2 * time.Hour)
+	if _, err := jwks.Key(ctx, "nova", algES256); err != nil {
+		t.Errorf("As chaves em cache deveriam ser usadas: %v", err)
+	}
+}
+
+func TestJWKS_ConcurrentRefresh(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	document := jwksDocument(t, map[string]interface{}{"nova": &key.PublicKey})
+
+	var fetches int32
+	jwks := newJWKS("teste", time.Hour, func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(10 * time.Millisecond)
+		return document, nil
+	})
+
+	// Requisições simultâneas com o kid desconhecido: uma única recarga
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := jwks.Key(context.Background(), "nova", algES256); err != nil {
+				t.Errorf("Erro inesperado: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Errorf("Esperada 1 recarga do JWKS, obtidas %d", n)
+	}
+}
+
+func TestParseJWKS_InvalidKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	invalid := []map[string]string{
+		{"kty": "RSA", "kid": "legada", "use": "sig", "n": b64(weak.N.Bytes()), "e": b64(big.NewInt(int64(weak.E)).Bytes())},
+		{"kty": "RSA", "kid": "sem-e", "use": "sig", "n": b64(weak.N.Bytes()), "e": ""},
+	}
+
+	var doc struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	json.Unmarshal(jwksDocument(t, map[string]interface{}{"valida": &key.PublicKey}), &doc)
+	doc.Keys = append(invalid, doc.Keys...)
+	data, _ := json.Marshal(doc)
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		t.Fatalf("Chaves inválidas deveriam ser ignoradas: %v", err)
+	}
+	if _, ok := keys["valida"]; !ok || len(keys) != 1 {
+		t.Errorf("Esperada apenas a chave válida, obtidas %v", keys)
+	}
+
+	// Sem nenhuma chave utilizável, o documento é rejeitado
+	data, _ = json.Marshal(map[string]interface{}{"keys": invalid})
+	if _, err := parseJWKS(data); err == nil {
+		t.Error("Esperado erro para JWKS sem chaves válidas")
+	}
+}
+
+func TestParseRoleScopes(t *testing.T) {
+	roleScopes, err := ParseRoleScopes("admin=produtos:admin, leitor=produtos:read ,")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(roleScopes) != 2 || roleScopes["admin"][0] != ScopeProdutosAdmin || roleScopes["leitor"][0] != ScopeProdutosRead {
+		t.Errorf("Mapeamento inesperado: %v", roleScopes)
+	}
+	for _, value := range []string{"admin", "admin=", "=produtos:read", "admin=produtos:tudo"} {
+		if _, err := ParseRoleScopes(value); err == nil {
+			t.Errorf("%q deveria ser rejeitado", value)
+		}
+	}
+}
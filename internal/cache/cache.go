@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	sortpkg "sort"
 	"time"
 )
 
@@ -50,7 +51,8 @@ func GenerateProdutoKey(id int) string {
 }
 
 // GenerateProdutosListKey gera uma chave de cache para lista de produtos
-func GenerateProdutosListKey(page, pageSize int, filters map[string]interface{}) string {
+// sort e fields devem estar na forma canônica (ver dto.SortRequest.String e dto.FieldsRequest.String)
+func GenerateProdutosListKey(page, pageSize int, filters map[string]interface{}, sort, fields string) string {
 	key := ProdutoKeyGenerator.Generate("list")
 	if page > 0 {
 		key += ":page:" + fmt.Sprintf("%d", page)
@@ -58,11 +60,49 @@ func GenerateProdutosListKey(page, pageSize int, filters map[string]interface{})
 	if pageSize > 0 {
 		key += ":size:" + fmt.Sprintf("%d", pageSize)
 	}
-	// Adicionar filtros à chave se existirem
-	if filters != nil && len(filters) > 0 {
+	if sort != "" {
+		key += ":sort:" + sort
+	}
+	if fields != "" {
+		key += ":fields:" + fields
+	}
+	return appendFiltersKey(key, filters)
+}
+
+// ProdutosAggregatesVersionKey guarda a versão das facetas e estatísticas em cache
+// Cada escrita grava uma nova versão; como a versão faz parte das chaves de facetas
+// e estatísticas, as entradas calculadas antes dela deixam de ser lidas (e expiram
+// pelo TTL), sem precisar listar as chaves de cada filtro
+var ProdutosAggregatesVersionKey = ProdutoKeyGenerator.Generate("aggregates", "version")
+
+// GenerateProdutosFacetsKey gera uma chave de cache para as facetas de uma lista de produtos
+// As facetas não dependem da página nem da ordenação, apenas dos filtros
+// facets deve estar na forma canônica (ver dto.FacetRequest.String) e version é a
+// versão lida de ProdutosAggregatesVersionKey
+func GenerateProdutosFacetsKey(version string, filters map[string]interface{}, facets string) string {
+	key := ProdutoKeyGenerator.Generate("facets", version, facets)
+	return appendFiltersKey(key, filters)
+}
+
+// GenerateProdutosStatsKey gera uma chave de cache para as estatísticas de produtos
+// version é a versão lida de ProdutosAggregatesVersionKey
+func GenerateProdutosStatsKey(version string, filters map[string]interface{}) string {
+	key := ProdutoKeyGenerator.Generate("stats", version)
+	return appendFiltersKey(key, filters)
+}
+
+// appendFiltersKey adiciona os filtros à chave se existirem
+func appendFiltersKey(key string, filters map[string]interface{}) string {
+	if len(filters) > 0 {
+		// Ordenar as chaves para que o mesmo filtro gere sempre a mesma chave
 		// Simplificado: em produção, seria melhor usar hash dos filtros
-		for k, v := range filters {
-			key += ":" + k + ":" + fmt.Sprintf("%v", v)
+		keys := make([]string, 0, len(filters))
+		for k := range filters {
+			keys = append(keys, k)
+		}
+		sortpkg.Strings(keys)
+		for _, k := range keys {
+			key += ":" + k + ":" + fmt.Sprintf("%v", filters[k])
 		}
 	}
 	return key
@@ -5,10 +5,17 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"api-go-arquitetura/internal/auth"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/ratelimit"
 )
 
 // Config contém todas as configurações da aplicação
 type Config struct {
+	// Repository
+	RepositoryType string // "mongo" ou "memory"
+
 	// MongoDB
 	MongoURI      string
 	Database      string
@@ -20,6 +27,8 @@ type Config struct {
 	WriteTimeout  time.Duration
 	IdleTimeout   time.Duration
 	ShutdownTimeout time.Duration
+	ExportTimeout   time.Duration // Prazo de escrita de GET /produtos/export, que substitui WriteTimeout (0 = sem prazo)
+	ImportTimeout   time.Duration // Prazo de leitura de POST /produtos/import, que substitui ReadTimeout (0 = sem prazo)
 	
 	// Database Pool
 	MaxPoolSize  uint64
@@ -36,6 +45,55 @@ type Config struct {
 	RedisPassword  string        // Senha do Redis
 	RedisDB        int           // Database do Redis
 	
+	// HTTP Cache (requisições condicionais com ETag/Last-Modified)
+	CacheControlItem string // Cache-Control de GET /produtos/{id}
+	CacheControlList string // Cache-Control de GET /produtos
+
+	// Busca
+	SearchRegexEnabled bool   // Permite filtros com match=regex (expressões regulares do cliente)
+	SearchLanguage     string // Idioma do índice de texto (stemming), ex: "portuguese", "english", "none"
+
+	// Admin
+	AdminToken string // Token das rotas administrativas sem autenticação (vazio = desabilitadas); com AUTH_ENABLED vale o escopo produtos:admin
+
+	// Autenticação por API key
+	AuthEnabled          bool           // Exigir credenciais com escopo nas rotas de produtos
+	AuthAPIKeys          []model.APIKey // Chaves provisionadas na inicialização (apenas o hash é mantido)
+	AuthLastUsedInterval time.Duration  // Intervalo mínimo entre registros de last_used_at da mesma chave
+	authAPIKeysErr       error
+
+	// Autenticação por bearer token JWT (habilitada com AUTH_ENABLED e uma fonte de chaves)
+	JWTJWKSURL       string              // URL do JWKS (RS256/ES256), ex: jwks_uri do provedor OIDC
+	JWTJWKSFile      string              // Arquivo local com o JWKS (alternativa à URL)
+	JWTJWKSRefresh   time.Duration       // Validade do JWKS em cache
+	JWTHS256Secret   string              // Segredo HS256, apenas para desenvolvimento
+	JWTIssuer        string              // Valor exigido na claim iss (obrigatório com JWKS; vazio não verifica)
+	JWTAudience      string              // Valor exigido na claim aud (obrigatório com JWKS; vazio não verifica)
+	JWTLeeway        time.Duration       // Tolerância de relógio em exp e nbf
+	JWTRolesClaim    string              // Claim com os papéis (pontos para claims aninhadas)
+	JWTRoleScopes    map[string][]string // Escopos concedidos a cada papel
+	jwtRoleScopesErr error
+
+	// Rate limit (por IP do cliente)
+	RateLimitStore    string        // "memory" (por réplica) ou "redis" (compartilhado entre réplicas)
+	RateLimitRequests int           // Requisições permitidas por período
+	RateLimitPeriod   time.Duration // Período do limite
+	RateLimitBurst    int           // Rajada máxima (0 = RateLimitRequests)
+
+	// Políticas por rota, método e identidade (avaliadas em ordem antes do limite
+	// padrão) e redes isentas de limite (ex: scrapers internos)
+	RateLimitPolicies    []ratelimit.Policy
+	RateLimitExemptCIDRs []string
+	// Proxies confiáveis (CIDRs): o IP do cliente vem do X-Forwarded-For enviado por
+	// eles; vazio = IP da conexão (headers de IP do cliente são ignorados)
+	TrustedProxies       []string
+	rateLimitPoliciesErr error
+
+	// Retenção de produtos deletados (soft delete)
+	SoftDeleteRetention time.Duration // Tempo até a remoção definitiva (0 = desabilitado)
+	RetentionInterval   time.Duration // Intervalo entre execuções do job de retenção
+	RetentionDryRun     bool          // Apenas registrar o que seria removido
+
 	// CORS
 	CORSAllowedOrigins []string // Origens permitidas (vazio = todas)
 	CORSAllowedMethods []string // Métodos permitidos
@@ -46,6 +104,10 @@ type Config struct {
 // Load carrega as configurações da aplicação a partir de variáveis de ambiente
 // com valores padrão apropriados
 func Load() Config {
+	authAPIKeys, authAPIKeysErr := auth.ParseAPIKeys(os.Getenv("AUTH_API_KEYS"))
+	jwtRoleScopes, jwtRoleScopesErr := auth.ParseRoleScopes(os.Getenv("JWT_ROLE_SCOPES"))
+	rateLimitPolicies, rateLimitPoliciesErr := getRateLimitPoliciesEnv("RATE_LIMIT_POLICIES_FILE", "RATE_LIMIT_POLICIES")
+
 	port := getEnv("PORT", "8080")
 	// Garantir que a porta tenha o formato correto
 	if !strings.HasPrefix(port, ":") {
@@ -53,6 +115,9 @@ func Load() Config {
 	}
 
 	return Config{
+		// Repository
+		RepositoryType: getEnv("REPOSITORY_TYPE", "mongo"), // mongo ou memory
+
 		// MongoDB
 		MongoURI:       getEnv("MONGO_URI", "mongodb://localhost:27017"),
 		Database:        getEnv("MONGO_DB", "api_go"),
@@ -64,6 +129,8 @@ func Load() Config {
 		WriteTimeout:    getDurationEnv("WRITE_TIMEOUT", 15*time.Second),
 		IdleTimeout:     getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
 		ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ExportTimeout:   getDurationEnv("EXPORT_TIMEOUT", 30*time.Minute),
+		ImportTimeout:   getDurationEnv("IMPORT_TIMEOUT", 30*time.Minute),
 		
 		// Database Pool
 		MaxPoolSize: getUint64Env("MONGO_MAX_POOL_SIZE", 100),
@@ -80,31 +147,137 @@ func Load() Config {
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:        getIntEnv("REDIS_DB", 0),
 		
+		// HTTP Cache
+		CacheControlItem: getEnv("CACHE_CONTROL_ITEM", "no-cache"),
+		CacheControlList: getEnv("CACHE_CONTROL_LIST", "no-cache"),
+
+		// Busca
+		SearchRegexEnabled: getBoolEnv("SEARCH_REGEX_ENABLED", false),
+		SearchLanguage:     getEnv("SEARCH_LANGUAGE", "portuguese"),
+
+		// Admin
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		// Autenticação (AUTH_API_KEYS: "id:chave:escopo1 escopo2", separadas por vírgula)
+		AuthEnabled:          getBoolEnv("AUTH_ENABLED", false),
+		AuthAPIKeys:          authAPIKeys,
+		AuthLastUsedInterval: getDurationEnv("AUTH_LAST_USED_INTERVAL", auth.DefaultTouchInterval),
+		authAPIKeysErr:       authAPIKeysErr,
+
+		// JWT (JWT_ROLE_SCOPES: "papel=escopo1 escopo2", separados por vírgula)
+		JWTJWKSURL:       getEnv("JWT_JWKS_URL", ""),
+		JWTJWKSFile:      getEnv("JWT_JWKS_FILE", ""),
+		JWTJWKSRefresh:   getDurationEnv("JWT_JWKS_REFRESH", auth.DefaultJWKSRefresh),
+		JWTHS256Secret:   getEnv("JWT_HS256_SECRET", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+		JWTLeeway:        getDurationEnv("JWT_LEEWAY", auth.DefaultJWTLeeway),
+		JWTRolesClaim:    getEnv("JWT_ROLES_CLAIM", "roles"),
+		JWTRoleScopes:    jwtRoleScopes,
+		jwtRoleScopesErr: jwtRoleScopesErr,
+
+		// Rate limit (usa REDIS_ADDR, REDIS_PASSWORD e REDIS_DB quando redis)
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitRequests:    getIntEnv("RATE_LIMIT_REQUESTS", 60),
+		RateLimitPeriod:      getDurationEnv("RATE_LIMIT_PERIOD", time.Minute),
+		RateLimitBurst:       getIntEnv("RATE_LIMIT_BURST", 0),
+		RateLimitPolicies:    rateLimitPolicies, // JSON de RATE_LIMIT_POLICIES_FILE ou RATE_LIMIT_POLICIES
+		rateLimitPoliciesErr: rateLimitPoliciesErr,
+		RateLimitExemptCIDRs: getStringSliceEnv("RATE_LIMIT_EXEMPT_CIDRS", nil), // ex: 10.0.0.0/8,127.0.0.1
+		TrustedProxies:       getStringSliceEnv("TRUSTED_PROXIES", nil),         // ex: 10.0.0.0/8
+
+		// Retenção de produtos deletados
+		SoftDeleteRetention: getRetentionEnv("SOFT_DELETE_RETENTION", 0), // ex: 720h ou 30d
+		RetentionInterval:   getDurationEnv("RETENTION_INTERVAL", time.Hour),
+		RetentionDryRun:     getBoolEnv("RETENTION_DRY_RUN", false),
+
 		// CORS
 		CORSAllowedOrigins: getStringSliceEnv("CORS_ALLOWED_ORIGINS", []string{"*"}),
 		CORSAllowedMethods: getStringSliceEnv("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
-		CORSAllowedHeaders: getStringSliceEnv("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization"}),
+		CORSAllowedHeaders: getStringSliceEnv("CORS_ALLOWED_HEADERS", []string{"Content-Type", "Authorization", "If-Match", "X-API-Key"}),
 		CORSCredentials:    getBoolEnv("CORS_CREDENTIALS", false),
 	}
 }
 
 // Validate valida as configurações e retorna erro se alguma estiver inválida
 func (c *Config) Validate() error {
+	if c.RepositoryType != "mongo" && c.RepositoryType != "memory" {
+		return fmt.Errorf("REPOSITORY_TYPE inválido: %s (use mongo ou memory)", c.RepositoryType)
+	}
+	if c.Port == "" {
+		return fmt.Errorf("PORT não pode ser vazio")
+	}
+	if c.SoftDeleteRetention < 0 {
+		return fmt.Errorf("SOFT_DELETE_RETENTION não pode ser negativo")
+	}
+	if c.SoftDeleteRetention > 0 && c.RetentionInterval <= 0 {
+		return fmt.Errorf("RETENTION_INTERVAL deve ser maior que zero")
+	}
+	if c.authAPIKeysErr != nil {
+		return fmt.Errorf("AUTH_API_KEYS inválido: %w", c.authAPIKeysErr)
+	}
+	if c.AuthLastUsedInterval < 0 {
+		return fmt.Errorf("AUTH_LAST_USED_INTERVAL não pode ser negativo")
+	}
+	if c.JWTJWKSURL != "" && c.JWTJWKSFile != "" {
+		return fmt.Errorf("use apenas uma fonte de chaves JWT: JWT_JWKS_URL ou JWT_JWKS_FILE")
+	}
+	// Tokens de provedores externos (JWKS) precisam ser restritos ao emissor e à
+	// audiência desta API: sem isso, tokens emitidos para outras aplicações seriam aceitos
+	if (c.JWTJWKSURL != "" || c.JWTJWKSFile != "") && (c.JWTIssuer == "" || c.JWTAudience == "") {
+		return fmt.Errorf("JWT_ISSUER e JWT_AUDIENCE são obrigatórios com JWT_JWKS_URL ou JWT_JWKS_FILE")
+	}
+	if c.JWTHS256Secret != "" && len(c.JWTHS256Secret) < auth.MinHS256SecretLength {
+		return fmt.Errorf("JWT_HS256_SECRET deve ter pelo menos %d bytes", auth.MinHS256SecretLength)
+	}
+	if c.JWTLeeway < 0 {
+		return fmt.Errorf("JWT_LEEWAY não pode ser negativo")
+	}
+	if c.jwtRoleScopesErr != nil {
+		return fmt.Errorf("JWT_ROLE_SCOPES inválido: %w", c.jwtRoleScopesErr)
+	}
+	if c.RateLimitStore != "memory" && c.RateLimitStore != "redis" {
+		return fmt.Errorf("RATE_LIMIT_STORE inválido: %s (use memory ou redis)", c.RateLimitStore)
+	}
+	if c.RateLimitRequests <= 0 {
+		return fmt.Errorf("RATE_LIMIT_REQUESTS deve ser maior que zero")
+	}
+	if c.RateLimitPeriod <= 0 {
+		return fmt.Errorf("RATE_LIMIT_PERIOD deve ser maior que zero")
+	}
+	if c.RateLimitBurst < 0 {
+		return fmt.Errorf("RATE_LIMIT_BURST não pode ser negativo")
+	}
+	if c.rateLimitPoliciesErr != nil {
+		return c.rateLimitPoliciesErr
+	}
+	if _, err := ratelimit.ParseCIDRs(c.RateLimitExemptCIDRs); err != nil {
+		return fmt.Errorf("RATE_LIMIT_EXEMPT_CIDRS inválido: %w", err)
+	}
+	if _, err := ratelimit.ParseCIDRs(c.TrustedProxies); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES inválido: %w", err)
+	}
+	// As configurações do MongoDB só são obrigatórias quando ele é usado
+	if c.RepositoryType == "memory" {
+		return nil
+	}
 	if c.MongoURI == "" {
 		return fmt.Errorf("MONGO_URI não pode ser vazia")
 	}
 	if c.Database == "" {
 		return fmt.Errorf("MONGO_DB não pode ser vazio")
 	}
-	if c.Port == "" {
-		return fmt.Errorf("PORT não pode ser vazio")
-	}
 	if c.ConnectTimeout <= 0 {
 		return fmt.Errorf("MONGO_CONNECT_TIMEOUT deve ser maior que zero")
 	}
 	return nil
 }
 
+// JWTEnabled informa se há uma fonte de chaves para validar tokens JWT
+func (c *Config) JWTEnabled() bool {
+	return c.JWTJWKSURL != "" || c.JWTJWKSFile != "" || c.JWTHS256Secret != ""
+}
+
 // getEnv obtém uma variável de ambiente ou retorna o valor padrão
 func getEnv(key, def string) string {
 	if value := os.Getenv(key); value != "" {
@@ -123,6 +296,50 @@ func getDurationEnv(key string, def time.Duration) time.Duration {
 	return def
 }
 
+// getRetentionEnv obtém uma variável de ambiente como duration, aceitando também
+// dias com o sufixo "d" (ex: "30d"), ou retorna o valor padrão
+func getRetentionEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	if strings.HasSuffix(value, "d") {
+		var days int
+		if _, err := fmt.Sscanf(strings.TrimSuffix(value, "d"), "%d", &days); err == nil {
+			return time.Duration(days) * 24 * time.Hour
+		}
+		return def
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+	return def
+}
+
+// getRateLimitPoliciesEnv lê as políticas de rate limit do arquivo JSON indicado em
+// fileKey ou, sem arquivo, do JSON da variável jsonKey
+func getRateLimitPoliciesEnv(fileKey, jsonKey string) ([]ratelimit.Policy, error) {
+	var data []byte
+	source := fileKey
+	if file := os.Getenv(fileKey); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileKey, err)
+		}
+		data = content
+	} else if value := os.Getenv(jsonKey); value != "" {
+		data, source = []byte(value), jsonKey
+	} else {
+		return nil, nil
+	}
+
+	policies, err := ratelimit.ParsePolicies(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return policies, nil
+}
+
 // getUint64Env obtém uma variável de ambiente como uint64 ou retorna o valor padrão
 func getUint64Env(key string, def uint64) uint64 {
 	if value := os.Getenv(key); value != "" {
@@ -119,8 +119,19 @@ func Disconnect(ctx context.Context, client *mongo.Client) error {
 	return nil
 }
 
+// TextIndexName é o nome do índice de texto (busca textual) da coleção de produtos
+const TextIndexName = "idx_texto"
+
+// TextIndexWeights define o peso de cada campo na relevância da busca textual
+var TextIndexWeights = map[string]int{
+	"nome":      10,
+	"descricao": 2,
+}
+
 // CreateIndexes cria índices otimizados para a coleção de produtos
-func CreateIndexes(ctx context.Context, client *mongo.Client, database, collection string) error {
+// textLanguage é o idioma padrão do índice de texto (stemming e stop words), ex: "portuguese"
+// O MongoDB não altera um índice existente: para trocar o idioma, remova o idx_texto antes
+func CreateIndexes(ctx context.Context, client *mongo.Client, database, collection, textLanguage string) error {
 	col := client.Database(database).Collection(collection)
 
 	// Índice único no campo ID
@@ -147,8 +158,23 @@ func CreateIndexes(ctx context.Context, client *mongo.Client, database, collecti
 		Options: options.Index().SetName("idx_preco"),
 	}
 
+	// Índice de texto ponderado para a busca textual ($text)
+	weights := bson.D{}
+	textKeys := bson.D{}
+	for _, field := range []string{"nome", "descricao"} {
+		textKeys = append(textKeys, bson.E{Key: field, Value: "text"})
+		weights = append(weights, bson.E{Key: field, Value: TextIndexWeights[field]})
+	}
+	textIndex := mongo.IndexModel{
+		Keys: textKeys,
+		Options: options.Index().
+			SetName(TextIndexName).
+			SetWeights(weights).
+			SetDefaultLanguage(textLanguage),
+	}
+
 	// Criar todos os índices
-	indexes := []mongo.IndexModel{idIndex, nomeIndex, descricaoIndex, precoIndex}
+	indexes := []mongo.IndexModel{idIndex, nomeIndex, descricaoIndex, precoIndex, textIndex}
 	_, err := col.Indexes().CreateMany(ctx, indexes)
 	if err != nil {
 		return fmt.Errorf("erro ao criar índices: %w", err)
@@ -0,0 +1,59 @@
+package dto
+
+import "api-go-arquitetura/internal/errors"
+
+// MaxBatchSize limita o número de operações de um lote
+const MaxBatchSize = 1000
+
+// Operações aceitas em um lote
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchPatch  = "patch"
+	BatchDelete = "delete"
+)
+
+// BatchRequest representa um lote de operações sobre produtos
+// @Description Lote de operações de criação, atualização e remoção de produtos
+type BatchRequest struct {
+	// Tudo ou nada: a falha de uma operação desfaz as demais (requer transações no MongoDB)
+	Atomic     bool                    `json:"atomic" example:"false"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest representa uma operação do lote
+// produto segue o formato do corpo da rota individual (POST, PUT ou PATCH)
+type BatchOperationRequest struct {
+	Op      string               `json:"op" example:"update" enums:"create,update,patch,delete"`
+	ID      int                  `json:"id,omitempty" example:"1"`      // Obrigatório em update, patch e delete
+	Version *int                 `json:"version,omitempty" example:"3"` // Versão esperada do produto (equivalente ao If-Match); ausente = sem condição
+	Produto *BatchProdutoRequest `json:"produto,omitempty"`
+}
+
+// BatchProdutoRequest representa os dados do produto de uma operação do lote
+// Os campos obrigatórios dependem da operação (como em CreateProdutoRequest ou
+// PatchProdutoRequest) e são validados pelo service em cada operação
+type BatchProdutoRequest struct {
+	Nome      *string  `json:"nome,omitempty" example:"Notebook"`
+	Preco     *float64 `json:"preco,omitempty" example:"3500.00"`
+	Descricao *string  `json:"descricao,omitempty" example:"Notebook de alta performance"`
+}
+
+// BatchItemResult representa o resultado de uma operação do lote
+type BatchItemResult struct {
+	Index  int    `json:"index" example:"0"` // Posição da operação no lote
+	Op     string `json:"op" example:"update"`
+	ID     int    `json:"id,omitempty" example:"1"`
+	Status int    `json:"status" example:"200"` // Status HTTP equivalente ao da rota individual
+	// Produto resultante (create, update e patch), na representação negociada
+	Produto interface{}      `json:"produto,omitempty" swaggertype:"object"`
+	Error   *errors.APIError `json:"error,omitempty"`
+}
+
+// BatchResponse representa o resultado de um lote (207 Multi-Status)
+type BatchResponse struct {
+	Atomic    bool              `json:"atomic"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
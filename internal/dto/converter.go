@@ -37,6 +37,43 @@ func (r *PatchProdutoRequest) ToMap() map[string]interface{} {
 	return updates
 }
 
+// NewPatchProdutoRequest reconstrói o PatchProdutoRequest das alterações de um
+// patch (inverso de ToMap), para validá-las com as regras da rota individual
+func NewPatchProdutoRequest(updates map[string]interface{}) PatchProdutoRequest {
+	var r PatchProdutoRequest
+	if nome, ok := updates["nome"].(string); ok {
+		r.Nome = &nome
+	}
+	if preco, ok := updates["preco"].(float64); ok {
+		r.Preco = &preco
+	}
+	if descricao, ok := updates["descricao"].(string); ok {
+		r.Descricao = &descricao
+	}
+	return r
+}
+
+// ToModel converte BatchProdutoRequest para model.Produto (campos ausentes ficam vazios)
+func (r *BatchProdutoRequest) ToModel() model.Produto {
+	var p model.Produto
+	if r.Nome != nil {
+		p.Nome = *r.Nome
+	}
+	if r.Preco != nil {
+		p.Preco = *r.Preco
+	}
+	if r.Descricao != nil {
+		p.Descricao = *r.Descricao
+	}
+	return p
+}
+
+// ToMap converte BatchProdutoRequest para as alterações de um patch
+func (r *BatchProdutoRequest) ToMap() map[string]interface{} {
+	patch := PatchProdutoRequest{Nome: r.Nome, Preco: r.Preco, Descricao: r.Descricao}
+	return patch.ToMap()
+}
+
 // FromModel converte model.Produto para ProdutoResponse
 func FromModel(p model.Produto) ProdutoResponse {
 	return ProdutoResponse{
@@ -44,6 +81,7 @@ func FromModel(p model.Produto) ProdutoResponse {
 		Nome:      p.Nome,
 		Preco:     p.Preco,
 		Descricao: p.Descricao,
+		DeletedAt: p.DeletedAt,
 	}
 }
 
@@ -0,0 +1,173 @@
+package dto
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cursor representa a posição de um produto em uma listagem ordenada (paginação por keyset)
+// Guarda os campos de ordenação, suas direções e os valores do último produto retornado;
+// o ID é sempre o último campo, garantindo uma posição única
+type Cursor struct {
+	Keys       []string      `bson:"k"`
+	Directions []int         `bson:"d"`
+	Values     []interface{} `bson:"v"`
+}
+
+// keysetSort retorna a ordenação com o ID como último critério de desempate,
+// a mesma aplicada pelo repositório
+func keysetSort(sort bson.D) bson.D {
+	for _, e := range sort {
+		if e.Key == "id" {
+			return sort
+		}
+	}
+	result := make(bson.D, 0, len(sort)+1)
+	result = append(result, sort...)
+	return append(result, bson.E{Key: "id", Value: 1})
+}
+
+// sortDirection converte o valor de ordenação do MongoDB (1 ou -1) para int
+func sortDirection(v interface{}) int {
+	switch d := v.(type) {
+	case int:
+		if d < 0 {
+			return -1
+		}
+	case int32:
+		if d < 0 {
+			return -1
+		}
+	case int64:
+		if d < 0 {
+			return -1
+		}
+	}
+	return 1
+}
+
+// NewCursor cria o cursor que aponta para o produto, na ordenação informada
+func NewCursor(sort bson.D, p model.Produto) (Cursor, error) {
+	data, err := bson.Marshal(p)
+	if err != nil {
+		return Cursor{}, err
+	}
+	doc := bson.Raw(data)
+
+	sort = keysetSort(sort)
+	cursor := Cursor{
+		Keys:       make([]string, 0, len(sort)),
+		Directions: make([]int, 0, len(sort)),
+		Values:     make([]interface{}, 0, len(sort)),
+	}
+	for _, e := range sort {
+		value, err := doc.LookupErr(e.Key)
+		if err != nil {
+			return Cursor{}, fmt.Errorf("campo de ordenação %s não encontrado no produto", e.Key)
+		}
+		cursor.Keys = append(cursor.Keys, e.Key)
+		cursor.Directions = append(cursor.Directions, sortDirection(e.Value))
+		cursor.Values = append(cursor.Values, value)
+	}
+	return cursor, nil
+}
+
+// Encode serializa o cursor em uma string opaca (BSON em base64 URL-safe)
+// O BSON preserva os tipos dos valores (datas, números e strings)
+func (c Cursor) Encode() (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor desserializa um cursor gerado por Encode e verifica se ele
+// corresponde à ordenação da listagem
+func DecodeCursor(encoded string, sort bson.D) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("cursor inválido")
+	}
+	var cursor Cursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, fmt.Errorf("cursor inválido")
+	}
+
+	sort = keysetSort(sort)
+	if len(cursor.Keys) != len(sort) || len(cursor.Directions) != len(sort) || len(cursor.Values) != len(sort) {
+		return Cursor{}, fmt.Errorf("cursor não corresponde à ordenação da listagem")
+	}
+	for i, e := range sort {
+		if cursor.Keys[i] != e.Key || cursor.Directions[i] != sortDirection(e.Value) {
+			return Cursor{}, fmt.Errorf("cursor não corresponde à ordenação da listagem")
+		}
+		// O cursor não é assinado: um valor que não seja do tipo do campo (ex: um
+		// documento {"$ne": null}) injetaria operadores na consulta
+		if !cursorValueAllowed(e.Key, cursor.Values[i]) {
+			return Cursor{}, fmt.Errorf("cursor inválido")
+		}
+	}
+	return cursor, nil
+}
+
+// cursorValueAllowed verifica se o valor decodificado tem o tipo escalar do campo de ordenação
+func cursorValueAllowed(key string, value interface{}) bool {
+	switch key {
+	case "id", "preco":
+		switch value.(type) {
+		case int32, int64, float64:
+			return true
+		}
+	case "nome", "descricao":
+		_, ok := value.(string)
+		return ok
+	case "created_at", "updated_at":
+		_, ok := value.(primitive.DateTime)
+		return ok
+	}
+	return false
+}
+
+// ToMongoFilter gera a condição que seleciona os produtos posteriores ao cursor
+// Para a ordenação (a, b, id) a condição é:
+// a > va OU (a = va E b > vb) OU (a = va E b = vb E id > vid)
+// com $lt no lugar de $gt para campos em ordem decrescente
+func (c Cursor) ToMongoFilter() map[string]interface{} {
+	clauses := make([]interface{}, 0, len(c.Keys))
+	for i, key := range c.Keys {
+		clause := make(map[string]interface{}, i+1)
+		for j := 0; j < i; j++ {
+			clause[c.Keys[j]] = c.Values[j]
+		}
+		op := "$gt"
+		if c.Directions[i] < 0 {
+			op = "$lt"
+		}
+		clause[key] = map[string]interface{}{op: c.Values[i]}
+		clauses = append(clauses, clause)
+	}
+	if len(clauses) == 1 {
+		return clauses[0].(map[string]interface{})
+	}
+	return map[string]interface{}{"$or": clauses}
+}
+
+// WithCursor retorna uma cópia do filtro restrita aos produtos posteriores ao cursor
+func WithCursor(filter map[string]interface{}, cursor Cursor) map[string]interface{} {
+	result := make(map[string]interface{}, len(filter)+1)
+	for k, v := range filter {
+		result[k] = v
+	}
+	conditions := []interface{}{cursor.ToMongoFilter()}
+	if existing, ok := filter["$and"].([]interface{}); ok {
+		conditions = append(append([]interface{}{}, existing...), conditions...)
+	}
+	result["$and"] = conditions
+	return result
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// facetAllowedFields são as facetas que podem ser solicitadas
+var facetAllowedFields = map[string]bool{
+	model.FacetPreco: true,
+	model.FacetNome:  true,
+}
+
+// FacetRequest representa as facetas solicitadas para uma listagem ou busca
+type FacetRequest struct {
+	Fields []string `json:"fields" example:"preco"`
+}
+
+// GetFacetsFromQuery extrai as facetas do parâmetro de query (ex: "preco,nome")
+// Os nomes são separados por vírgula
+func GetFacetsFromQuery(facetsParam string) FacetRequest {
+	var request FacetRequest
+	for _, field := range strings.Split(facetsParam, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" {
+			request.Fields = append(request.Fields, field)
+		}
+	}
+	return request
+}
+
+// IsEmpty verifica se nenhuma faceta foi solicitada
+func (f *FacetRequest) IsEmpty() bool {
+	return len(f.Fields) == 0
+}
+
+// Validate valida as facetas solicitadas e remove repetições
+func (f *FacetRequest) Validate() error {
+	seen := make(map[string]bool, len(f.Fields))
+	fields := make([]string, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		if !facetAllowedFields[field] {
+			return fmt.Errorf("faceta inválida: %s. Facetas permitidas: %s, %s", field, model.FacetPreco, model.FacetNome)
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	f.Fields = fields
+	return nil
+}
+
+// String retorna as facetas na forma canônica (ordenadas e separadas por vírgula),
+// usada na chave de cache
+func (f *FacetRequest) String() string {
+	fields := append([]string(nil), f.Fields...)
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,175 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fieldsAllowed são os campos de ProdutoResponseV2 que podem ser selecionados
+// O nome na resposta é o mesmo do documento no MongoDB
+var fieldsAllowed = map[string]bool{
+	"id":         true,
+	"nome":       true,
+	"preco":      true,
+	"descricao":  true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+	"version":    true,
+}
+
+// fieldsV2Only são os campos ausentes da representação v1 (ProdutoResponse)
+var fieldsV2Only = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"version":    true,
+}
+
+// FieldsRequest representa os campos solicitados (sparse fieldset)
+// Vazio significa todos os campos
+type FieldsRequest struct {
+	Fields []string `json:"fields" example:"id,nome,preco"`
+}
+
+// GetFieldsFromQuery extrai os campos do parâmetro de query (ex: "id,nome,preco")
+func GetFieldsFromQuery(fieldsParam string) FieldsRequest {
+	var request FieldsRequest
+	for _, field := range strings.Split(fieldsParam, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			request.Fields = append(request.Fields, field)
+		}
+	}
+	return request
+}
+
+// IsEmpty verifica se nenhum campo foi solicitado (resposta completa)
+func (f *FieldsRequest) IsEmpty() bool {
+	return len(f.Fields) == 0
+}
+
+// Validate valida os campos solicitados e remove repetições
+func (f *FieldsRequest) Validate() error {
+	seen := make(map[string]bool, len(f.Fields))
+	fields := make([]string, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		if !fieldsAllowed[field] {
+			return fmt.Errorf("campo inválido em fields: %s. Campos permitidos: id, nome, preco, descricao, created_at, updated_at, deleted_at, version", field)
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	f.Fields = fields
+	return nil
+}
+
+// ValidateV1 verifica se os campos solicitados existem na representação v1
+func (f *FieldsRequest) ValidateV1() error {
+	for _, field := range f.Fields {
+		if fieldsV2Only[field] {
+			return fmt.Errorf("campo %s disponível apenas na representação v2 (/api/v2 ou Accept: %s)", field, MediaTypeProdutoV2)
+		}
+	}
+	return nil
+}
+
+// String retorna os campos na forma canônica (ordenados e separados por vírgula),
+// usada na chave de cache
+func (f *FieldsRequest) String() string {
+	fields := append([]string(nil), f.Fields...)
+	sort.Strings(fields)
+	return strings.Join(fields, ",")
+}
+
+// ToProjection retorna os campos a buscar no banco: os solicitados mais os campos
+// da ordenação, necessários para gerar o cursor da próxima página
+// Retorna nil quando todos os campos foram solicitados
+func (f *FieldsRequest) ToProjection(mongoSort bson.D) []string {
+	if f.IsEmpty() {
+		return nil
+	}
+	projection := append([]string(nil), f.Fields...)
+	for _, e := range mongoSort {
+		if !f.has(e.Key) {
+			projection = append(projection, e.Key)
+		}
+	}
+	return projection
+}
+
+// has verifica se o campo foi solicitado
+func (f *FieldsRequest) has(field string) bool {
+	for _, requested := range f.Fields {
+		if requested == field {
+			return true
+		}
+	}
+	return false
+}
+
+// SparseProdutoResponse é a representação parcial de um produto: apenas os campos
+// solicitados estão presentes (os demais são omitidos, e não preenchidos com zero)
+type SparseProdutoResponse map[string]json.RawMessage
+
+// Select retorna a representação do produto (ProdutoResponse ou ProdutoResponseV2)
+// com apenas os campos solicitados
+// Na v1, deleted_at só aparece em produtos deletados, assim como na resposta completa
+func (f *FieldsRequest) Select(p interface{}) (SparseProdutoResponse, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	sparse := make(SparseProdutoResponse, len(f.Fields))
+	for _, field := range f.Fields {
+		if value, ok := all[field]; ok {
+			sparse[field] = value
+		}
+	}
+	return sparse, nil
+}
+
+// SelectList aplica Select a uma lista de produtos
+func (f *FieldsRequest) SelectList(produtos []ProdutoResponse) ([]SparseProdutoResponse, error) {
+	result := make([]SparseProdutoResponse, 0, len(produtos))
+	for _, p := range produtos {
+		sparse, err := f.Select(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sparse)
+	}
+	return result, nil
+}
+
+// SelectListV2 aplica Select a uma lista de produtos da API v2
+func (f *FieldsRequest) SelectListV2(produtos []ProdutoResponseV2) ([]SparseProdutoResponse, error) {
+	result := make([]SparseProdutoResponse, 0, len(produtos))
+	for _, p := range produtos {
+		sparse, err := f.Select(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sparse)
+	}
+	return result, nil
+}
+
+// PaginatedSparseProdutoListResponse representa uma resposta paginada com campos selecionados
+type PaginatedSparseProdutoListResponse struct {
+	Produtos   []SparseProdutoResponse `json:"produtos"`
+	Pagination PaginationResponse      `json:"pagination"`
+	Facets     *model.Facets           `json:"facets,omitempty"`
+}
@@ -1,29 +1,141 @@
 package dto
 
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/rsql"
+)
+
+// Modos de listagem de produtos deletados (soft delete)
+const (
+	DeletedExclude = "exclude" // Apenas produtos ativos (padrão)
+	DeletedInclude = "include" // Produtos ativos e deletados
+	DeletedOnly    = "only"    // Apenas produtos deletados (lixeira)
+)
+
+// Modos de comparação dos filtros de texto (nome e descricao)
+const (
+	MatchContains = "contains" // Contém o texto, sem diferenciar maiúsculas (padrão)
+	MatchPrefix   = "prefix"   // Começa com o texto (diferencia maiúsculas para usar o índice)
+	MatchExact    = "exact"    // Igual ao texto (diferencia maiúsculas)
+	MatchRegex    = "regex"    // Expressão regular informada pelo cliente (requer habilitação)
+)
+
+// MaxRegexLength limita o tamanho das expressões regulares informadas no modo regex
+const MaxRegexLength = 100
+
+// FilterFields são os campos permitidos na expressão filter (ver pacote rsql) e seus tipos
+// deleted_at não é permitido: os produtos deletados são controlados pelo parâmetro deleted
+var FilterFields = rsql.Schema{
+	"id":         rsql.Integer,
+	"nome":       rsql.String,
+	"preco":      rsql.Number,
+	"descricao":  rsql.String,
+	"created_at": rsql.Time,
+	"updated_at": rsql.Time,
+	"version":    rsql.Integer,
+}
+
 // FilterRequest representa os filtros de busca
 type FilterRequest struct {
 	Nome      *string  `json:"nome,omitempty"`      // Busca por nome (contém)
 	PrecoMin  *float64 `json:"precoMin,omitempty"`  // Preço mínimo
 	PrecoMax  *float64 `json:"precoMax,omitempty"`  // Preço máximo
 	Descricao *string  `json:"descricao,omitempty"` // Busca por descrição (contém)
+	Deleted   string   `json:"deleted,omitempty"`   // Modo de produtos deletados: exclude, include ou only
+	Match     string   `json:"match,omitempty"`     // Modo de comparação de nome/descricao: contains, prefix, exact ou regex
+	// Filtros de data (RFC3339)
+	CreatedFrom  *time.Time `json:"createdFrom,omitempty"`  // Criados a partir de (inclusive)
+	CreatedTo    *time.Time `json:"createdTo,omitempty"`    // Criados até (inclusive)
+	UpdatedSince *time.Time `json:"updatedSince,omitempty"` // Alterados a partir de (inclusive); inclui deletados por padrão (sincronização incremental)
+	// Expressão RSQL/FIQL combinada (E) com os demais filtros (ex: preco=gt=100;nome=like=note*)
+	Expression string `json:"filter,omitempty"`
+}
+
+// Validate valida os filtros
+func (f *FilterRequest) Validate() error {
+	switch f.Deleted {
+	case "", DeletedExclude, DeletedInclude, DeletedOnly:
+	default:
+		return fmt.Errorf("valor inválido para deleted: %s. Valores permitidos: exclude, include, only", f.Deleted)
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedFrom.After(*f.CreatedTo) {
+		return fmt.Errorf("createdFrom deve ser anterior ou igual a createdTo")
+	}
+
+	// Erros da expressão são *rsql.Error, com a posição do problema
+	if _, err := f.expressionFilter(); err != nil {
+		return err
+	}
+
+	switch f.Match {
+	case "", MatchContains, MatchPrefix, MatchExact:
+	case MatchRegex:
+		// Rejeitar expressões inválidas ou longas antes de enviá-las ao banco
+		for _, pattern := range []*string{f.Nome, f.Descricao} {
+			if pattern == nil || *pattern == "" {
+				continue
+			}
+			if len(*pattern) > MaxRegexLength {
+				return fmt.Errorf("expressão regular muito longa (máximo de %d caracteres)", MaxRegexLength)
+			}
+			if _, err := regexp.Compile(*pattern); err != nil {
+				return fmt.Errorf("expressão regular inválida: %s", *pattern)
+			}
+		}
+	default:
+		return fmt.Errorf("valor inválido para match: %s. Valores permitidos: contains, prefix, exact, regex", f.Match)
+	}
+	return nil
+}
+
+// textCondition gera a condição de um filtro de texto de acordo com o modo de comparação
+// Exceto no modo regex, o texto do cliente é escapado e nunca interpretado como expressão
+func (f *FilterRequest) textCondition(value string) interface{} {
+	switch f.Match {
+	case MatchExact:
+		return value
+	case MatchPrefix:
+		// Prefixo ancorado e sem a opção "i": o MongoDB consegue usar o índice
+		return map[string]interface{}{"$regex": "^" + regexp.QuoteMeta(value)}
+	case MatchRegex:
+		return map[string]interface{}{
+			"$regex":   value,
+			"$options": "i", // Case insensitive
+		}
+	}
+	return map[string]interface{}{
+		"$regex":   regexp.QuoteMeta(value),
+		"$options": "i", // Case insensitive
+	}
 }
 
 // ToMongoFilter converte FilterRequest para filtro MongoDB
+// O filtro gerado é completo, inclusive a condição de soft delete (deleted_at)
 func (f *FilterRequest) ToMongoFilter() map[string]interface{} {
 	filter := make(map[string]interface{})
 
+	// Filtro de produtos deletados (soft delete)
+	switch f.deletedMode() {
+	case DeletedInclude:
+		// Inclusão explícita: sem condição, o repositório considera apenas os ativos
+		filter["deleted_at"] = model.IncludeDeleted
+	case DeletedOnly:
+		filter["deleted_at"] = map[string]interface{}{"$exists": true}
+	default:
+		filter["deleted_at"] = map[string]interface{}{"$exists": false}
+	}
+
 	if f.Nome != nil && *f.Nome != "" {
-		filter["nome"] = map[string]interface{}{
-			"$regex":   *f.Nome,
-			"$options": "i", // Case insensitive
-		}
+		filter["nome"] = f.textCondition(*f.Nome)
 	}
 
 	if f.Descricao != nil && *f.Descricao != "" {
-		filter["descricao"] = map[string]interface{}{
-			"$regex":   *f.Descricao,
-			"$options": "i", // Case insensitive
-		}
+		filter["descricao"] = f.textCondition(*f.Descricao)
 	}
 
 	// Filtro de preço
@@ -38,14 +150,66 @@ func (f *FilterRequest) ToMongoFilter() map[string]interface{} {
 		filter["preco"] = precoFilter
 	}
 
+	// Filtros de data
+	createdFilter := make(map[string]interface{})
+	if f.CreatedFrom != nil {
+		createdFilter["$gte"] = *f.CreatedFrom
+	}
+	if f.CreatedTo != nil {
+		createdFilter["$lte"] = *f.CreatedTo
+	}
+	if len(createdFilter) > 0 {
+		filter["created_at"] = createdFilter
+	}
+	// $gte (e não $gt): reenviar um produto alterado no mesmo instante do último
+	// sincronizado é inofensivo, enquanto perdê-lo não seria
+	if f.UpdatedSince != nil {
+		filter["updated_at"] = map[string]interface{}{"$gte": *f.UpdatedSince}
+	}
+
+	// Expressão filter (inválida apenas se Validate não foi chamado; nesse caso é ignorada)
+	// Fica dentro de $and para não conflitar com os campos dos demais filtros
+	if expression, err := f.expressionFilter(); err == nil && expression != nil {
+		filter["$and"] = []interface{}{expression}
+	}
+
 	return filter
 }
 
+// expressionFilter interpreta e compila a expressão filter (nil se vazia)
+func (f *FilterRequest) expressionFilter() (map[string]interface{}, error) {
+	if f.Expression == "" {
+		return nil, nil
+	}
+	node, err := rsql.Parse(f.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return rsql.Compile(node, FilterFields)
+}
+
+// deletedMode retorna o modo de produtos deletados efetivo
+// Com updatedSince e sem modo explícito, os deletados são incluídos: eles são as
+// marcas de remoção (tombstones, com deleted_at) que a sincronização incremental
+// precisa receber. Produtos removidos definitivamente (purge/retenção) não aparecem
+func (f *FilterRequest) deletedMode() string {
+	if f.Deleted == "" && f.UpdatedSince != nil {
+		return DeletedInclude
+	}
+	return f.Deleted
+}
+
 // IsEmpty verifica se o filtro está vazio
 func (f *FilterRequest) IsEmpty() bool {
 	return (f.Nome == nil || *f.Nome == "") &&
 		(f.PrecoMin == nil) &&
 		(f.PrecoMax == nil) &&
-		(f.Descricao == nil || *f.Descricao == "")
+		(f.Descricao == nil || *f.Descricao == "") &&
+		(f.Deleted == "" || f.Deleted == DeletedExclude) &&
+		f.Match == "" &&
+		f.CreatedFrom == nil &&
+		f.CreatedTo == nil &&
+		f.UpdatedSince == nil &&
+		f.Expression == ""
 }
 
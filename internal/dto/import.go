@@ -0,0 +1,42 @@
+package dto
+
+import "api-go-arquitetura/internal/errors"
+
+// ImportBatchSize é o número de linhas gravadas por lote durante a importação
+const ImportBatchSize = 500
+
+// MaxImportReportErrors limita os erros de linha listados no relatório; os demais
+// são apenas contados em rejected
+const MaxImportReportErrors = 1000
+
+// ImportRowError representa o erro de uma linha rejeitada
+type ImportRowError struct {
+	Line  int              `json:"line" example:"3"` // Linha no arquivo (no CSV, o cabeçalho é a linha 1)
+	Error *errors.APIError `json:"error"`
+}
+
+// ImportReport representa o resultado de uma importação
+// @Description Relatório da importação com as contagens e os erros por linha
+type ImportReport struct {
+	DryRun   bool             `json:"dryRun"`
+	Total    int              `json:"total" example:"1000"`   // Linhas lidas
+	Accepted int              `json:"accepted" example:"998"` // Linhas válidas (gravadas, exceto em dryRun)
+	Rejected int              `json:"rejected" example:"2"`
+	Created  int              `json:"created" example:"900"`
+	Updated  int              `json:"updated" example:"98"`
+	Errors   []ImportRowError `json:"errors"`
+	// ErrorsTruncated indica que havia mais erros do que MaxImportReportErrors
+	ErrorsTruncated bool `json:"errorsTruncated,omitempty"`
+	// Error é o motivo da interrupção da importação; as linhas anteriores já foram processadas
+	Error *errors.APIError `json:"error,omitempty"`
+}
+
+// Reject registra uma linha rejeitada
+func (r *ImportReport) Reject(line int, err *errors.APIError) {
+	r.Rejected++
+	if len(r.Errors) >= MaxImportReportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, ImportRowError{Line: line, Error: err})
+}
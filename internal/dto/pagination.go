@@ -2,8 +2,9 @@ package dto
 
 // PaginationRequest representa os parâmetros de paginação
 type PaginationRequest struct {
-	Page     int `json:"page"`     // Página atual (começa em 1)
-	PageSize int `json:"pageSize"` // Tamanho da página
+	Page     int    `json:"page"`     // Página atual (começa em 1)
+	PageSize int    `json:"pageSize"` // Tamanho da página
+	Cursor   string `json:"cursor"`   // Cursor opaco (nextCursor da página anterior); quando informado, Page é ignorado
 }
 
 // PaginationResponse representa os metadados de paginação na resposta
@@ -14,6 +15,7 @@ type PaginationResponse struct {
 	TotalItems int `json:"totalItems"`  // Total de itens
 	HasNext    bool `json:"hasNext"`   // Tem próxima página
 	HasPrev    bool `json:"hasPrev"`   // Tem página anterior
+	NextCursor string `json:"nextCursor,omitempty"` // Cursor da próxima página (paginação por keyset)
 }
 
 // Validate valida os parâmetros de paginação e aplica valores padrão
@@ -29,6 +31,11 @@ func (p *PaginationRequest) Validate() {
 	}
 }
 
+// IsCursor verifica se a paginação é por cursor (keyset) ao invés de offset
+func (p *PaginationRequest) IsCursor() bool {
+	return p.Cursor != ""
+}
+
 // GetSkip calcula o número de documentos a pular
 func (p *PaginationRequest) GetSkip() int64 {
 	return int64((p.Page - 1) * p.PageSize)
@@ -56,3 +63,20 @@ func NewPaginationResponse(page, pageSize, totalItems int) PaginationResponse {
 	}
 }
 
+
+// NewCursorPaginationResponse cria uma resposta de paginação por cursor
+// Na paginação por cursor não há número de página: Page é sempre zero
+func NewCursorPaginationResponse(pageSize, totalItems int, hasNext bool) PaginationResponse {
+	totalPages := (totalItems + pageSize - 1) / pageSize // Arredondamento para cima
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	return PaginationResponse{
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+		TotalItems: totalItems,
+		HasNext:    hasNext,
+		HasPrev:    true,
+	}
+}
@@ -1,12 +1,15 @@
 package dto
 
+import "time"
+
 // ProdutoResponse representa a resposta de um produto
 // @Description Resposta com dados do produto
 type ProdutoResponse struct {
-	ID        int     `json:"id" example:"1"`
-	Nome      string  `json:"nome" example:"Notebook"`
-	Preco     float64 `json:"preco" example:"3500.00"`
-	Descricao string  `json:"descricao" example:"Notebook de alta performance"`
+	ID        int        `json:"id" example:"1"`
+	Nome      string     `json:"nome" example:"Notebook"`
+	Preco     float64    `json:"preco" example:"3500.00"`
+	Descricao string     `json:"descricao" example:"Notebook de alta performance"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" example:"2024-01-15T10:30:00Z"` // Presente apenas em produtos deletados
 }
 
 // ProdutoListResponse representa uma lista de produtos
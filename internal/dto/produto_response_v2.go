@@ -0,0 +1,105 @@
+package dto
+
+import (
+	"strconv"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// MediaTypeProdutoV2 é o media type da representação v2 dos produtos, aceito no
+// header Accept das rotas /api/v1 e /api para negociar a representação
+const MediaTypeProdutoV2 = "application/vnd.produto.v2+json"
+
+// ProdutosV2Path é o caminho da coleção de produtos na API v2, base dos links
+const ProdutosV2Path = "/api/v2/produtos"
+
+// ProdutoLinks contém os links de navegação de um produto
+type ProdutoLinks struct {
+	Self       string `json:"self" example:"/api/v2/produtos/1"`
+	Collection string `json:"collection" example:"/api/v2/produtos"`
+	Restore    string `json:"restore,omitempty" example:"/api/v2/produtos/1/restore"` // Presente apenas em produtos deletados
+}
+
+// ProdutoResponseV2 representa a resposta de um produto na API v2
+// Diferente da v1, deleted_at está sempre presente (null em produtos ativos)
+// @Description Resposta com dados do produto, metadados e links (v2)
+type ProdutoResponseV2 struct {
+	ID        int          `json:"id" example:"1"`
+	Nome      string       `json:"nome" example:"Notebook"`
+	Preco     float64      `json:"preco" example:"3500.00"`
+	Descricao string       `json:"descricao" example:"Notebook de alta performance"`
+	CreatedAt time.Time    `json:"created_at" example:"2024-01-10T08:00:00Z"`
+	UpdatedAt time.Time    `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+	DeletedAt *time.Time   `json:"deleted_at" example:"2024-01-15T10:30:00Z"`
+	Version   int          `json:"version" example:"1"`
+	Links     ProdutoLinks `json:"links"`
+}
+
+// PaginatedProdutoListResponseV2 representa uma resposta paginada de produtos na API v2
+type PaginatedProdutoListResponseV2 struct {
+	Produtos   []ProdutoResponseV2 `json:"produtos"`
+	Pagination PaginationResponse  `json:"pagination"`
+	Facets     *model.Facets       `json:"facets,omitempty"` // Presente apenas quando solicitado (?facets=preco)
+}
+
+// SearchResultResponseV2 representa um produto encontrado pela busca textual na API v2
+type SearchResultResponseV2 struct {
+	ProdutoResponseV2
+	Score float64 `json:"score" example:"10.5"` // Relevância; maior é mais relevante
+}
+
+// PaginatedSearchResponseV2 representa uma resposta paginada da busca textual na API v2
+type PaginatedSearchResponseV2 struct {
+	Produtos   []SearchResultResponseV2 `json:"produtos"`
+	Pagination PaginationResponse       `json:"pagination"`
+	Facets     *model.Facets            `json:"facets,omitempty"`
+}
+
+// FromModelV2 converte model.Produto para ProdutoResponseV2
+func FromModelV2(p model.Produto) ProdutoResponseV2 {
+	self := ProdutosV2Path + "/" + strconv.Itoa(p.ID)
+	links := ProdutoLinks{
+		Self:       self,
+		Collection: ProdutosV2Path,
+	}
+	if p.DeletedAt != nil {
+		links.Restore = self + "/restore"
+	}
+
+	return ProdutoResponseV2{
+		ID:        p.ID,
+		Nome:      p.Nome,
+		Preco:     p.Preco,
+		Descricao: p.Descricao,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		DeletedAt: p.DeletedAt,
+		Version:   p.Version,
+		Links:     links,
+	}
+}
+
+// FromModelListV2 converte []model.Produto para []ProdutoResponseV2
+func FromModelListV2(produtos []model.Produto) []ProdutoResponseV2 {
+	responses := make([]ProdutoResponseV2, len(produtos))
+	for i, p := range produtos {
+		responses[i] = FromModelV2(p)
+	}
+	return responses
+}
+
+// FromSearchResultsV2 converte resultados da busca para a resposta paginada da API v2
+func FromSearchResultsV2(results []model.SearchResult, pagination PaginationResponse) PaginatedSearchResponseV2 {
+	produtos := make([]SearchResultResponseV2, 0, len(results))
+	for _, r := range results {
+		produtos = append(produtos, SearchResultResponseV2{
+			ProdutoResponseV2: FromModelV2(r.Produto),
+			Score:             r.Score,
+		})
+	}
+	return PaginatedSearchResponseV2{
+		Produtos:   produtos,
+		Pagination: pagination,
+	}
+}
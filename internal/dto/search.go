@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// MaxSearchQueryLength limita o tamanho do texto da busca textual
+const MaxSearchQueryLength = 200
+
+// SearchRequest representa os parâmetros da busca textual
+type SearchRequest struct {
+	Query string `json:"q" example:"notebook gamer"` // Termos buscados; "-termo" exclui resultados
+}
+
+// Validate valida os parâmetros da busca
+func (r *SearchRequest) Validate() error {
+	r.Query = strings.TrimSpace(r.Query)
+	if r.Query == "" {
+		return fmt.Errorf("o parâmetro q é obrigatório")
+	}
+	if len(r.Query) > MaxSearchQueryLength {
+		return fmt.Errorf("o parâmetro q deve ter no máximo %d caracteres", MaxSearchQueryLength)
+	}
+	return nil
+}
+
+// WithTextSearch retorna uma cópia do filtro com a condição de busca textual ($text)
+// O idioma (stemming) é o padrão do índice de texto
+func WithTextSearch(filter map[string]interface{}, query string) map[string]interface{} {
+	result := make(map[string]interface{}, len(filter)+1)
+	for k, v := range filter {
+		result[k] = v
+	}
+	result["$text"] = map[string]interface{}{"$search": query}
+	return result
+}
+
+// SearchResultResponse representa um produto encontrado pela busca textual
+// @Description Produto encontrado pela busca com a relevância
+type SearchResultResponse struct {
+	ProdutoResponse
+	Score float64 `json:"score" example:"10.5"` // Relevância; maior é mais relevante
+}
+
+// PaginatedSearchResponse representa uma resposta paginada da busca textual
+type PaginatedSearchResponse struct {
+	Produtos   []SearchResultResponse `json:"produtos"`
+	Pagination PaginationResponse     `json:"pagination"`
+	Facets     *model.Facets          `json:"facets,omitempty"` // Presente apenas quando solicitado (?facets=preco)
+}
+
+// FromSearchResults converte resultados da busca para a resposta paginada
+func FromSearchResults(results []model.SearchResult, pagination PaginationResponse) PaginatedSearchResponse {
+	produtos := make([]SearchResultResponse, 0, len(results))
+	for _, r := range results {
+		produtos = append(produtos, SearchResultResponse{
+			ProdutoResponse: FromModel(r.Produto),
+			Score:           r.Score,
+		})
+	}
+	return PaginatedSearchResponse{
+		Produtos:   produtos,
+		Pagination: pagination,
+	}
+}
@@ -7,78 +7,119 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// sortAllowedFields são os campos permitidos para ordenação
+var sortAllowedFields = map[string]bool{
+	"id":         true,
+	"nome":       true,
+	"preco":      true,
+	"descricao":  true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// SortField representa um campo de ordenação
+type SortField struct {
+	Field string `json:"field" example:"preco"` // Campo para ordenar (ex: "preco", "nome", "created_at")
+	Order string `json:"order" example:"asc"`   // Ordem: "asc" ou "desc" (padrão: "asc")
+}
+
 // SortRequest representa os parâmetros de ordenação
+// Os campos são aplicados em ordem de prioridade (ex: preco desc e, em caso de empate, nome asc)
 type SortRequest struct {
-	Field string `json:"field" query:"sort" example:"preco"` // Campo para ordenar (ex: "preco", "nome", "created_at")
-	Order string `json:"order" query:"order" example:"asc"`   // Ordem: "asc" ou "desc" (padrão: "asc")
+	Fields []SortField `json:"fields"`
+}
+
+// IsEmpty verifica se nenhuma ordenação foi informada
+func (s *SortRequest) IsEmpty() bool {
+	return len(s.Fields) == 0
 }
 
 // Validate valida os parâmetros de ordenação
 func (s *SortRequest) Validate() error {
-	if s.Field == "" {
-		return nil // Sem ordenação
-	}
-
-	// Campos permitidos para ordenação
-	allowedFields := map[string]bool{
-		"id":         true,
-		"nome":       true,
-		"preco":      true,
-		"descricao":  true,
-		"created_at": true,
-		"updated_at": true,
-	}
-
-	if !allowedFields[s.Field] {
-		return fmt.Errorf("campo de ordenação inválido: %s. Campos permitidos: id, nome, preco, descricao, created_at, updated_at", s.Field)
-	}
+	seen := make(map[string]bool, len(s.Fields))
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		if !sortAllowedFields[f.Field] {
+			return fmt.Errorf("campo de ordenação inválido: %s. Campos permitidos: id, nome, preco, descricao, created_at, updated_at", f.Field)
+		}
+		if seen[f.Field] {
+			return fmt.Errorf("campo de ordenação repetido: %s", f.Field)
+		}
+		seen[f.Field] = true
 
-	// Normalizar ordem
-	s.Order = strings.ToLower(s.Order)
-	if s.Order != "asc" && s.Order != "desc" {
-		s.Order = "asc" // Padrão
+		// Normalizar ordem
+		f.Order = strings.ToLower(f.Order)
+		if f.Order != "asc" && f.Order != "desc" {
+			f.Order = "asc" // Padrão
+		}
 	}
 
 	return nil
 }
 
 // ToMongoSort converte SortRequest para bson.D (formato de ordenação do MongoDB)
+// O ID é adicionado como último critério para que a ordem seja determinística
 func (s *SortRequest) ToMongoSort() bson.D {
-	if s.Field == "" {
-		// Ordenação padrão por ID
-		return bson.D{{Key: "id", Value: 1}}
+	sort := make(bson.D, 0, len(s.Fields)+1)
+	hasID := false
+	for _, f := range s.Fields {
+		order := 1 // asc
+		if f.Order == "desc" {
+			order = -1
+		}
+		if f.Field == "id" {
+			hasID = true
+		}
+		sort = append(sort, bson.E{Key: f.Field, Value: order})
 	}
 
-	order := 1 // asc
-	if s.Order == "desc" {
-		order = -1
+	// Ordenação padrão (e desempate) por ID
+	if !hasID {
+		sort = append(sort, bson.E{Key: "id", Value: 1})
 	}
+	return sort
+}
 
-	return bson.D{{Key: s.Field, Value: order}}
+// String retorna a forma canônica da ordenação, incluindo o desempate por ID
+// (ex: "preco:desc,nome:asc,id:asc"); usada na chave de cache
+func (s *SortRequest) String() string {
+	parts := make([]string, 0, len(s.Fields)+1)
+	for _, e := range s.ToMongoSort() {
+		order := "asc"
+		if sortDirection(e.Value) < 0 {
+			order = "desc"
+		}
+		parts = append(parts, e.Key+":"+order)
+	}
+	return strings.Join(parts, ",")
 }
 
 // GetSortFromQuery extrai parâmetros de ordenação da query string
-// Formato esperado: ?sort=preco&order=desc ou ?sort=preco:desc
+// Formatos aceitos: ?sort=preco:desc,nome:asc ou ?sort=preco&order=desc
+// O parâmetro order vale para os campos informados sem ordem explícita
 func GetSortFromQuery(sortParam, orderParam string) SortRequest {
 	sort := SortRequest{}
 
-	// Se sort contém ":", tratar como formato "campo:ordem"
-	if strings.Contains(sortParam, ":") {
-		parts := strings.Split(sortParam, ":")
-		if len(parts) == 2 {
-			sort.Field = parts[0]
-			sort.Order = parts[1]
-		}
-	} else {
-		sort.Field = sortParam
-		sort.Order = orderParam
+	// Se order não foi especificado, usar padrão
+	if orderParam == "" {
+		orderParam = "asc"
 	}
 
-	// Se order não foi especificado, usar padrão
-	if sort.Order == "" {
-		sort.Order = "asc"
+	for _, item := range strings.Split(sortParam, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		field := SortField{Field: item, Order: orderParam}
+		// Se o item contém ":", tratar como formato "campo:ordem"
+		// Itens com mais de um ":" são mantidos inteiros e rejeitados por Validate
+		if parts := strings.Split(item, ":"); len(parts) == 2 {
+			field.Field = strings.TrimSpace(parts[0])
+			field.Order = strings.TrimSpace(parts[1])
+		}
+		sort.Fields = append(sort.Fields, field)
 	}
 
 	return sort
 }
-
@@ -10,7 +10,10 @@ type APIError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
-	Status  int    `json:"-"` // Não serializa, usado apenas internamente
+	// Position é a posição (a partir de 0) do erro no parâmetro de entrada, quando
+	// aplicável (ex: erro de sintaxe na expressão filter)
+	Position *int `json:"position,omitempty"`
+	Status   int  `json:"-"` // Não serializa, usado apenas internamente
 }
 
 // Error implementa a interface error
@@ -31,6 +34,17 @@ func (e *APIError) WithDetails(details string) *APIError {
 	}
 }
 
+// WithPosition adiciona a posição do erro no parâmetro de entrada
+func (e *APIError) WithPosition(position int) *APIError {
+	return &APIError{
+		Code:     e.Code,
+		Message:  e.Message,
+		Details:  e.Details,
+		Position: &position,
+		Status:   e.Status,
+	}
+}
+
 // WithDetailsf adiciona detalhes formatados ao erro
 func (e *APIError) WithDetailsf(format string, args ...interface{}) *APIError {
 	return e.WithDetails(fmt.Sprintf(format, args...))
@@ -51,6 +65,25 @@ var (
 		Status:  http.StatusBadRequest,
 	}
 
+	ErrUnsupportedMediaType = &APIError{
+		Code:    "UNSUPPORTED_MEDIA_TYPE",
+		Message: "Content-Type não suportado",
+		Status:  http.StatusUnsupportedMediaType,
+	}
+
+	// Erros de autenticação e autorização (401, 403)
+	ErrUnauthorized = &APIError{
+		Code:    "UNAUTHORIZED",
+		Message: "Autenticação necessária",
+		Status:  http.StatusUnauthorized,
+	}
+
+	ErrForbidden = &APIError{
+		Code:    "FORBIDDEN",
+		Message: "Acesso negado",
+		Status:  http.StatusForbidden,
+	}
+
 	// Erros de recurso não encontrado (404)
 	ErrNotFound = &APIError{
 		Code:    "NOT_FOUND",
@@ -64,6 +97,28 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	// Erros de concorrência (412)
+	ErrPreconditionFailed = &APIError{
+		Code:    "PRECONDITION_FAILED",
+		Message: "O produto foi alterado por outra requisição",
+		Status:  http.StatusPreconditionFailed,
+	}
+
+	// Erros de operações em lote (424): a operação foi desfeita porque outra
+	// operação do mesmo lote atômico falhou
+	ErrBatchAborted = &APIError{
+		Code:    "BATCH_ABORTED",
+		Message: "Operação desfeita pela falha de outra operação do lote",
+		Status:  http.StatusFailedDependency,
+	}
+
+	// Erros de limite de requisições (429)
+	ErrTooManyRequests = &APIError{
+		Code:    "RATE_LIMIT_EXCEEDED",
+		Message: "Limite de requisições excedido",
+		Status:  http.StatusTooManyRequests,
+	}
+
 	// Erros de servidor (500)
 	ErrInternalServer = &APIError{
 		Code:    "INTERNAL_SERVER_ERROR",
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Colunas aceitas no cabeçalho do CSV
+const (
+	columnID        = "id"
+	columnNome      = "nome"
+	columnPreco     = "preco"
+	columnDescricao = "descricao"
+)
+
+// csvReader lê produtos de um CSV com cabeçalho
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int // Posição de cada coluna do cabeçalho
+}
+
+// NewCSVReader cria um leitor de CSV e lê o cabeçalho
+// Retorna erro se o cabeçalho tiver colunas desconhecidas, repetidas ou sem nome e preco
+func NewCSVReader(r io.Reader) (Reader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true   // Evita uma alocação por linha
+	reader.FieldsPerRecord = -1 // O número de colunas é verificado por linha, sem interromper a leitura
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("arquivo CSV vazio: o cabeçalho é obrigatório")
+		}
+		return nil, fmt.Errorf("cabeçalho CSV inválido: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // BOM de arquivos exportados por planilhas
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case columnID, columnNome, columnPreco, columnDescricao:
+		case "created_at", "updated_at", "deleted_at", "version":
+			// Colunas somente leitura do CSV exportado: aceitas e ignoradas, para que
+			// uma exportação possa ser reimportada
+		default:
+			return nil, fmt.Errorf("coluna desconhecida no cabeçalho CSV: %q. Colunas permitidas: id, nome, preco, descricao", name)
+		}
+		if _, repeated := columns[name]; repeated {
+			return nil, fmt.Errorf("coluna repetida no cabeçalho CSV: %q", name)
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{columnNome, columnPreco} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("coluna obrigatória ausente no cabeçalho CSV: %q", required)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+// Next lê a próxima linha do CSV
+// Erros de formato de uma linha (ex: aspas ou número de colunas) rejeitam apenas a linha
+func (r *csvReader) Next() (Row, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Row{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+		}
+		return Row{}, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	row := Row{Line: line}
+	if len(record) != len(r.columns) {
+		row.Err = fmt.Errorf("esperadas %d colunas, encontradas %d", len(r.columns), len(record))
+		return row, nil
+	}
+
+	row.Produto.Nome = r.field(record, columnNome)
+	row.Produto.Descricao = r.field(record, columnDescricao)
+
+	if preco := r.field(record, columnPreco); preco != "" {
+		row.Produto.Preco, err = strconv.ParseFloat(preco, 64)
+		if err != nil {
+			row.Err = fmt.Errorf("preco inválido: %q", preco)
+			return row, nil
+		}
+	}
+	if id := r.field(record, columnID); id != "" {
+		row.ID, err = strconv.Atoi(id)
+		if err != nil || row.ID <= 0 {
+			row.Err = fmt.Errorf("id inválido: %q", id)
+			return row, nil
+		}
+	}
+	return row, nil
+}
+
+// field retorna o valor da coluna (vazio se a coluna não existe no cabeçalho)
+func (r *csvReader) field(record []string, column string) string {
+	i, ok := r.columns[column]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,60 @@
+// Package importer lê arquivos de importação de produtos (CSV e NDJSON) linha a
+// linha, sem carregar o arquivo inteiro em memória
+//
+// CSV: a primeira linha é o cabeçalho, com as colunas nome, preco e, opcionalmente,
+// descricao e id (em qualquer ordem); as colunas somente leitura do CSV exportado
+// (created_at, updated_at, deleted_at e version) são ignoradas
+//
+// NDJSON: um objeto JSON por linha, com os mesmos campos; linhas em branco são ignoradas
+//
+// Linhas com id fazem upsert (atualizam o produto ou o criam com esse id); sem id, um
+// novo produto é sempre criado
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"api-go-arquitetura/internal/dto"
+)
+
+// Media types aceitos na importação
+const (
+	MediaTypeCSV    = "text/csv"
+	MediaTypeNDJSON = "application/x-ndjson"
+)
+
+// ErrUnsupportedMediaType indica um Content-Type sem leitor de importação
+var ErrUnsupportedMediaType = errors.New("media type não suportado")
+
+// Row é uma linha do arquivo de importação
+type Row struct {
+	Line    int                      // Linha no arquivo (a partir de 1; no CSV, o cabeçalho é a linha 1)
+	ID      int                      // Produto a atualizar; 0 cria um novo produto
+	Produto dto.CreateProdutoRequest // Dados do produto, ainda não validados
+	Err     error                    // Erro de leitura da linha: ela é rejeitada e a importação continua
+}
+
+// Reader lê as linhas de um arquivo de importação
+// Next retorna io.EOF ao final do arquivo; outros erros interrompem a importação
+type Reader interface {
+	Next() (Row, error)
+}
+
+// NewReader cria o leitor correspondente ao Content-Type informado
+func NewReader(contentType string, r io.Reader) (Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
+	}
+	switch strings.ToLower(mediaType) {
+	case MediaTypeCSV:
+		return NewCSVReader(r)
+	case MediaTypeNDJSON:
+		return NewNDJSONReader(r), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+}
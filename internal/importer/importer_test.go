@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll lê todas as linhas até io.EOF
+func readAll(t *testing.T, reader Reader) []Row {
+	t.Helper()
+	var rows []Row
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	t.Run("deve ler as linhas com as colunas em qualquer ordem", func(t *testing.T) {
+		input := "\ufeffpreco, Nome ,descricao,id\n" +
+			"3500.50,Notebook,\"Leve, 14\"\"\",\n" +
+			"50,Mouse,,7\n"
+		reader, err := NewCSVReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		rows := readAll(t, reader)
+		if len(rows) != 2 {
+			t.Fatalf("Esperadas 2 linhas, obtidas %d", len(rows))
+		}
+		first := rows[0]
+		if first.Line != 2 || first.ID != 0 || first.Produto.Nome != "Notebook" || first.Produto.Preco != 3500.50 || first.Produto.Descricao != `Leve, 14"` || first.Err != nil {
+			t.Errorf("Primeira linha inesperada: %+v", first)
+		}
+		if rows[1].Line != 3 || rows[1].ID != 7 {
+			t.Errorf("Segunda linha inesperada: %+v", rows[1])
+		}
+	})
+
+	t.Run("deve rejeitar apenas as linhas inválidas", func(t *testing.T) {
+		input := "nome,preco,id\n" +
+			"Mouse,abc,\n" +
+			"Teclado,150,\n" +
+			"Monitor,1500\n" +
+			"Webcam,300,-1\n" +
+			"Cabo,10,\n"
+		reader, err := NewCSVReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		rows := readAll(t, reader)
+		if len(rows) != 5 {
+			t.Fatalf("Esperadas 5 linhas, obtidas %d", len(rows))
+		}
+		for i, invalid := range []bool{true, false, true, true, false} {
+			if (rows[i].Err != nil) != invalid {
+				t.Errorf("Linha %d: erro esperado %v, obtido %v", rows[i].Line, invalid, rows[i].Err)
+			}
+		}
+	})
+
+	t.Run("deve rejeitar cabeçalhos inválidos", func(t *testing.T) {
+		for _, header := range []string{"", "nome,preco,sku\n", "nome,nome,preco\n", "nome,descricao\n"} {
+			if _, err := NewCSVReader(strings.NewReader(header)); err == nil {
+				t.Errorf("Cabeçalho %q deveria ser rejeitado", header)
+			}
+		}
+	})
+}
+
+func TestNDJSONReader(t *testing.T) {
+	t.Run("deve ler um objeto por linha e ignorar linhas em branco", func(t *testing.T) {
+		input := `{"nome": "Notebook", "preco": 3500, "descricao": "Leve"}` + "\n\n" +
+			`{"id": 7, "nome": "Mouse", "preco": 50}` + "\r\n" +
+			`{"nome": "Teclado", "preco": "caro"}` + "\n" +
+			`{"nome": "Monitor", "preco": 1500, "sku": "X"}` + "\n" +
+			`{"nome": "A", "preco": 1} {"nome": "B", "preco": 2}`
+		rows := readAll(t, NewNDJSONReader(strings.NewReader(input)))
+		if len(rows) != 5 {
+			t.Fatalf("Esperadas 5 linhas, obtidas %d", len(rows))
+		}
+		if rows[0].Line != 1 || rows[0].Produto.Nome != "Notebook" || rows[0].Err != nil {
+			t.Errorf("Primeira linha inesperada: %+v", rows[0])
+		}
+		if rows[1].Line != 3 || rows[1].ID != 7 || rows[1].Err != nil {
+			t.Errorf("Segunda linha inesperada: %+v", rows[1])
+		}
+		for _, row := range rows[2:] {
+			if row.Err == nil {
+				t.Errorf("Linha %d deveria ser rejeitada", row.Line)
+			}
+		}
+	})
+
+	t.Run("deve interromper a leitura em linhas muito longas", func(t *testing.T) {
+		input := `{"nome": "` + strings.Repeat("x", MaxNDJSONLineLength) + `", "preco": 1}`
+		if _, err := NewNDJSONReader(strings.NewReader(input)).Next(); err == nil || err == io.EOF {
+			t.Errorf("Esperado erro de linha muito longa, obtido %v", err)
+		}
+	})
+}
+
+func TestNewReader(t *testing.T) {
+	if _, err := NewReader("text/csv; charset=utf-8", strings.NewReader("nome,preco\n")); err != nil {
+		t.Errorf("CSV deveria ser aceito: %v", err)
+	}
+	if _, err := NewReader("application/x-ndjson", strings.NewReader("")); err != nil {
+		t.Errorf("NDJSON deveria ser aceito: %v", err)
+	}
+	for _, contentType := range []string{"application/json", ""} {
+		if _, err := NewReader(contentType, strings.NewReader("")); !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Errorf("%q: esperado ErrUnsupportedMediaType, obtido %v", contentType, err)
+		}
+	}
+}
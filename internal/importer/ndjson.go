@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxNDJSONLineLength limita o tamanho de uma linha NDJSON (e a memória usada por ela)
+const MaxNDJSONLineLength = 64 * 1024
+
+// ndjsonRow é o formato de uma linha NDJSON
+type ndjsonRow struct {
+	ID        int     `json:"id"`
+	Nome      string  `json:"nome"`
+	Preco     float64 `json:"preco"`
+	Descricao string  `json:"descricao"`
+}
+
+// ndjsonReader lê produtos de um arquivo NDJSON (um objeto JSON por linha)
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewNDJSONReader cria um leitor de NDJSON
+func NewNDJSONReader(r io.Reader) Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), MaxNDJSONLineLength)
+	return &ndjsonReader{scanner: scanner}
+}
+
+// Next lê a próxima linha não vazia
+// JSON inválido ou com campos desconhecidos rejeita apenas a linha; uma linha acima de
+// MaxNDJSONLineLength interrompe a leitura
+func (r *ndjsonReader) Next() (Row, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := Row{Line: r.line}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		var produto ndjsonRow
+		if err := decoder.Decode(&produto); err != nil {
+			row.Err = fmt.Errorf("JSON inválido: %v", err)
+			return row, nil
+		}
+		if decoder.More() {
+			row.Err = errors.New("JSON inválido: a linha deve conter um único objeto")
+			return row, nil
+		}
+		if produto.ID < 0 {
+			row.Err = fmt.Errorf("id inválido: %d", produto.ID)
+			return row, nil
+		}
+
+		row.ID = produto.ID
+		row.Produto.Nome = produto.Nome
+		row.Produto.Preco = produto.Preco
+		row.Produto.Descricao = produto.Descricao
+		return row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return Row{}, fmt.Errorf("linha %d excede o tamanho máximo de %d bytes", r.line+1, MaxNDJSONLineLength)
+		}
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
@@ -84,6 +84,24 @@ var (
 		[]string{"operation"},
 	)
 
+	// RetentionPurgedTotal é um contador de produtos removidos pela política de retenção
+	RetentionPurgedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retention_purged_total",
+			Help: "Total de produtos deletados removidos (ou que seriam removidos em dry-run) pela retenção",
+		},
+		[]string{"mode"}, // mode: purge, dry_run
+	)
+
+	// RetentionRuns é um contador de execuções do job de retenção
+	RetentionRuns = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retention_runs_total",
+			Help: "Total de execuções do job de retenção",
+		},
+		[]string{"mode", "status"}, // status: success, error
+	)
+
 	// DatabaseConnections é um gauge para conexões de banco de dados
 	DatabaseConnections = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -136,6 +154,14 @@ func RecordCacheError(operation string, duration time.Duration) {
 	RecordCacheOperation(operation, "error", duration)
 }
 
+// RecordRetentionRun registra uma execução do job de retenção
+func RecordRetentionRun(mode, status string, purged int64) {
+	RetentionRuns.WithLabelValues(mode, status).Inc()
+	if purged > 0 {
+		RetentionPurgedTotal.WithLabelValues(mode).Add(float64(purged))
+	}
+}
+
 // SetDatabaseConnections atualiza o número de conexões de banco de dados
 func SetDatabaseConnections(state string, count float64) {
 	DatabaseConnections.WithLabelValues(state).Set(count)
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// APIKey representa uma chave de acesso à API
+// A chave em si nunca é armazenada, apenas o seu hash (SHA-256)
+type APIKey struct {
+	ID         string     `json:"id" bson:"_id"`
+	Name       string     `json:"name" bson:"name"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// IsRevoked verifica se a chave foi revogada
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil && !k.RevokedAt.IsZero()
+}
@@ -0,0 +1,49 @@
+package model
+
+// Nomes das facetas
+const (
+	FacetPreco = "preco" // Faixas de preço
+	FacetNome  = "nome"  // Contagem por nome
+)
+
+// AttributeFacetLimit é o número máximo de valores de uma faceta de atributo
+const AttributeFacetLimit = 10
+
+// PrecoFacetBoundaries são os limites inferiores das faixas de preço da faceta:
+// cada faixa vai do seu limite (inclusive) até o próximo (exclusive) e a última
+// não tem limite superior
+var PrecoFacetBoundaries = []float64{0, 100, 500, 1000, 5000}
+
+// PriceBucket representa uma faixa de preço e a quantidade de produtos nela
+type PriceBucket struct {
+	Min   float64  `json:"min"`           // Limite inferior (inclusive)
+	Max   *float64 `json:"max,omitempty"` // Limite superior (exclusive); ausente na última faixa
+	Count int64    `json:"count"`
+}
+
+// PriceFacet representa a faceta de preço: menor e maior preço e contagem por faixa
+type PriceFacet struct {
+	Min     float64       `json:"min"`
+	Max     float64       `json:"max"`
+	Count   int64         `json:"count"` // Total de produtos considerados
+	Buckets []PriceBucket `json:"buckets"`
+}
+
+// AttributeCount representa um valor de atributo e a quantidade de produtos com ele
+type AttributeCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// AttributeFacet representa a contagem de produtos por valor de um atributo, dos
+// valores mais frequentes aos menos frequentes (até AttributeFacetLimit valores)
+type AttributeFacet struct {
+	Values []AttributeCount `json:"values"`
+}
+
+// Facets agrupa as facetas calculadas sobre o resultado de uma listagem ou busca
+// Apenas as facetas solicitadas são preenchidas
+type Facets struct {
+	Preco *PriceFacet     `json:"preco,omitempty"`
+	Nome  *AttributeFacet `json:"nome,omitempty"`
+}
@@ -12,8 +12,17 @@ type Produto struct {
 	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" bson:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"` // Soft delete
+	Version   int        `json:"version" bson:"version"`                           // Controle de concorrência otimista
 }
 
+// deletedScope é o tipo das condições especiais de deleted_at (ver IncludeDeleted)
+type deletedScope string
+
+// IncludeDeleted é a condição de deleted_at que inclui os produtos deletados em uma
+// consulta do repositório (ex: {"deleted_at": IncludeDeleted}). Sem condição sobre
+// deleted_at, as consultas consideram apenas produtos ativos
+const IncludeDeleted deletedScope = "include"
+
 // IsDeleted verifica se o produto foi deletado (soft delete)
 func (p *Produto) IsDeleted() bool {
 	return p.DeletedAt != nil && !p.DeletedAt.IsZero()
@@ -41,6 +50,9 @@ func (p *Produto) BeforeCreate() {
 	if p.UpdatedAt.IsZero() {
 		p.UpdatedAt = now
 	}
+	if p.Version == 0 {
+		p.Version = 1
+	}
 }
 
 // BeforeUpdate atualiza o timestamp de atualização
@@ -0,0 +1,7 @@
+package model
+
+// SearchResult representa um produto encontrado pela busca textual e sua relevância
+type SearchResult struct {
+	Produto `bson:",inline"`
+	Score   float64 `json:"score" bson:"score"` // Relevância (textScore); maior é mais relevante
+}
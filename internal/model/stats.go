@@ -0,0 +1,23 @@
+package model
+
+// StatsPercentiles são os percentis de preço calculados pelas estatísticas
+var StatsPercentiles = []float64{0.5, 0.9, 0.99}
+
+// ProdutoStats representa as estatísticas agregadas de um conjunto de produtos
+type ProdutoStats struct {
+	Count int64      `json:"count" example:"42"` // Total de produtos
+	Preco PrecoStats `json:"preco"`
+}
+
+// PrecoStats representa as estatísticas do preço
+// Os percentis são estimativas: o MongoDB 7.0+ usa o algoritmo aproximado do $percentile
+// (em versões anteriores, são calculados por posição na ordenação por preço)
+type PrecoStats struct {
+	Sum float64 `json:"sum" example:"147000.00"`
+	Avg float64 `json:"avg" example:"3500.00"`
+	Min float64 `json:"min" example:"50.00"`
+	Max float64 `json:"max" example:"7500.00"`
+	P50 float64 `json:"p50" example:"2500.00"`
+	P90 float64 `json:"p90" example:"5500.00"`
+	P99 float64 `json:"p99" example:"7500.00"`
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultSweepInterval é o intervalo padrão entre remoções de chaves ociosas
+const DefaultSweepInterval = time.Minute
+
+// memoryStore guarda o TAT de cada chave em memória (limites por processo)
+// Chaves cujo TAT já passou estão com o limite completo e são removidas
+// periodicamente, sem alterar o comportamento, para que o mapa não cresça sem limite
+type memoryStore struct {
+	mu            sync.Mutex
+	tats          map[string]time.Time
+	sweepInterval time.Duration
+	lastSweep     time.Time
+	now           func() time.Time
+}
+
+// NewMemoryStore cria um Store em memória com remoção de chaves ociosas
+func NewMemoryStore() Store {
+	return newMemoryStore(DefaultSweepInterval, time.Now)
+}
+
+func newMemoryStore(sweepInterval time.Duration, now func() time.Time) *memoryStore {
+	return &memoryStore{
+		tats:          make(map[string]time.Time),
+		sweepInterval: sweepInterval,
+		lastSweep:     now(),
+		now:           now,
+	}
+}
+
+// Allow consome uma requisição da chave, se o limite permitir
+func (s *memoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= s.sweepInterval {
+		s.sweep(now)
+	}
+
+	result, tat := decide(now, s.tats[key], limit)
+	if result.Allowed {
+		s.tats[key] = tat
+	}
+	return result, nil
+}
+
+// sweep remove as chaves ociosas; deve ser chamado com o lock adquirido
+func (s *memoryStore) sweep(now time.Time) {
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+	s.lastSweep = now
+}
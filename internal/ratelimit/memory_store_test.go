@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock é um relógio controlado pelo teste
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryStore_Allow(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	t.Run("deve aceitar a rajada e recusar a requisição seguinte", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		store := newMemoryStore(time.Minute, clock.Now)
+
+		for i, expectedRemaining := range []int{2, 1, 0} {
+			result, err := store.Allow(ctx, "a", limit)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if !result.Allowed || result.Limit != 3 || result.Remaining != expectedRemaining {
+				t.Errorf("Requisição %d: resultado inesperado %+v", i+1, result)
+			}
+		}
+
+		result, _ := store.Allow(ctx, "a", limit)
+		if result.Allowed || result.Remaining != 0 {
+			t.Errorf("A quarta requisição deveria ser recusada: %+v", result)
+		}
+		if result.RetryAfter != time.Second || result.ResetAfter != 3*time.Second {
+			t.Errorf("RetryAfter/ResetAfter inesperados: %+v", result)
+		}
+
+		// Outra chave tem o próprio limite
+		if result, _ := store.Allow(ctx, "b", limit); !result.Allowed {
+			t.Errorf("A chave b deveria ser aceita: %+v", result)
+		}
+	})
+
+	t.Run("deve liberar requisições na taxa sustentada", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		store := newMemoryStore(time.Minute, clock.Now)
+		for i := 0; i < 3; i++ {
+			store.Allow(ctx, "a", limit)
+		}
+
+		clock.Advance(time.Second)
+		if result, _ := store.Allow(ctx, "a", limit); !result.Allowed || result.Remaining != 0 {
+			t.Errorf("Uma requisição deveria ser liberada após 1s: %+v", result)
+		}
+		if result, _ := store.Allow(ctx, "a", limit); result.Allowed {
+			t.Errorf("Apenas uma requisição deveria ser liberada após 1s: %+v", result)
+		}
+
+		clock.Advance(10 * time.Second)
+		if result, _ := store.Allow(ctx, "a", limit); !result.Allowed || result.Remaining != 2 {
+			t.Errorf("O limite deveria estar completo após o período: %+v", result)
+		}
+	})
+
+	t.Run("deve respeitar o burst configurado", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		store := newMemoryStore(time.Minute, clock.Now)
+		burst := Limit{Requests: 60, Period: time.Minute, Burst: 1}
+
+		if result, _ := store.Allow(ctx, "a", burst); !result.Allowed || result.Limit != 1 {
+			t.Errorf("A primeira requisição deveria ser aceita: %+v", result)
+		}
+		if result, _ := store.Allow(ctx, "a", burst); result.Allowed || result.RetryAfter != time.Second {
+			t.Errorf("A segunda requisição deveria ser recusada por 1s: %+v", result)
+		}
+	})
+
+	t.Run("deve remover clientes ociosos", func(t *testing.T) {
+		clock := &fakeClock{now: time.Unix(1000, 0)}
+		store := newMemoryStore(time.Minute, clock.Now)
+		store.Allow(ctx, "a", limit)
+		store.Allow(ctx, "b", Limit{Requests: 1, Period: time.Hour})
+
+		clock.Advance(time.Minute)
+		store.Allow(ctx, "c", limit)
+
+		if _, ok := store.tats["a"]; ok {
+			t.Error("A chave ociosa a deveria ter sido removida")
+		}
+		if _, ok := store.tats["b"]; !ok {
+			t.Error("A chave b ainda está limitada e não deveria ser removida")
+		}
+		if len(store.tats) != 2 {
+			t.Errorf("Esperadas 2 chaves, obtidas %d", len(store.tats))
+		}
+	})
+}
+
+func TestLimit_Validate(t *testing.T) {
+	if err := (Limit{Requests: 60, Period: time.Minute}).Validate(); err != nil {
+		t.Errorf("Limite válido rejeitado: %v", err)
+	}
+	for _, limit := range []Limit{{Period: time.Minute}, {Requests: 1}, {Requests: 1, Period: time.Second, Burst: -1}} {
+		if err := limit.Validate(); err == nil {
+			t.Errorf("Limite %+v deveria ser rejeitado", limit)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// Identidades usadas para agrupar as requisições de uma política
+const (
+	KeyIP      = "ip"      // IP do cliente
+	KeyAPIKey  = "api_key" // API key apresentada (IP quando ausente)
+	KeySubject = "subject" // Sujeito autenticado (IP quando não autenticado)
+)
+
+// Policy associa um limite às requisições de uma rota e método
+type Policy struct {
+	Name    string   // Identifica a política nas chaves do store e nos logs
+	Path    string   // Padrão da rota (ver Matches); vazio casa todas as rotas
+	Methods []string // Métodos HTTP; vazio casa todos
+	Key     string   // Identidade do cliente: KeyIP (padrão), KeyAPIKey ou KeySubject
+	Limit   Limit
+	Exempt  bool // Requisições que casam com a política não são limitadas
+}
+
+// Matches informa se a política se aplica à requisição
+// O padrão é comparado com a rota registrada no Echo (ex: /api/v1/produtos/:id)
+// usando a sintaxe de path.Match, em que * casa um segmento; um padrão terminado
+// em /** casa o prefixo e tudo abaixo dele
+func (p Policy) Matches(method, route string) bool {
+	if len(p.Methods) > 0 {
+		matched := false
+		for _, m := range p.Methods {
+			if strings.EqualFold(m, method) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if p.Path == "" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(p.Path, "/**"); ok {
+		return route == prefix || strings.HasPrefix(route, prefix+"/")
+	}
+	matched, err := path.Match(p.Path, route)
+	return err == nil && matched
+}
+
+// Validate verifica se a política é utilizável
+func (p Policy) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name é obrigatório")
+	}
+	if p.Path != "" {
+		if _, err := path.Match(strings.TrimSuffix(p.Path, "/**"), ""); err != nil {
+			return fmt.Errorf("path inválido: %s", p.Path)
+		}
+	}
+	switch p.Key {
+	case KeyIP, KeyAPIKey, KeySubject:
+	default:
+		return fmt.Errorf("key inválida: %s (use %s, %s ou %s)", p.Key, KeyIP, KeyAPIKey, KeySubject)
+	}
+	if p.Exempt {
+		return nil
+	}
+	return p.Limit.Validate()
+}
+
+// FindPolicy retorna a primeira política que se aplica à requisição
+func FindPolicy(policies []Policy, method, route string) (Policy, bool) {
+	for _, p := range policies {
+		if p.Matches(method, route) {
+			return p, true
+		}
+	}
+	return Policy{}, false
+}
+
+// policyJSON é o formato de uma política na configuração
+type policyJSON struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Methods  []string `json:"methods"`
+	Key      string   `json:"key"`
+	Requests int      `json:"requests"`
+	Period   string   `json:"period"` // Duração do Go, ex: "1m"
+	Burst    int      `json:"burst"`
+	Exempt   bool     `json:"exempt"`
+}
+
+// ParsePolicies lê uma tabela de políticas em JSON, na ordem de avaliação
+//
+//	[
+//	  {"name": "observabilidade", "path": "/metrics", "exempt": true},
+//	  {"name": "escrita", "path": "/api/*/produtos/**", "methods": ["POST", "PUT", "PATCH", "DELETE"],
+//	   "key": "api_key", "requests": 10, "period": "1m"}
+//	]
+func ParsePolicies(data []byte) ([]Policy, error) {
+	var raw []policyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("políticas de rate limit inválidas: %w", err)
+	}
+
+	policies := make([]Policy, 0, len(raw))
+	for i, r := range raw {
+		p := Policy{
+			Name:    r.Name,
+			Path:    r.Path,
+			Methods: r.Methods,
+			Key:     r.Key,
+			Exempt:  r.Exempt,
+			Limit:   Limit{Requests: r.Requests, Burst: r.Burst},
+		}
+		if p.Key == "" {
+			p.Key = KeyIP
+		}
+		if r.Period != "" {
+			period, err := time.ParseDuration(r.Period)
+			if err != nil {
+				return nil, fmt.Errorf("política %d (%s): period inválido: %s", i, r.Name, r.Period)
+			}
+			p.Limit.Period = period
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("política %d (%s): %w", i, r.Name, err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// ParseCIDRs converte endereços e faixas CIDR (ex: 10.0.0.0/8, 127.0.0.1) em redes
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("endereço inválido: %s", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("faixa CIDR inválida: %s", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ContainsIP informa se o IP pertence a alguma das redes
+func ContainsIP(networks []*net.IPNet, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
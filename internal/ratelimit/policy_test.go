@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		method string
+		route  string
+		want   bool
+	}{
+		{"rota exata", Policy{Path: "/metrics"}, "GET", "/metrics", true},
+		{"rota diferente", Policy{Path: "/metrics"}, "GET", "/health", false},
+		{"segmento curinga", Policy{Path: "/api/*/produtos"}, "POST", "/api/v2/produtos", true},
+		{"curinga não cruza segmentos", Policy{Path: "/api/*"}, "GET", "/api/v1/produtos", false},
+		{"prefixo", Policy{Path: "/api/v1/produtos/**"}, "GET", "/api/v1/produtos/:id", true},
+		{"prefixo inclui a própria rota", Policy{Path: "/api/v1/produtos/**"}, "GET", "/api/v1/produtos", true},
+		{"prefixo não casa nomes parecidos", Policy{Path: "/api/v1/produtos/**"}, "GET", "/api/v1/produtosx", false},
+		{"método permitido", Policy{Methods: []string{"post", "PUT"}}, "POST", "/api/v1/produtos", true},
+		{"método não listado", Policy{Methods: []string{"POST"}}, "GET", "/api/v1/produtos", false},
+		{"sem path nem métodos", Policy{}, "DELETE", "/qualquer", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Matches(tt.method, tt.route); got != tt.want {
+				t.Errorf("Matches(%s, %s) = %v, esperado %v", tt.method, tt.route, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPolicy(t *testing.T) {
+	policies := []Policy{
+		{Name: "escrita", Path: "/api/v1/produtos", Methods: []string{"POST"}},
+		{Name: "api", Path: "/api/**"},
+	}
+	if p, ok := FindPolicy(policies, "POST", "/api/v1/produtos"); !ok || p.Name != "escrita" {
+		t.Errorf("Esperada a política escrita, obtida %+v", p)
+	}
+	if p, ok := FindPolicy(policies, "GET", "/api/v1/produtos"); !ok || p.Name != "api" {
+		t.Errorf("Esperada a política api, obtida %+v", p)
+	}
+	if _, ok := FindPolicy(policies, "GET", "/health"); ok {
+		t.Error("Nenhuma política deveria se aplicar a /health")
+	}
+}
+
+func TestParsePolicies(t *testing.T) {
+	t.Run("deve ler as políticas na ordem", func(t *testing.T) {
+		data := `[
+			{"name": "metrics", "path": "/metrics", "exempt": true},
+			{"name": "escrita", "path": "/api/*/produtos", "methods": ["POST"], "key": "api_key", "requests": 10, "period": "1m", "burst": 2}
+		]`
+		policies, err := ParsePolicies([]byte(data))
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if len(policies) != 2 {
+			t.Fatalf("Esperadas 2 políticas, obtidas %d", len(policies))
+		}
+		if !policies[0].Exempt || policies[0].Key != KeyIP {
+			t.Errorf("Primeira política inesperada: %+v", policies[0])
+		}
+		want := Limit{Requests: 10, Period: time.Minute, Burst: 2}
+		if policies[1].Key != KeyAPIKey || policies[1].Limit != want {
+			t.Errorf("Segunda política inesperada: %+v", policies[1])
+		}
+	})
+
+	t.Run("deve rejeitar políticas inválidas", func(t *testing.T) {
+		for _, data := range []string{
+			`{"name": "x"}`,
+			`[{"path": "/metrics", "exempt": true}]`,
+			`[{"name": "x", "requests": 10}]`,
+			`[{"name": "x", "requests": 10, "period": "1 minuto"}]`,
+			`[{"name": "x", "key": "usuario", "requests": 10, "period": "1m"}]`,
+			`[{"name": "x", "path": "/api/[", "exempt": true}]`,
+		} {
+			if _, err := ParsePolicies([]byte(data)); err == nil {
+				t.Errorf("%s deveria ser rejeitado", data)
+			}
+		}
+	})
+}
+
+func TestParseCIDRs(t *testing.T) {
+	networks, err := ParseCIDRs([]string{"10.0.0.0/8", " 127.0.0.1", "::1", ""})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"127.0.0.1":   true,
+		"127.0.0.2":   false,
+		"::1":         true,
+		"192.168.0.1": false,
+		"invalido":    false,
+	} {
+		if got := ContainsIP(networks, ip); got != want {
+			t.Errorf("ContainsIP(%s) = %v, esperado %v", ip, got, want)
+		}
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "localhost"} {
+		if _, err := ParseCIDRs([]string{value}); err == nil {
+			t.Errorf("%s deveria ser rejeitado", value)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package ratelimit implementa a limitação de requisições com o algoritmo GCRA
+// (Generic Cell Rate Algorithm), equivalente a um token bucket sem contador
+//
+// Para cada chave é guardado apenas o TAT (theoretical arrival time): o instante em
+// que o bucket estaria cheio novamente. Uma requisição é aceita se, somando o
+// intervalo de emissão (Period/Requests) ao TAT, ele não ultrapassar o instante
+// atual mais a tolerância de rajada (Burst intervalos)
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limit define o limite de uma chave: Requests requisições por Period, com rajadas
+// de até Burst requisições (Burst zero equivale a Requests)
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// Validate verifica se o limite é utilizável
+func (l Limit) Validate() error {
+	if l.Requests <= 0 {
+		return fmt.Errorf("requests deve ser maior que zero")
+	}
+	if l.Period <= 0 {
+		return fmt.Errorf("period deve ser maior que zero")
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("burst não pode ser negativo")
+	}
+	return nil
+}
+
+// emissionInterval é o intervalo entre requisições na taxa sustentada
+func (l Limit) emissionInterval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// burst retorna o tamanho efetivo da rajada
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// tolerance é quanto o TAT pode estar à frente do instante atual
+func (l Limit) tolerance() time.Duration {
+	return l.emissionInterval() * time.Duration(l.burst())
+}
+
+// Result é a decisão para uma requisição
+type Result struct {
+	Allowed    bool
+	Limit      int           // Tamanho da rajada (header X-RateLimit-Limit)
+	Remaining  int           // Requisições ainda permitidas imediatamente
+	ResetAfter time.Duration // Tempo até o limite estar completo novamente
+	RetryAfter time.Duration // Tempo até a próxima requisição ser aceita (zero se aceita)
+}
+
+// Store guarda o estado dos limites; implementações devem ser seguras para uso concorrente
+type Store interface {
+	// Allow consome uma requisição da chave, se o limite permitir
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// decide aplica o GCRA: tat é o TAT atual da chave (ou zero) e retorna o resultado
+// e o novo TAT a ser guardado (igual ao atual quando a requisição é recusada)
+func decide(now, tat time.Time, limit Limit) (Result, time.Time) {
+	emission := limit.emissionInterval()
+	tolerance := limit.tolerance()
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(emission)
+	allowAt := newTAT.Add(-tolerance)
+
+	result := Result{Limit: limit.burst()}
+	if allowAt.After(now) {
+		result.RetryAfter = allowAt.Sub(now)
+		result.ResetAfter = tat.Sub(now)
+		return result, tat
+	}
+
+	result.Allowed = true
+	result.ResetAfter = newTAT.Sub(now)
+	result.Remaining = remaining(result.ResetAfter, emission, tolerance)
+	return result, newTAT
+}
+
+// remaining calcula quantas requisições ainda cabem na tolerância
+func remaining(ahead, emission, tolerance time.Duration) int {
+	if emission <= 0 {
+		return 0
+	}
+	n := int((tolerance - ahead) / emission)
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix é o prefixo das chaves de limite no Redis
+const redisKeyPrefix = "ratelimit:"
+
+// gcraScript aplica o GCRA de forma atômica no Redis, com o relógio do próprio Redis
+// (o mesmo para todas as réplicas da API)
+// ARGV: intervalo de emissão e tolerância, em microssegundos
+// Retorna: aceita (1/0), TAT - agora e tempo até a próxima requisição aceita (µs)
+// A chave expira quando o limite volta a estar completo, removendo clientes ociosos
+// replicate_commands permite escrever depois de TIME no Redis < 5 (replica os comandos
+// em vez do script); a partir do Redis 5 esse já é o padrão e a chamada não faz nada
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local now = redis.call('TIME')
+now = tonumber(now[1]) * 1000000 + tonumber(now[2])
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - tolerance
+if allow_at > now then
+  return {0, tat - now, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil((new_tat - now) / 1000))
+return {1, new_tat - now, 0}
+`)
+
+// redisStore compartilha os limites entre réplicas usando Redis
+type redisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore cria um Store Redis, verificando a conexão
+func NewRedisStore(addr string, password string, db int) (Store, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	// Verificar conexão
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	return &redisStore{client: client}, nil
+}
+
+// Allow consome uma requisição da chave, se o limite permitir
+func (s *redisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	emission := limit.emissionInterval()
+	tolerance := limit.tolerance()
+
+	values, err := gcraScript.Run(ctx, s.client, []string{redisKeyPrefix + key},
+		emission.Microseconds(), tolerance.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("resposta inesperada do script de rate limit: %v", values)
+	}
+
+	result := Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.burst(),
+		ResetAfter: time.Duration(values[1]) * time.Microsecond,
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+	}
+	if result.Allowed {
+		result.Remaining = remaining(result.ResetAfter, emission, tolerance)
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestRedisStore_Parity verifica que o Store Redis decide como o Store em memória
+// Requer um Redis acessível em REDIS_TEST_ADDR (ex: localhost:6379)
+func TestRedisStore_Parity(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("Teste de integração - requer Redis rodando (defina REDIS_TEST_ADDR)")
+	}
+
+	store, err := NewRedisStore(addr, "", 0)
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	client := store.(*redisStore).client
+	defer client.Close()
+
+	ctx := context.Background()
+	memory := newMemoryStore(time.Minute, time.Now)
+
+	// O Redis usa o próprio relógio: com períodos longos, o tempo decorrido durante
+	// o teste não muda as decisões e afeta os tempos apenas em microssegundos
+	limits := map[string]Limit{
+		"rajada": {Requests: 3, Period: 3 * time.Hour},
+		"burst":  {Requests: 60, Period: time.Hour, Burst: 2},
+	}
+	for name, limit := range limits {
+		t.Run(name, func(t *testing.T) {
+			key := fmt.Sprintf("teste:%s:%d", name, time.Now().UnixNano())
+			defer client.Del(context.Background(), redisKeyPrefix+key)
+
+			for i := 0; i < limit.burst()+2; i++ {
+				want, _ := memory.Allow(ctx, key, limit)
+				got, err := store.Allow(ctx, key, limit)
+				if err != nil {
+					t.Fatalf("Erro inesperado: %v", err)
+				}
+				if got.Allowed != want.Allowed || got.Limit != want.Limit || got.Remaining != want.Remaining {
+					t.Errorf("Requisição %d: esperado %+v, obtido %+v", i+1, want, got)
+				}
+				if !near(got.RetryAfter, want.RetryAfter) || !near(got.ResetAfter, want.ResetAfter) {
+					t.Errorf("Requisição %d: tempos esperados %+v, obtidos %+v", i+1, want, got)
+				}
+			}
+		})
+	}
+}
+
+// near compara durações com a tolerância do tempo decorrido entre as duas decisões
+func near(a, b time.Duration) bool {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff < time.Second
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeysCollection é o nome da coleção que armazena as API keys
+const APIKeysCollection = "api_keys"
+
+// APIKeyRepository define a interface para as API keys
+type APIKeyRepository interface {
+	// FindByHash busca a chave pelo hash; retorna o erro "not found" se não existir
+	FindByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+	// Save cria ou substitui a chave com o mesmo ID, preservando created_at e last_used_at
+	Save(ctx context.Context, key model.APIKey) error
+	// TouchLastUsed registra o último uso da chave
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// mongoAPIKeyRepository implementa APIKeyRepository usando MongoDB
+type mongoAPIKeyRepository struct {
+	Collection *mongo.Collection
+}
+
+// NewAPIKeyRepository cria uma nova instância do APIKeyRepository
+func NewAPIKeyRepository(col *mongo.Collection) APIKeyRepository {
+	return &mongoAPIKeyRepository{Collection: col}
+}
+
+// CreateAPIKeyIndexes cria o índice único do hash das chaves
+func CreateAPIKeyIndexes(ctx context.Context, col *mongo.Collection) error {
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key_hash", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("idx_key_hash"),
+	})
+	return err
+}
+
+func (r *mongoAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := r.Collection.FindOne(ctx, bson.M{"key_hash": keyHash}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.APIKey{}, errors.New("not found")
+		}
+		return model.APIKey{}, err
+	}
+	return key, nil
+}
+
+func (r *mongoAPIKeyRepository) Save(ctx context.Context, key model.APIKey) error {
+	set := bson.M{
+		"name":     key.Name,
+		"key_hash": key.KeyHash,
+		"scopes":   key.Scopes,
+	}
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": bson.M{"created_at": key.CreatedAt},
+	}
+	if key.RevokedAt != nil {
+		set["revoked_at"] = key.RevokedAt
+	} else {
+		update["$unset"] = bson.M{"revoked_at": ""}
+	}
+	_, err := r.Collection.UpdateOne(ctx, bson.M{"_id": key.ID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	result, err := r.Collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": usedAt}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// memoryAPIKeyRepository implementa APIKeyRepository mantendo as chaves em memória
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]model.APIKey
+}
+
+// NewMemoryAPIKeyRepository cria uma nova instância do APIKeyRepository em memória
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{keys: make(map[string]model.APIKey)}
+}
+
+func (r *memoryAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return model.APIKey{}, errors.New("not found")
+}
+
+func (r *memoryAPIKeyRepository) Save(ctx context.Context, key model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Equivalente ao índice único idx_key_hash
+	for id, existing := range r.keys {
+		if id != key.ID && existing.KeyHash == key.KeyHash {
+			return errors.New("duplicate key hash")
+		}
+	}
+	if existing, ok := r.keys[key.ID]; ok {
+		key.CreatedAt = existing.CreatedAt
+		key.LastUsedAt = existing.LastUsedAt
+	}
+	r.keys[key.ID] = cloneAPIKey(key)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return errors.New("not found")
+	}
+	key.LastUsedAt = &usedAt
+	r.keys[id] = key
+	return nil
+}
+
+// cloneAPIKey copia a chave para que o chamador não altere o estado do repositório
+func cloneAPIKey(key model.APIKey) model.APIKey {
+	key.Scopes = append([]string(nil), key.Scopes...)
+	return key
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// Tipos de operação aceitos por BulkWrite
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkPatch  = "patch"
+	BulkDelete = "delete"
+	// BulkUpsert substitui os campos editáveis do produto ID ou, se ele não existir,
+	// o cria com esse ID. Um produto deletado não é alterado (erro "deleted")
+	BulkUpsert = "upsert"
+)
+
+// BulkOperation é uma operação de escrita executada por BulkWrite
+type BulkOperation struct {
+	Type            string                 // create, update, patch, delete ou upsert
+	ID              int                    // Produto alterado (update, patch, delete e upsert)
+	Produto         model.Produto          // Dados do produto (create, update e upsert)
+	Updates         map[string]interface{} // Campos alterados (patch)
+	ExpectedVersion *int                   // Versão exigida (update, patch e delete); nil = sem condição
+}
+
+// Version retorna a versão exigida pela operação, ou AnyVersion se não houver condição
+func (op BulkOperation) Version() int {
+	if op.ExpectedVersion == nil {
+		return AnyVersion
+	}
+	return *op.ExpectedVersion
+}
+
+// BulkResult é o resultado de uma operação de BulkWrite
+type BulkResult struct {
+	Produto model.Produto // Produto resultante (create, update, patch e upsert)
+	Created bool          // A operação criou o produto (create, ou upsert de ID inexistente)
+	Err     error         // Erro da operação, com as mesmas mensagens dos métodos individuais
+}
+
+// ErrBulkAborted é o erro das operações desfeitas (ou não executadas) porque outra
+// operação de um lote atômico falhou
+var ErrBulkAborted = errors.New("bulk aborted")
+
+// produtoWriter são as operações de escrita usadas por BulkWrite
+type produtoWriter interface {
+	Create(ctx context.Context, produto model.Produto) (model.Produto, error)
+	Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error)
+	Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error)
+	Delete(ctx context.Context, id int, expectedVersion int) error
+	// Upsert retorna também se o produto foi criado
+	Upsert(ctx context.Context, id int, produto model.Produto) (model.Produto, bool, error)
+}
+
+// applyBulkOperation executa uma operação do lote com as operações individuais do repositório
+func applyBulkOperation(ctx context.Context, w produtoWriter, op BulkOperation) BulkResult {
+	var result BulkResult
+	switch op.Type {
+	case BulkCreate:
+		result.Produto, result.Err = w.Create(ctx, op.Produto)
+		result.Created = result.Err == nil
+	case BulkUpdate:
+		result.Produto, result.Err = w.Update(ctx, op.ID, op.Produto, op.Version())
+	case BulkPatch:
+		result.Produto, result.Err = w.Patch(ctx, op.ID, op.Updates, op.Version())
+	case BulkDelete:
+		result.Err = w.Delete(ctx, op.ID, op.Version())
+	case BulkUpsert:
+		result.Produto, result.Created, result.Err = w.Upsert(ctx, op.ID, op.Produto)
+	default:
+		result.Err = fmt.Errorf("tipo de operação inválido: %s", op.Type)
+	}
+	return result
+}
+
+// abortBulkResults marca como desfeitas todas as operações do lote, exceto a que falhou
+func abortBulkResults(results []BulkResult, failed int) {
+	for i := range results {
+		if i != failed {
+			results[i] = BulkResult{Err: ErrBulkAborted}
+		}
+	}
+}
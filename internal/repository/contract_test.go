@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/repository/repositorytest"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestMemoryProdutoRepository_Contract executa a suíte de conformidade no repositório em memória
+func TestMemoryProdutoRepository_Contract(t *testing.T) {
+	repositorytest.RunProdutoRepositoryContract(t, func(t *testing.T) repository.ProdutoRepository {
+		return repository.NewMemoryProdutoRepository()
+	})
+}
+
+// TestMongoProdutoRepository_Contract executa a suíte de conformidade no repositório MongoDB
+// Requer um MongoDB acessível em MONGO_TEST_URI; cada subteste usa um banco de dados próprio
+func TestMongoProdutoRepository_Contract(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("Teste de integração - requer MongoDB rodando (defina MONGO_TEST_URI)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	var seq int64
+	repositorytest.RunProdutoRepositoryContract(t, func(t *testing.T) repository.ProdutoRepository {
+		name := fmt.Sprintf("api_go_contract_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&seq, 1))
+		db := client.Database(name)
+		t.Cleanup(func() {
+			_ = db.Drop(context.Background())
+		})
+		return repository.NewProdutoRepository(db.Collection("produtos"))
+	})
+}
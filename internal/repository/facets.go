@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// precoFacetStats é o resumo de preços calculado por Facets
+type precoFacetStats struct {
+	Min   float64 `bson:"min"`
+	Max   float64 `bson:"max"`
+	Count int64   `bson:"count"`
+}
+
+// precoFacetBucket é a contagem de uma faixa de preço, identificada pelo limite inferior
+type precoFacetBucket struct {
+	Min   float64 `bson:"_id"`
+	Count int64   `bson:"count"`
+}
+
+// attributeFacetCount é a contagem de um valor de atributo gerada por $sortByCount
+type attributeFacetCount struct {
+	Value string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+// facetAllowed são as facetas suportadas por Facets
+var facetAllowed = map[string]bool{
+	model.FacetPreco: true,
+	model.FacetNome:  true,
+}
+
+// validateFacets verifica se todas as facetas solicitadas são suportadas
+func validateFacets(fields []string) error {
+	for _, field := range fields {
+		if !facetAllowed[field] {
+			return fmt.Errorf("faceta não suportada: %s", field)
+		}
+	}
+	return nil
+}
+
+// precoBucketLower retorna o limite inferior da faixa do preço, seguindo a semântica
+// do $bucket com default igual ao último limite: preços fora dos limites vão para a última faixa
+func precoBucketLower(preco float64) float64 {
+	boundaries := model.PrecoFacetBoundaries
+	last := boundaries[len(boundaries)-1]
+	if preco < boundaries[0] || preco >= last {
+		return last
+	}
+	for i := len(boundaries) - 2; i >= 0; i-- {
+		if preco >= boundaries[i] {
+			return boundaries[i]
+		}
+	}
+	return last
+}
+
+// newPriceFacet monta a faceta de preço com todas as faixas, inclusive as vazias
+// (o $bucket omite faixas sem documentos)
+func newPriceFacet(stats precoFacetStats, buckets []precoFacetBucket) *model.PriceFacet {
+	counts := make(map[float64]int64, len(buckets))
+	for _, b := range buckets {
+		counts[b.Min] += b.Count
+	}
+
+	boundaries := model.PrecoFacetBoundaries
+	facet := &model.PriceFacet{
+		Min:     stats.Min,
+		Max:     stats.Max,
+		Count:   stats.Count,
+		Buckets: make([]model.PriceBucket, 0, len(boundaries)),
+	}
+	for i, lower := range boundaries {
+		bucket := model.PriceBucket{Min: lower, Count: counts[lower]}
+		if i+1 < len(boundaries) {
+			upper := boundaries[i+1]
+			bucket.Max = &upper
+		}
+		facet.Buckets = append(facet.Buckets, bucket)
+	}
+	return facet
+}
+
+// hasFacet verifica se a faceta foi solicitada
+func hasFacet(fields []string, facet string) bool {
+	for _, field := range fields {
+		if field == facet {
+			return true
+		}
+	}
+	return false
+}
+
+// newAttributeFacet monta a faceta de atributo a partir das contagens já ordenadas
+func newAttributeFacet(counts []attributeFacetCount) *model.AttributeFacet {
+	facet := &model.AttributeFacet{Values: make([]model.AttributeCount, 0, len(counts))}
+	for _, c := range counts {
+		facet.Values = append(facet.Values, model.AttributeCount{Value: c.Value, Count: c.Count})
+	}
+	return facet
+}
+
+// sortAttributeCounts ordena as contagens como o pipeline da faceta (quantidade
+// decrescente, depois valor) e mantém no máximo model.AttributeFacetLimit valores
+func sortAttributeCounts(counts map[string]int64) []attributeFacetCount {
+	result := make([]attributeFacetCount, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, attributeFacetCount{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	if len(result) > model.AttributeFacetLimit {
+		result = result[:model.AttributeFacetLimit]
+	}
+	return result
+}
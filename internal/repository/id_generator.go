@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CountersCollection é o nome da coleção que armazena as sequências de IDs
+const CountersCollection = "counters"
+
+// IDGenerator define a interface para alocação de IDs de produtos
+// Implementações devem ser seguras para uso concorrente (inclusive entre réplicas)
+type IDGenerator interface {
+	NextID(ctx context.Context) (int, error)
+	// Reserve garante que NextID nunca retorne o ID informado nem um menor, para
+	// produtos criados com ID explícito (ver BulkUpsert)
+	Reserve(ctx context.Context, id int) error
+}
+
+// counterDocument representa um documento da coleção de contadores
+type counterDocument struct {
+	Name string `bson:"_id"`
+	Seq  int    `bson:"seq"`
+}
+
+// mongoIDGenerator aloca IDs sequenciais usando $inc atômico na coleção de contadores
+type mongoIDGenerator struct {
+	collection *mongo.Collection
+	name       string
+}
+
+// NewMongoIDGenerator cria um gerador de IDs baseado em um contador do MongoDB
+func NewMongoIDGenerator(counters *mongo.Collection, name string) IDGenerator {
+	return &mongoIDGenerator{collection: counters, name: name}
+}
+
+// NextID incrementa o contador atomicamente e retorna o novo valor
+func (g *mongoIDGenerator) NextID(ctx context.Context) (int, error) {
+	filter := bson.M{"_id": g.name}
+	update := bson.M{"$inc": bson.M{"seq": 1}}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var counter counterDocument
+	if err := g.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter); err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// Reserve eleva o contador até o ID informado; $max nunca o reduz
+func (g *mongoIDGenerator) Reserve(ctx context.Context, id int) error {
+	_, err := g.collection.UpdateOne(ctx,
+		bson.M{"_id": g.name},
+		bson.M{"$max": bson.M{"seq": id}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// SeedIDGenerator garante que o contador nunca fique abaixo do maior ID já existente
+// na coleção de produtos. Deve ser chamado na inicialização para migrar bases que
+// ainda usavam o cálculo "maior id + 1"
+func SeedIDGenerator(ctx context.Context, produtos, counters *mongo.Collection, name string) error {
+	opts := options.FindOne().SetSort(bson.D{{Key: "id", Value: -1}})
+	var last struct {
+		ID int `bson:"id"`
+	}
+	err := produtos.FindOne(ctx, bson.M{}, opts).Decode(&last)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	// $max só altera o contador se o valor atual for menor, então é seguro
+	// executar em várias réplicas ao mesmo tempo
+	_, err = counters.UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$max": bson.M{"seq": last.ID}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// memoryIDGenerator aloca IDs sequenciais em memória (útil para desenvolvimento e testes)
+type memoryIDGenerator struct {
+	mu   sync.Mutex
+	last int
+}
+
+// NewMemoryIDGenerator cria um gerador de IDs em memória que começa após o valor informado
+func NewMemoryIDGenerator(start int) IDGenerator {
+	return &memoryIDGenerator{last: start}
+}
+
+// NextID retorna o próximo ID da sequência
+func (g *memoryIDGenerator) NextID(ctx context.Context) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.last++
+	return g.last, nil
+}
+
+// Reserve avança a sequência até o ID informado, se ela ainda estiver abaixo dele
+func (g *memoryIDGenerator) Reserve(ctx context.Context, id int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if id > g.last {
+		g.last = id
+	}
+	return nil
+}
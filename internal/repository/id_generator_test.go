@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// hammerIDGenerator chama NextID a partir de várias goroutines e falha se houver IDs repetidos
+func hammerIDGenerator(t *testing.T, gen IDGenerator, workers, perWorker int) []int {
+	t.Helper()
+	ctx := context.Background()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		ids  = make([]int, 0, workers*perWorker)
+		errs = make(chan error, workers)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := gen.NextID(ctx)
+				if err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				ids = append(ids, id)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("Erro inesperado ao alocar ID: %v", err)
+	}
+
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("ID %d foi alocado mais de uma vez", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Fatalf("Quantidade de IDs esperada %d, obtida %d", workers*perWorker, len(seen))
+	}
+	return ids
+}
+
+// TestMemoryIDGenerator_Concurrent verifica que o gerador em memória não repete IDs sob concorrência
+func TestMemoryIDGenerator_Concurrent(t *testing.T) {
+	gen := NewMemoryIDGenerator(10)
+	ids := hammerIDGenerator(t, gen, 50, 200)
+
+	for _, id := range ids {
+		if id <= 10 || id > 10+50*200 {
+			t.Fatalf("ID %d fora da sequência esperada", id)
+		}
+	}
+}
+
+// TestMongoIDGenerator_Concurrent verifica a alocação atômica via coleção de contadores
+// Requer um MongoDB acessível em MONGO_TEST_URI
+func TestMongoIDGenerator_Concurrent(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("Teste de integração - requer MongoDB rodando (defina MONGO_TEST_URI)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(fmt.Sprintf("api_go_test_%d", time.Now().UnixNano()))
+	defer db.Drop(context.Background())
+
+	produtos := db.Collection("produtos")
+	counters := db.Collection(CountersCollection)
+
+	t.Run("deve continuar a partir do maior ID existente", func(t *testing.T) {
+		if _, err := produtos.InsertOne(ctx, bson.M{"id": 41}); err != nil {
+			t.Fatalf("Erro ao inserir produto: %v", err)
+		}
+		if err := SeedIDGenerator(ctx, produtos, counters, "produtos"); err != nil {
+			t.Fatalf("Erro ao inicializar contador: %v", err)
+		}
+
+		id, err := NewMongoIDGenerator(counters, "produtos").NextID(ctx)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if id != 42 {
+			t.Errorf("ID esperado 42, obtido %d", id)
+		}
+	})
+
+	t.Run("não deve repetir IDs sob concorrência", func(t *testing.T) {
+		// O contador vive no MongoDB, então o resultado vale também entre réplicas da API
+		gen := NewMongoIDGenerator(counters, "concorrencia")
+		hammerIDGenerator(t, gen, 20, 25)
+	})
+}
@@ -2,22 +2,58 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"api-go-arquitetura/internal/model"
 
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// AnyVersion indica que a alteração não exige uma versão específica do produto
+// Não pode ser zero: documentos criados antes do controle de versão não têm o
+// campo version e são lidos com versão 0, que continua sendo uma condição válida
+const AnyVersion = -1
+
 // ProdutoRepository define a interface para operações de produto no repositório
 type ProdutoRepository interface {
 	Create(ctx context.Context, produto model.Produto) (model.Produto, error)
 	FindAll(ctx context.Context) ([]model.Produto, error)
 	FindByID(ctx context.Context, id int) (model.Produto, error)
-	Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error)
-	Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error)
-	Delete(ctx context.Context, id int) error
+	// Update, Patch e Delete incrementam a versão do produto. Quando expectedVersion é
+	// diferente de AnyVersion, a alteração só é aplicada se a versão atual for igual a
+	// ela; caso contrário é retornado o erro "version conflict"
+	Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error)
+	Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error)
+	Delete(ctx context.Context, id int, expectedVersion int) error
+	// BulkWrite executa as operações em ordem, com a mesma semântica dos métodos
+	// individuais, e retorna o resultado de cada uma na mesma posição. Com atomic as
+	// operações formam uma transação: a primeira falha desfaz o lote, e as demais
+	// operações recebem ErrBulkAborted. O erro retornado indica falha do lote como um
+	// todo (ex: transação não confirmada), e não de uma operação
+	BulkWrite(ctx context.Context, operations []BulkOperation, atomic bool) ([]BulkResult, error)
 	// Novos métodos para paginação e filtros
-	FindAllPaginated(ctx context.Context, skip, limit int64, filter map[string]interface{}, sort bson.D) ([]model.Produto, error)
+	// As consultas (FindAllPaginated, Count, Stream, Search, Facets e Aggregate) excluem
+	// os produtos deletados, a menos que o filtro tenha uma condição sobre deleted_at
+	// (ex: model.IncludeDeleted ou {"$exists": true} para a lixeira)
+	// projection limita os campos retornados (nil retorna todos); os demais ficam com o valor zero
+	FindAllPaginated(ctx context.Context, skip, limit int64, filter map[string]interface{}, sort bson.D, projection []string) ([]model.Produto, error)
 	Count(ctx context.Context, filter map[string]interface{}) (int64, error)
+	// Stream percorre os produtos do filtro na ordem informada (com o ID como desempate),
+	// chamando fn para cada um sem acumulá-los em memória. Um erro de fn interrompe a
+	// leitura e é retornado
+	Stream(ctx context.Context, filter map[string]interface{}, sort bson.D, fn func(model.Produto) error) error
+	// Search executa a busca textual: o filtro deve conter a condição $text (ver
+	// dto.WithTextSearch) e os resultados são ordenados por relevância e, em caso de
+	// empate, por ID. Count com o mesmo filtro retorna o total de resultados
+	Search(ctx context.Context, filter map[string]interface{}, skip, limit int64) ([]model.SearchResult, error)
+	// Facets calcula as facetas solicitadas (ver model.Facets) sobre os produtos do filtro
+	Facets(ctx context.Context, filter map[string]interface{}, fields []string) (model.Facets, error)
+	// Aggregate calcula as estatísticas (ver model.ProdutoStats) dos produtos do filtro
+	Aggregate(ctx context.Context, filter map[string]interface{}) (model.ProdutoStats, error)
+	// Métodos para recuperar ou remover definitivamente produtos deletados (soft delete)
+	Restore(ctx context.Context, id int) (model.Produto, error)
+	Purge(ctx context.Context, id int) error
+	// PurgeDeletedBefore remove definitivamente os produtos deletados antes da data informada
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
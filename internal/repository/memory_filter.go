@@ -0,0 +1,437 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Este arquivo implementa um subconjunto da linguagem de consulta do MongoDB
+// para o repositório em memória. Os documentos são avaliados na sua forma BSON,
+// de modo que os nomes de campo e os tipos sejam os mesmos vistos pelo MongoDB.
+
+// toDocument converte um produto para o documento BSON equivalente ao armazenado no MongoDB
+func toDocument(p model.Produto) (bson.M, error) {
+	data, err := bson.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// fromDocument converte um documento BSON de volta para produto
+func fromDocument(doc bson.M) (model.Produto, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	var p model.Produto
+	if err := bson.Unmarshal(data, &p); err != nil {
+		return model.Produto{}, err
+	}
+	return p, nil
+}
+
+// normalizeProduto aplica ao produto as mesmas conversões de uma ida e volta ao
+// MongoDB (ex: timestamps truncados em milissegundos e em UTC)
+func normalizeProduto(p model.Produto) (model.Produto, error) {
+	doc, err := toDocument(p)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	return fromDocument(doc)
+}
+
+// asFilterMap converte os formatos de filtro aceitos (map, bson.M, bson.D) para map
+func asFilterMap(v interface{}) (map[string]interface{}, bool) {
+	switch f := v.(type) {
+	case map[string]interface{}:
+		return f, true
+	case bson.M:
+		return f, true
+	case bson.D:
+		m := make(map[string]interface{}, len(f))
+		for _, e := range f {
+			m[e.Key] = e.Value
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// asList converte slices de qualquer tipo para []interface{}
+func asList(v interface{}) ([]interface{}, bool) {
+	switch l := v.(type) {
+	case []interface{}:
+		return l, true
+	case bson.A:
+		return l, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// matchDocument verifica se o documento satisfaz o filtro
+func matchDocument(doc bson.M, filter map[string]interface{}) (bool, error) {
+	for key, cond := range filter {
+		var (
+			ok  bool
+			err error
+		)
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, key, cond)
+		case "$text":
+			ok, err = matchText(doc, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("operador de consulta não suportado: %s", key)
+			}
+			value, exists := doc[key]
+			ok, err = matchField(value, exists, cond)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchLogical avalia os operadores $and, $or e $nor
+func matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	clauses, ok := asList(cond)
+	if !ok || len(clauses) == 0 {
+		return false, fmt.Errorf("%s requer uma lista não vazia de filtros", op)
+	}
+	for _, clause := range clauses {
+		sub, ok := asFilterMap(clause)
+		if !ok {
+			return false, fmt.Errorf("%s requer uma lista de filtros", op)
+		}
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch op {
+		case "$and":
+			if !matched {
+				return false, nil
+			}
+		case "$or":
+			if matched {
+				return true, nil
+			}
+		case "$nor":
+			if matched {
+				return false, nil
+			}
+		}
+	}
+	return op != "$or", nil
+}
+
+// isOperatorMap verifica se a condição é um documento de operadores ({"$gte": ...})
+func isOperatorMap(cond map[string]interface{}) bool {
+	if len(cond) == 0 {
+		return false
+	}
+	for k := range cond {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchField avalia a condição de um único campo
+func matchField(value interface{}, exists bool, cond interface{}) (bool, error) {
+	ops, ok := asFilterMap(cond)
+	if !ok || !isOperatorMap(ops) {
+		// Igualdade implícita ({"campo": valor})
+		return exists && valuesEqual(value, cond), nil
+	}
+
+	for op, arg := range ops {
+		var (
+			matched bool
+			err     error
+		)
+		switch op {
+		case "$eq":
+			matched = exists && valuesEqual(value, arg)
+		case "$ne":
+			matched = !exists || !valuesEqual(value, arg)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = exists && matchComparison(op, value, arg)
+		case "$in", "$nin":
+			list, ok := asList(arg)
+			if !ok {
+				return false, fmt.Errorf("%s requer uma lista", op)
+			}
+			found := false
+			for _, item := range list {
+				if (exists && valuesEqual(value, item)) || (!exists && item == nil) {
+					found = true
+					break
+				}
+			}
+			matched = found == (op == "$in")
+		case "$exists":
+			want, ok := arg.(bool)
+			if !ok {
+				return false, fmt.Errorf("$exists requer um valor booleano")
+			}
+			matched = exists == want
+		case "$regex":
+			options, _ := ops["$options"].(string)
+			matched, err = matchRegex(value, exists, arg, options)
+		case "$options":
+			// Tratado junto com $regex
+			matched = true
+		case "$not":
+			sub, ok := asFilterMap(arg)
+			if !ok {
+				return false, fmt.Errorf("$not requer um documento de operadores")
+			}
+			matched, err = matchField(value, exists, sub)
+			matched = !matched
+		default:
+			return false, fmt.Errorf("operador de consulta não suportado: %s", op)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchRegex avalia o operador $regex (apenas a opção "i" é suportada)
+func matchRegex(value interface{}, exists bool, pattern interface{}, options string) (bool, error) {
+	var expr string
+	switch p := pattern.(type) {
+	case string:
+		expr = p
+	case primitive.Regex:
+		expr = p.Pattern
+		if options == "" {
+			options = p.Options
+		}
+	default:
+		return false, fmt.Errorf("$regex requer uma string")
+	}
+	if strings.Contains(options, "i") {
+		expr = "(?i)" + expr
+	}
+	re, err := compileRegex(expr)
+	if err != nil {
+		return false, fmt.Errorf("expressão regular inválida: %w", err)
+	}
+	s, ok := value.(string)
+	return exists && ok && re.MatchString(s), nil
+}
+
+// maxCachedRegexes limita as expressões mantidas por compileRegex
+const maxCachedRegexes = 256
+
+// regexCache guarda as expressões já compiladas (a chave inclui as opções, na forma
+// de flags como (?i)): um filtro é avaliado uma vez por documento, e sem o cache
+// cada consulta compilaria a expressão N vezes
+var regexCache = struct {
+	sync.Mutex
+	entries map[string]*regexp.Regexp
+}{entries: make(map[string]*regexp.Regexp)}
+
+// compileRegex compila a expressão ou a retorna do cache; o cache é esvaziado ao
+// atingir maxCachedRegexes
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	regexCache.Lock()
+	defer regexCache.Unlock()
+
+	if re, ok := regexCache.entries[expr]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(regexCache.entries) >= maxCachedRegexes {
+		regexCache.entries = make(map[string]*regexp.Regexp)
+	}
+	regexCache.entries[expr] = re
+	return re, nil
+}
+
+// matchComparison avalia os operadores $gt, $gte, $lt e $lte
+func matchComparison(op string, value, arg interface{}) bool {
+	cmp, ok := compareValues(value, arg)
+	if !ok {
+		return false
+	}
+	switch op {
+	case "$gt":
+		return cmp > 0
+	case "$gte":
+		return cmp >= 0
+	case "$lt":
+		return cmp < 0
+	case "$lte":
+		return cmp <= 0
+	}
+	return false
+}
+
+// valuesEqual compara dois valores com a semântica de igualdade do MongoDB
+func valuesEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converte valores numéricos para float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// toTime converte valores de data para time.Time
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, true
+	case primitive.DateTime:
+		return t.Time(), true
+	}
+	return time.Time{}, false
+}
+
+// compareValues compara dois valores do mesmo tipo BSON (número, string ou data)
+// Retorna false quando os tipos não são comparáveis, assim como o MongoDB
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	if sa, ok := a.(string); ok {
+		sb, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(sa, sb), true
+	}
+	if ta, ok := toTime(a); ok {
+		tb, ok := toTime(b)
+		if !ok {
+			return 0, false
+		}
+		// O MongoDB armazena datas com precisão de milissegundos
+		ma, mb := ta.UnixMilli(), tb.UnixMilli()
+		switch {
+		case ma < mb:
+			return -1, true
+		case ma > mb:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sortRank define a ordem entre tipos BSON distintos (ausente/nulo < números < strings < datas)
+func sortRank(v interface{}, exists bool) int {
+	if !exists || v == nil {
+		return 0
+	}
+	if _, ok := toFloat(v); ok {
+		return 1
+	}
+	if _, ok := v.(string); ok {
+		return 2
+	}
+	if _, ok := toTime(v); ok {
+		return 3
+	}
+	return 4
+}
+
+// compareForSort compara dois valores de campo para ordenação
+func compareForSort(a interface{}, aExists bool, b interface{}, bExists bool) int {
+	ra, rb := sortRank(a, aExists), sortRank(b, bExists)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	if cmp, ok := compareValues(a, b); ok {
+		return cmp
+	}
+	return 0
+}
+
+// sortDocuments ordena os documentos de acordo com a especificação bson.D
+func sortDocuments(docs []bson.M, spec bson.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, e := range spec {
+			a, aExists := docs[i][e.Key]
+			b, bExists := docs[j][e.Key]
+			cmp := compareForSort(a, aExists, b, bExists)
+			if cmp == 0 {
+				continue
+			}
+			if dir, ok := toFloat(e.Value); ok && dir < 0 {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
@@ -0,0 +1,570 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// memoryProdutoRepository implementa ProdutoRepository mantendo os produtos em memória
+// Os produtos são armazenados na forma de documentos BSON para reproduzir a
+// semântica do MongoDB (filtros, ordenação e precisão de timestamps)
+type memoryProdutoRepository struct {
+	mu          sync.RWMutex
+	documents   map[int]bson.M
+	idGenerator IDGenerator
+}
+
+// NewMemoryProdutoRepository cria uma nova instância do ProdutoRepository em memória
+func NewMemoryProdutoRepository() ProdutoRepository {
+	return NewMemoryProdutoRepositoryWithIDGenerator(NewMemoryIDGenerator(0))
+}
+
+// NewMemoryProdutoRepositoryWithIDGenerator cria um repositório em memória com gerador de IDs customizado
+func NewMemoryProdutoRepositoryWithIDGenerator(idGenerator IDGenerator) ProdutoRepository {
+	return &memoryProdutoRepository{
+		documents:   make(map[int]bson.M),
+		idGenerator: idGenerator,
+	}
+}
+
+func (r *memoryProdutoRepository) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(ctx, produto)
+}
+
+// create insere o produto; deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) create(ctx context.Context, produto model.Produto) (model.Produto, error) {
+	id, err := r.idGenerator.NextID(ctx)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	produto.ID = id
+	produto.BeforeCreate() // Inicializar timestamps
+
+	doc, err := toDocument(produto)
+	if err != nil {
+		return model.Produto{}, err
+	}
+
+	// Equivalente ao índice único idx_id
+	if _, exists := r.documents[id]; exists {
+		return model.Produto{}, fmt.Errorf("duplicate key: id %d", id)
+	}
+	r.documents[id] = doc
+	return fromDocument(doc)
+}
+
+func (r *memoryProdutoRepository) FindAll(ctx context.Context) ([]model.Produto, error) {
+	// Filtrar produtos deletados (soft delete), assim como as demais consultas
+	docs, err := r.find(bson.M{"deleted_at": bson.M{"$exists": false}}, bson.D{{Key: "id", Value: 1}}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return toProdutos(docs)
+}
+
+func (r *memoryProdutoRepository) FindByID(ctx context.Context, id int) (model.Produto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	doc, ok := r.activeDocument(id)
+	if !ok {
+		return model.Produto{}, errors.New("not found")
+	}
+	return fromDocument(doc)
+}
+
+func (r *memoryProdutoRepository) Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(id, produto, expectedVersion)
+}
+
+// update substitui os campos editáveis do produto; deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) update(id int, produto model.Produto, expectedVersion int) (model.Produto, error) {
+	existing, err := r.versionedProduto(id, expectedVersion)
+	if err != nil {
+		return model.Produto{}, err
+	}
+
+	produto.ID = id
+	produto.BeforeUpdate()                 // Atualizar timestamp
+	produto.CreatedAt = existing.CreatedAt // Preservar CreatedAt
+	produto.DeletedAt = existing.DeletedAt // Preservar DeletedAt (soft delete)
+	produto.Version = existing.Version + 1
+
+	replacement, err := toDocument(produto)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	r.documents[id] = replacement
+	return fromDocument(replacement)
+}
+
+func (r *memoryProdutoRepository) Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.patch(id, updates, expectedVersion)
+}
+
+// patch altera os campos informados do produto; deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) patch(id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error) {
+	existing, err := r.versionedProduto(id, expectedVersion)
+	if err != nil {
+		return model.Produto{}, err
+	}
+
+	// Equivalente a {"$set": updates, "$inc": {"version": 1}} com updated_at automático
+	patched := copyDocument(r.documents[id])
+	for key, value := range updates {
+		patched[key] = value
+	}
+	patched["updated_at"] = time.Now()
+	patched["version"] = existing.Version + 1
+
+	// Ida e volta pelo modelo para validar os tipos e normalizar o documento
+	produto, err := fromDocument(patched)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	normalized, err := toDocument(produto)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	r.documents[id] = normalized
+	return produto, nil
+}
+
+func (r *memoryProdutoRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delete(id, expectedVersion)
+}
+
+// delete marca o produto como deletado (soft delete); deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) delete(id int, expectedVersion int) error {
+	produto, err := r.versionedProduto(id, expectedVersion)
+	if err != nil {
+		return err
+	}
+
+	// Soft delete: marcar como deletado ao invés de remover
+	produto.SoftDelete()
+	produto.Version++
+
+	updated, err := toDocument(produto)
+	if err != nil {
+		return err
+	}
+	r.documents[id] = updated
+	return nil
+}
+
+// upsert substitui os campos editáveis do produto ou o cria com o ID informado; deve
+// ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) upsert(ctx context.Context, id int, produto model.Produto) (model.Produto, bool, error) {
+	if _, exists := r.documents[id]; exists {
+		if _, active := r.activeDocument(id); !active {
+			return model.Produto{}, false, errors.New("deleted")
+		}
+		updated, err := r.update(id, produto, AnyVersion)
+		return updated, false, err
+	}
+
+	if err := r.idGenerator.Reserve(ctx, id); err != nil {
+		return model.Produto{}, false, err
+	}
+	produto.ID = id
+	produto.BeforeCreate() // Inicializar timestamps
+
+	doc, err := toDocument(produto)
+	if err != nil {
+		return model.Produto{}, false, err
+	}
+	r.documents[id] = doc
+	created, err := fromDocument(doc)
+	return created, err == nil, err
+}
+
+// BulkWrite executa as operações em ordem com o lock adquirido durante todo o lote
+// No modo atômico, a falha de uma operação restaura os documentos anteriores ao lote
+func (r *memoryProdutoRepository) BulkWrite(ctx context.Context, operations []BulkOperation, atomic bool) ([]BulkResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Os documentos nunca são alterados no lugar (cada escrita grava um novo), então
+	// uma cópia rasa do mapa é suficiente para desfazer o lote
+	var snapshot map[int]bson.M
+	if atomic {
+		snapshot = make(map[int]bson.M, len(r.documents))
+		for id, doc := range r.documents {
+			snapshot[id] = doc
+		}
+	}
+
+	results := make([]BulkResult, len(operations))
+	for i, op := range operations {
+		results[i] = applyBulkOperation(ctx, lockedMemoryWriter{r}, op)
+		if atomic && results[i].Err != nil {
+			r.documents = snapshot
+			abortBulkResults(results, i)
+			return results, nil
+		}
+	}
+	return results, nil
+}
+
+// lockedMemoryWriter executa as escritas sem adquirir o lock, já adquirido por BulkWrite
+type lockedMemoryWriter struct {
+	r *memoryProdutoRepository
+}
+
+func (w lockedMemoryWriter) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
+	return w.r.create(ctx, produto)
+}
+
+func (w lockedMemoryWriter) Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error) {
+	return w.r.update(id, produto, expectedVersion)
+}
+
+func (w lockedMemoryWriter) Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error) {
+	return w.r.patch(id, updates, expectedVersion)
+}
+
+func (w lockedMemoryWriter) Delete(ctx context.Context, id int, expectedVersion int) error {
+	return w.r.delete(id, expectedVersion)
+}
+
+func (w lockedMemoryWriter) Upsert(ctx context.Context, id int, produto model.Produto) (model.Produto, bool, error) {
+	return w.r.upsert(ctx, id, produto)
+}
+
+// Restore desfaz o soft delete de um produto
+func (r *memoryProdutoRepository) Restore(ctx context.Context, id int) (model.Produto, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Apenas produtos deletados podem ser restaurados
+	doc, ok := r.documents[id]
+	if !ok {
+		return model.Produto{}, errors.New("not found")
+	}
+	if _, deleted := doc["deleted_at"]; !deleted {
+		return model.Produto{}, errors.New("not found")
+	}
+
+	produto, err := fromDocument(doc)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	produto.Restore()
+	produto.Version++
+
+	restored, err := toDocument(produto)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	r.documents[id] = restored
+	return fromDocument(restored)
+}
+
+// Purge remove definitivamente um produto, deletado ou não
+func (r *memoryProdutoRepository) Purge(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.documents[id]; !ok {
+		return errors.New("not found")
+	}
+	delete(r.documents, id)
+	return nil
+}
+
+// PurgeDeletedBefore remove definitivamente os produtos deletados antes da data informada
+func (r *memoryProdutoRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filter := bson.M{"deleted_at": bson.M{"$lt": before}}
+	var purged int64
+	for id, doc := range r.documents {
+		matched, err := matchDocument(doc, filter)
+		if err != nil {
+			return purged, err
+		}
+		if matched {
+			delete(r.documents, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// FindAllPaginated retorna produtos paginados com filtros e ordenação
+func (r *memoryProdutoRepository) FindAllPaginated(ctx context.Context, skip, limit int64, filter map[string]interface{}, sort bson.D, projection []string) ([]model.Produto, error) {
+	// Garantir ordenação determinística (ID como critério de desempate)
+	sort = withIDTieBreaker(sort)
+
+	docs, err := r.find(filter, sort, skip, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(projection) > 0 {
+		for i, doc := range docs {
+			docs[i] = projectDocument(doc, projection)
+		}
+	}
+	return toProdutos(docs)
+}
+
+// Stream percorre os produtos do filtro; fn é chamada sem o lock adquirido, então
+// pode acessar o repositório
+func (r *memoryProdutoRepository) Stream(ctx context.Context, filter map[string]interface{}, sort bson.D, fn func(model.Produto) error) error {
+	docs, err := r.find(filter, withIDTieBreaker(sort), 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		produto, err := fromDocument(doc)
+		if err != nil {
+			return err
+		}
+		if err := fn(produto); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Count retorna o total de documentos que correspondem ao filtro
+func (r *memoryProdutoRepository) Count(ctx context.Context, filter map[string]interface{}) (int64, error) {
+	docs, err := r.find(filter, nil, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(docs)), nil
+}
+
+// Search executa a busca textual com o avaliador simplificado de relevância
+func (r *memoryProdutoRepository) Search(ctx context.Context, filter map[string]interface{}, skip, limit int64) ([]model.SearchResult, error) {
+	search, err := textSearchValue(filter["$text"])
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := r.find(filter, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.SearchResult, 0, len(docs))
+	for _, doc := range docs {
+		p, err := fromDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, model.SearchResult{Produto: p, Score: textScore(doc, search)})
+	}
+
+	// Mais relevantes primeiro; find já ordenou por ID, então o empate é estável
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if skip >= int64(len(results)) {
+		return []model.SearchResult{}, nil
+	}
+	results = results[skip:]
+	if limit > 0 && limit < int64(len(results)) {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Facets calcula as facetas percorrendo os produtos do filtro
+func (r *memoryProdutoRepository) Facets(ctx context.Context, filter map[string]interface{}, fields []string) (model.Facets, error) {
+	if err := validateFacets(fields); err != nil {
+		return model.Facets{}, err
+	}
+	if len(fields) == 0 {
+		return model.Facets{}, nil
+	}
+
+	docs, err := r.find(filter, nil, 0, 0)
+	if err != nil {
+		return model.Facets{}, err
+	}
+
+	var stats precoFacetStats
+	counts := make(map[float64]int64)
+	nomes := make(map[string]int64)
+	for _, doc := range docs {
+		p, err := fromDocument(doc)
+		if err != nil {
+			return model.Facets{}, err
+		}
+		if stats.Count == 0 || p.Preco < stats.Min {
+			stats.Min = p.Preco
+		}
+		if stats.Count == 0 || p.Preco > stats.Max {
+			stats.Max = p.Preco
+		}
+		stats.Count++
+		counts[precoBucketLower(p.Preco)]++
+		nomes[p.Nome]++
+	}
+
+	var facets model.Facets
+	if hasFacet(fields, model.FacetPreco) {
+		buckets := make([]precoFacetBucket, 0, len(counts))
+		for lower, count := range counts {
+			buckets = append(buckets, precoFacetBucket{Min: lower, Count: count})
+		}
+		facets.Preco = newPriceFacet(stats, buckets)
+	}
+	if hasFacet(fields, model.FacetNome) {
+		facets.Nome = newAttributeFacet(sortAttributeCounts(nomes))
+	}
+	return facets, nil
+}
+
+// Aggregate calcula as estatísticas dos produtos do filtro
+// Os percentis são exatos (nearest-rank), dentro da margem da estimativa do MongoDB
+func (r *memoryProdutoRepository) Aggregate(ctx context.Context, filter map[string]interface{}) (model.ProdutoStats, error) {
+	docs, err := r.find(filter, bson.D{{Key: "preco", Value: 1}}, 0, 0)
+	if err != nil {
+		return model.ProdutoStats{}, err
+	}
+	if len(docs) == 0 {
+		return model.ProdutoStats{}, nil
+	}
+
+	precos := make([]float64, 0, len(docs))
+	sum := 0.0
+	for _, doc := range docs {
+		p, err := fromDocument(doc)
+		if err != nil {
+			return model.ProdutoStats{}, err
+		}
+		precos = append(precos, p.Preco)
+		sum += p.Preco
+	}
+
+	percentiles := make([]float64, 0, len(model.StatsPercentiles))
+	for _, p := range model.StatsPercentiles {
+		percentiles = append(percentiles, precos[percentileRank(p, int64(len(precos)))-1])
+	}
+
+	count := int64(len(precos))
+	return newProdutoStats(count, sum, precos[0], precos[len(precos)-1], percentiles), nil
+}
+
+// activeDocument retorna o documento do produto se ele existir e não estiver deletado
+// Deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) activeDocument(id int) (bson.M, bool) {
+	doc, ok := r.documents[id]
+	if !ok {
+		return nil, false
+	}
+	if _, deleted := doc["deleted_at"]; deleted {
+		return nil, false
+	}
+	return doc, true
+}
+
+// versionedProduto retorna o produto ativo, verificando a versão esperada quando informada
+// Deve ser chamado com o lock adquirido
+func (r *memoryProdutoRepository) versionedProduto(id int, expectedVersion int) (model.Produto, error) {
+	doc, ok := r.activeDocument(id)
+	if !ok {
+		return model.Produto{}, errors.New("not found")
+	}
+	produto, err := fromDocument(doc)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	if expectedVersion != AnyVersion && produto.Version != expectedVersion {
+		return model.Produto{}, errors.New("version conflict")
+	}
+	return produto, nil
+}
+
+// find retorna os documentos que satisfazem o filtro, ordenados e paginados
+// Sem condição sobre deleted_at, apenas produtos ativos (ver scopeDeleted)
+// limit igual a zero significa sem limite, assim como no MongoDB
+func (r *memoryProdutoRepository) find(filter map[string]interface{}, sort bson.D, skip, limit int64) ([]bson.M, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	filter = scopeDeleted(filter)
+
+	docs := make([]bson.M, 0, len(r.documents))
+	for _, doc := range r.documents {
+		matched, err := matchDocument(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			docs = append(docs, doc)
+		}
+	}
+
+	// Mapas não têm ordem: sem ordenação explícita, usar a ordem de inserção (ID)
+	if len(sort) == 0 {
+		sort = bson.D{{Key: "id", Value: 1}}
+	}
+	sortDocuments(docs, sort)
+
+	if skip > 0 {
+		if skip >= int64(len(docs)) {
+			return []bson.M{}, nil
+		}
+		docs = docs[skip:]
+	}
+	if limit > 0 && limit < int64(len(docs)) {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// copyDocument cria uma cópia rasa do documento
+func copyDocument(doc bson.M) bson.M {
+	result := make(bson.M, len(doc))
+	for k, v := range doc {
+		result[k] = v
+	}
+	return result
+}
+
+// projectDocument retorna uma cópia do documento com apenas os campos da projeção
+func projectDocument(doc bson.M, projection []string) bson.M {
+	result := make(bson.M, len(projection))
+	for _, field := range projection {
+		if value, ok := doc[field]; ok {
+			result[field] = value
+		}
+	}
+	return result
+}
+
+// toProdutos converte uma lista de documentos para produtos
+func toProdutos(docs []bson.M) ([]model.Produto, error) {
+	produtos := make([]model.Produto, 0, len(docs))
+	for _, doc := range docs {
+		p, err := fromDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		produtos = append(produtos, p)
+	}
+	return produtos, nil
+}
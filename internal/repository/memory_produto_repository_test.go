@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestMemoryProdutoRepository_Interface verifica se memoryProdutoRepository implementa a interface
+func TestMemoryProdutoRepository_Interface(t *testing.T) {
+	var _ ProdutoRepository = (*memoryProdutoRepository)(nil)
+}
+
+func seedMemoryRepository(t *testing.T, repo ProdutoRepository) {
+	t.Helper()
+	produtos := []model.Produto{
+		{Nome: "Notebook Gamer", Preco: 5500.00, Descricao: "Notebook com placa de vídeo"},
+		{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"},
+		{Nome: "notebook básico", Preco: 2500.00, Descricao: "Para o dia a dia"},
+		{Nome: "Teclado", Preco: 150.00, Descricao: "Teclado mecânico"},
+	}
+	for _, p := range produtos {
+		if _, err := repo.Create(context.Background(), p); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+	}
+}
+
+// TestMemoryProdutoRepository_Filters verifica os filtros gerados por FilterRequest.ToMongoFilter
+func TestMemoryProdutoRepository_Filters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+	seedMemoryRepository(t, repo)
+
+	nome := "notebook"
+	precoMin := 100.0
+	precoMax := 3000.0
+	ponto := "."
+	parentese := "("
+	prefixo := "Note"
+	exato := "Mouse"
+	regex := "^(mouse|teclado)$"
+
+	tests := []struct {
+		name   string
+		filter dto.FilterRequest
+		want   []int
+	}{
+		{"sem filtros", dto.FilterRequest{}, []int{1, 2, 3, 4}},
+		{"regex case-insensitive por nome", dto.FilterRequest{Nome: &nome}, []int{1, 3}},
+		{"faixa de preço", dto.FilterRequest{PrecoMin: &precoMin, PrecoMax: &precoMax}, []int{3, 4}},
+		{"nome e preço combinados", dto.FilterRequest{Nome: &nome, PrecoMax: &precoMax}, []int{3}},
+		{"texto é escapado no modo contains", dto.FilterRequest{Nome: &ponto}, []int{}},
+		{"caracteres especiais não geram regex inválida", dto.FilterRequest{Nome: &parentese}, []int{}},
+		{"prefixo diferencia maiúsculas", dto.FilterRequest{Nome: &prefixo, Match: dto.MatchPrefix}, []int{1}},
+		{"prefixo na descrição", dto.FilterRequest{Descricao: &exato, Match: dto.MatchPrefix}, []int{2}},
+		{"igualdade exata", dto.FilterRequest{Nome: &exato, Match: dto.MatchExact}, []int{2}},
+		{"expressão regular", dto.FilterRequest{Nome: &regex, Match: dto.MatchRegex}, []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter.ToMongoFilter()
+			produtos, err := repo.FindAllPaginated(ctx, 0, 10, filter, nil, nil)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if len(produtos) != len(tt.want) {
+				t.Fatalf("Quantidade esperada %d, obtida %d", len(tt.want), len(produtos))
+			}
+			for i, p := range produtos {
+				if p.ID != tt.want[i] {
+					t.Errorf("Posição %d: ID esperado %d, obtido %d", i, tt.want[i], p.ID)
+				}
+			}
+
+			count, err := repo.Count(ctx, filter)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if count != int64(len(tt.want)) {
+				t.Errorf("Count esperado %d, obtido %d", len(tt.want), count)
+			}
+		})
+	}
+}
+
+// TestMemoryProdutoRepository_SortAndPagination verifica ordenação bson.D e skip/limit
+func TestMemoryProdutoRepository_SortAndPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+	seedMemoryRepository(t, repo)
+
+	produtos, err := repo.FindAllPaginated(ctx, 1, 2, nil, bson.D{{Key: "preco", Value: -1}}, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(produtos) != 2 || produtos[0].ID != 3 || produtos[1].ID != 4 {
+		t.Errorf("Página esperada [3 4], obtida %v", produtos)
+	}
+}
+
+// TestMemoryProdutoRepository_Search verifica a relevância do avaliador de busca textual
+func TestMemoryProdutoRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+	seedMemoryRepository(t, repo)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"nome pesa mais que descrição", "notebook", []int{1, 3}},
+		{"plural e maiúsculas", "NOTEBOOKS", []int{1, 3}},
+		{"acentos são ignorados", "video", []int{1}},
+		{"termo negado exclui resultados", "notebook -gamer", []int{3}},
+		{"qualquer termo corresponde", "mouse teclado", []int{2, 4}},
+		{"stop words não correspondem", "de para", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := dto.WithTextSearch(nil, tt.query)
+			results, err := repo.Search(ctx, filter, 0, 10)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("Quantidade esperada %d, obtida %d", len(tt.want), len(results))
+			}
+			for i, r := range results {
+				if r.ID != tt.want[i] {
+					t.Errorf("Posição %d: ID esperado %d, obtido %d", i, tt.want[i], r.ID)
+				}
+				if i > 0 && r.Score > results[i-1].Score {
+					t.Errorf("Resultados fora da ordem de relevância: %v", results)
+				}
+			}
+
+			count, err := repo.Count(ctx, filter)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if count != int64(len(tt.want)) {
+				t.Errorf("Count esperado %d, obtido %d", len(tt.want), count)
+			}
+		})
+	}
+}
+
+// TestMemoryProdutoRepository_AggregatePercentiles verifica os percentis exatos (nearest-rank)
+func TestMemoryProdutoRepository_AggregatePercentiles(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+	for i := 1; i <= 100; i++ {
+		if _, err := repo.Create(ctx, model.Produto{Nome: "Produto", Preco: float64(i)}); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+	}
+
+	stats, err := repo.Aggregate(ctx, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if stats.Preco.P50 != 50 || stats.Preco.P90 != 90 || stats.Preco.P99 != 99 {
+		t.Errorf("Percentis esperados 50/90/99, obtidos %v/%v/%v", stats.Preco.P50, stats.Preco.P90, stats.Preco.P99)
+	}
+	if stats.Preco.Avg != 50.5 {
+		t.Errorf("Média esperada 50.5, obtida %v", stats.Preco.Avg)
+	}
+}
+
+// TestCompileRegex verifica que a expressão é compilada uma única vez por padrão e opções
+func TestCompileRegex(t *testing.T) {
+	first, err := compileRegex("(?i)^note")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if again, _ := compileRegex("(?i)^note"); again != first {
+		t.Error("A mesma expressão deveria ser reutilizada do cache")
+	}
+	if other, _ := compileRegex("^note"); other == first {
+		t.Error("Opções diferentes deveriam gerar outra expressão")
+	}
+	if _, err := compileRegex("(["); err == nil {
+		t.Error("Esperado erro para expressão inválida")
+	}
+}
+
+// TestMemoryProdutoRepository_SoftDelete verifica que produtos deletados ficam ocultos
+func TestMemoryProdutoRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+	seedMemoryRepository(t, repo)
+
+	if err := repo.Delete(ctx, 2, AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if _, err := repo.FindByID(ctx, 2); err == nil || err.Error() != "not found" {
+		t.Errorf("Esperado erro 'not found', obtido %v", err)
+	}
+	if err := repo.Delete(ctx, 2, AnyVersion); err == nil || err.Error() != "not found" {
+		t.Errorf("Esperado erro 'not found' ao deletar novamente, obtido %v", err)
+	}
+	if _, err := repo.Patch(ctx, 2, map[string]interface{}{"nome": "Outro"}, AnyVersion); err == nil {
+		t.Error("Patch não deveria alterar produto deletado")
+	}
+
+	active := dto.FilterRequest{}
+	count, err := repo.Count(ctx, active.ToMongoFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Count esperado 3, obtido %d", count)
+	}
+}
+
+// TestMemoryProdutoRepository_Concurrent verifica o uso concorrente do repositório
+func TestMemoryProdutoRepository_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				created, err := repo.Create(ctx, model.Produto{Nome: "Produto", Preco: 10})
+				if err != nil {
+					t.Errorf("Erro inesperado: %v", err)
+					return
+				}
+				if _, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 20.0}, AnyVersion); err != nil {
+					t.Errorf("Erro inesperado: %v", err)
+					return
+				}
+				if _, err := repo.FindAllPaginated(ctx, 0, 10, nil, nil, nil); err != nil {
+					t.Errorf("Erro inesperado: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := repo.Count(ctx, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != 1000 {
+		t.Errorf("Count esperado 1000, obtido %d", count)
+	}
+}
+
+// TestMemoryProdutoRepository_LegacyVersion verifica que documentos anteriores ao
+// controle de versão (sem o campo version) continuam sujeitos à versão esperada
+func TestMemoryProdutoRepository_LegacyVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryProdutoRepository().(*memoryProdutoRepository)
+	created, err := repo.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50.00})
+	if err != nil {
+		t.Fatalf("Erro ao criar produto: %v", err)
+	}
+	delete(repo.documents[created.ID], "version")
+
+	legacy, err := repo.FindByID(ctx, created.ID)
+	if err != nil || legacy.Version != 0 {
+		t.Fatalf("Produto legado deveria ter versão 0: %+v (%v)", legacy, err)
+	}
+
+	if _, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 45.00}, 1); err == nil || err.Error() != "version conflict" {
+		t.Errorf("Esperado version conflict, obtido %v", err)
+	}
+	updated, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 45.00}, legacy.Version)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if updated.Version != 1 {
+		t.Errorf("Versão esperada 1, obtida %d", updated.Version)
+	}
+	if _, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 40.00}, legacy.Version); err == nil || err.Error() != "version conflict" {
+		t.Errorf("A versão 0 não deveria mais corresponder: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"api-go-arquitetura/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Este arquivo implementa uma versão simplificada da busca textual ($text) do
+// MongoDB para o repositório em memória. Não há stemming por idioma: termos e
+// palavras são normalizados (minúsculas, sem acentos, sem plural simples) e as
+// stop words mais comuns do português são ignoradas.
+
+// textStopWords são palavras ignoradas na busca, assim como no índice de texto do MongoDB
+var textStopWords = map[string]bool{
+	"a": true, "o": true, "as": true, "os": true, "e": true, "de": true, "da": true,
+	"do": true, "das": true, "dos": true, "em": true, "no": true, "na": true,
+	"nos": true, "nas": true, "um": true, "uma": true, "para": true, "com": true,
+	"por": true, "sem": true,
+}
+
+// accentFold mapeia letras acentuadas para a letra sem acento
+var accentFold = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// normalizeTerm normaliza uma palavra para comparação (stemming simplificado)
+func normalizeTerm(word string) string {
+	word = accentFold.Replace(strings.ToLower(word))
+	// Plural simples: "notebooks" -> "notebook"
+	if len(word) > 3 && strings.HasSuffix(word, "s") {
+		word = strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// tokenize separa o texto em palavras normalizadas, ignorando stop words
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if textStopWords[strings.ToLower(w)] {
+			continue
+		}
+		tokens = append(tokens, normalizeTerm(w))
+	}
+	return tokens
+}
+
+// textQuery representa uma busca textual já interpretada
+type textQuery struct {
+	terms    []string // Termos buscados (basta um corresponder)
+	excluded []string // Termos negados com "-" (nenhum pode corresponder)
+}
+
+// parseTextSearch interpreta o valor de $search: termos separados por espaço
+// e termos negados com "-"; aspas são tratadas como termos comuns
+func parseTextSearch(search string) textQuery {
+	var q textQuery
+	for _, field := range strings.Fields(strings.ReplaceAll(search, `"`, " ")) {
+		negated := strings.HasPrefix(field, "-")
+		for _, token := range tokenize(strings.TrimPrefix(field, "-")) {
+			if negated {
+				q.excluded = append(q.excluded, token)
+			} else {
+				q.terms = append(q.terms, token)
+			}
+		}
+	}
+	return q
+}
+
+// textSearchValue extrai o texto buscado da condição {"$search": "..."}
+func textSearchValue(cond interface{}) (string, error) {
+	m, ok := asFilterMap(cond)
+	if !ok {
+		return "", fmt.Errorf("$text requer um documento")
+	}
+	search, ok := m["$search"].(string)
+	if !ok {
+		return "", fmt.Errorf("$text requer $search")
+	}
+	return search, nil
+}
+
+// textScore calcula a relevância do documento para a busca: cada ocorrência de um
+// termo soma o peso do campo (database.TextIndexWeights). Retorna zero quando nenhum
+// termo corresponde ou quando um termo negado corresponde
+func textScore(doc bson.M, search string) float64 {
+	q := parseTextSearch(search)
+	if len(q.terms) == 0 {
+		return 0
+	}
+
+	score := 0.0
+	for field, weight := range database.TextIndexWeights {
+		text, _ := doc[field].(string)
+		for _, token := range tokenize(text) {
+			for _, excluded := range q.excluded {
+				if token == excluded {
+					return 0
+				}
+			}
+			for _, term := range q.terms {
+				if token == term {
+					score += float64(weight)
+				}
+			}
+		}
+	}
+	return score
+}
+
+// matchText avalia o operador $text
+func matchText(doc bson.M, cond interface{}) (bool, error) {
+	search, err := textSearchValue(cond)
+	if err != nil {
+		return false, err
+	}
+	return textScore(doc, search) > 0, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"time"
 
 	"api-go-arquitetura/internal/database"
@@ -15,39 +16,57 @@ import (
 
 // mongoProdutoRepository implementa ProdutoRepository usando MongoDB
 type mongoProdutoRepository struct {
-	Collection *mongo.Collection
+	Collection  *mongo.Collection
+	idGenerator IDGenerator
+
+	// percentileUnsupported indica que o servidor não tem $percentile (ver Aggregate)
+	percentileUnsupported atomic.Bool
 }
 
+// codeUnknownGroupOperator é o código de erro do MongoDB para acumulador desconhecido no $group
+const codeUnknownGroupOperator = 15952
+
 // NewProdutoRepository cria uma nova instância do ProdutoRepository
+// Os IDs são alocados pelo contador "produtos" da coleção de contadores do mesmo banco
 func NewProdutoRepository(col *mongo.Collection) ProdutoRepository {
-	return &mongoProdutoRepository{Collection: col}
+	counters := col.Database().Collection(CountersCollection)
+	return NewProdutoRepositoryWithIDGenerator(col, NewMongoIDGenerator(counters, col.Name()))
 }
 
-func (r *mongoProdutoRepository) getNextID(ctx context.Context) (int, error) {
-	opts := options.FindOne().SetSort(bson.D{{"id", -1}})
-	var p model.Produto
-	err := r.Collection.FindOne(ctx, bson.M{}, opts).Decode(&p)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return 1, nil
-		}
-		return 0, err
+// NewProdutoRepositoryWithIDGenerator cria uma nova instância do ProdutoRepository com gerador de IDs customizado
+func NewProdutoRepositoryWithIDGenerator(col *mongo.Collection, idGenerator IDGenerator) ProdutoRepository {
+	return &mongoProdutoRepository{
+		Collection:  col,
+		idGenerator: idGenerator,
 	}
-	return p.ID + 1, nil
 }
 
 func (r *mongoProdutoRepository) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
 	// Usar retry logic para operação crítica
-	retryOpts := database.DefaultRetryOptions()
+	return r.create(ctx, produto, database.DefaultRetryOptions())
+}
+
+// create insere o produto com as opções de retry informadas
+func (r *mongoProdutoRepository) create(ctx context.Context, produto model.Produto, retryOpts database.RetryOptions) (model.Produto, error) {
+	// Alocar o ID uma única vez, fora do retry: o contador é atômico, então o ID
+	// pertence exclusivamente a esta chamada mesmo que o insert precise ser repetido
+	id, err := r.idGenerator.NextID(ctx)
+	if err != nil {
+		return model.Produto{}, err
+	}
+	produto.ID = id
+	produto.BeforeCreate() // Inicializar timestamps
+
+	attempt := 0
 	result, err := database.RetryWithResult(ctx, func() (model.Produto, error) {
-		id, err := r.getNextID(ctx)
-		if err != nil {
-			return model.Produto{}, err
-		}
-		produto.ID = id
-		produto.BeforeCreate() // Inicializar timestamps
-		_, err = r.Collection.InsertOne(ctx, produto)
+		attempt++
+		_, err := r.Collection.InsertOne(ctx, produto)
 		if err != nil {
+			// Se uma tentativa anterior falhou por rede depois de gravar o documento,
+			// o retry encontra o próprio ID já inserido: a operação foi concluída
+			if attempt > 1 && mongo.IsDuplicateKeyError(err) {
+				return produto, nil
+			}
 			return model.Produto{}, err
 		}
 		return produto, nil
@@ -57,7 +76,8 @@ func (r *mongoProdutoRepository) Create(ctx context.Context, produto model.Produ
 }
 
 func (r *mongoProdutoRepository) FindAll(ctx context.Context) ([]model.Produto, error) {
-	cursor, err := r.Collection.Find(ctx, bson.M{})
+	// Filtrar produtos deletados (soft delete), assim como as demais consultas
+	cursor, err := r.Collection.Find(ctx, bson.M{"deleted_at": bson.M{"$exists": false}})
 	if err != nil {
 		return nil, err
 	}
@@ -85,109 +105,265 @@ func (r *mongoProdutoRepository) FindByID(ctx context.Context, id int) (model.Pr
 	return produto, nil
 }
 
-func (r *mongoProdutoRepository) Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error) {
-	// Usar retry logic para operação crítica
-	retryOpts := database.DefaultRetryOptions()
-	result, err := database.RetryWithResult(ctx, func() (model.Produto, error) {
-		produto.ID = id
-		produto.BeforeUpdate() // Atualizar timestamp
-		
-		// Buscar produto existente para preservar CreatedAt e verificar se não está deletado
-		filter := bson.M{
-			"id":        id,
-			"deleted_at": bson.M{"$exists": false},
-		}
-		var existing model.Produto
-		err := r.Collection.FindOne(ctx, filter).Decode(&existing)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return model.Produto{}, errors.New("not found")
-			}
-			return model.Produto{}, err
-		}
-		produto.CreatedAt = existing.CreatedAt // Preservar CreatedAt
-		produto.DeletedAt = existing.DeletedAt // Preservar DeletedAt (soft delete)
-		
-		res, err := r.Collection.ReplaceOne(ctx, filter, produto)
-		if err != nil {
-			return model.Produto{}, err
-		}
-		if res.MatchedCount == 0 {
-			return model.Produto{}, errors.New("not found")
+// Update, Patch e Delete não usam o retry da aplicação: todas incrementam a versão, e
+// uma repetição depois de uma tentativa gravada com a resposta perdida (erro de rede)
+// incrementaria a versão de novo (invalidando o ETag de quem acabou de ler o produto)
+// ou, em alterações condicionais, retornaria "version conflict" para uma alteração
+// concluída. As retryable writes do driver já repetem a operação uma vez com
+// segurança (sem reaplicá-la)
+func (r *mongoProdutoRepository) Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error) {
+	produto.BeforeUpdate() // Atualizar timestamp
+
+	// Substituir os campos editáveis e incrementar a versão em uma única operação
+	// atômica; CreatedAt e DeletedAt são preservados por não fazerem parte do $set
+	update := bson.M{
+		"$set": bson.M{
+			"nome":       produto.Nome,
+			"preco":      produto.Preco,
+			"descricao":  produto.Descricao,
+			"updated_at": produto.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated model.Produto
+	err := r.Collection.FindOneAndUpdate(ctx, versionedFilter(id, expectedVersion), update, opts).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return model.Produto{}, r.notFoundOrConflict(ctx, id, expectedVersion)
 		}
-		return produto, nil
-	}, retryOpts)
-	
-	return result, err
+		return model.Produto{}, err
+	}
+	return updated, nil
 }
 
-func (r *mongoProdutoRepository) Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error) {
-	// Adicionar updated_at automaticamente
-	updates["updated_at"] = time.Now()
-	
-	// Filtrar produtos deletados (soft delete)
-	filter := bson.M{
-		"id":        id,
-		"deleted_at": bson.M{"$exists": false},
+func (r *mongoProdutoRepository) Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error) {
+	// Adicionar updated_at automaticamente (sem alterar o mapa recebido)
+	set := bson.M{}
+	for k, v := range updates {
+		set[k] = v
 	}
+	set["updated_at"] = time.Now()
 	
-	update := bson.M{"$set": updates}
+	// Filtrar produtos deletados (soft delete) e verificar a versão esperada
+	filter := versionedFilter(id, expectedVersion)
+	
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	var updated model.Produto
 	err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return model.Produto{}, errors.New("not found")
+			return model.Produto{}, r.notFoundOrConflict(ctx, id, expectedVersion)
 		}
 		return model.Produto{}, err
 	}
 	return updated, nil
 }
 
-func (r *mongoProdutoRepository) Delete(ctx context.Context, id int) error {
+func (r *mongoProdutoRepository) Delete(ctx context.Context, id int, expectedVersion int) error {
 	// Soft delete: marcar como deletado ao invés de remover
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	res, err := r.Collection.UpdateOne(ctx, versionedFilter(id, expectedVersion), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return r.notFoundOrConflict(ctx, id, expectedVersion)
+	}
+	return nil
+}
+
+// Upsert substitui os campos editáveis do produto ou, se ele não existir, o cria com o
+// ID informado (ver BulkUpsert); retorna também se o produto foi criado
+func (r *mongoProdutoRepository) Upsert(ctx context.Context, id int, produto model.Produto) (model.Produto, bool, error) {
+	produto.BeforeUpdate()
+	filter := bson.M{"id": id, "deleted_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set": bson.M{
+			"nome":       produto.Nome,
+			"preco":      produto.Preco,
+			"descricao":  produto.Descricao,
+			"updated_at": produto.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"created_at": produto.UpdatedAt},
+		"$inc":         bson.M{"version": 1},
+	}
+	res, err := r.Collection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		// Sem produto ativo: o ID pode pertencer a um produto deletado, que não é alterado
+		var deleted int64
+		deleted, err = r.Collection.CountDocuments(ctx, bson.M{"id": id})
+		if err == nil && deleted > 0 {
+			return model.Produto{}, false, errors.New("deleted")
+		}
+		if err == nil {
+			// Reservar o ID antes do insert, para que NextID não o aloque para outro produto
+			err = r.idGenerator.Reserve(ctx, id)
+		}
+		if err == nil {
+			// Se outra requisição criar o ID entre as duas operações, o upsert apenas
+			// o altera (ou falha pelo índice único, se ele tiver sido deletado)
+			res, err = r.Collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		}
+	}
+	if err != nil {
+		return model.Produto{}, false, err
+	}
+
+	var upserted model.Produto
+	if err := r.Collection.FindOne(ctx, filter).Decode(&upserted); err != nil {
+		return model.Produto{}, false, err
+	}
+	return upserted, res.UpsertedCount > 0, nil
+}
+
+// BulkWrite executa as operações em ordem, uma a uma: a API BulkWrite do MongoDB
+// informa apenas totais para updates sem correspondência, o que não permite
+// distinguir produto inexistente de conflito de versão em cada item
+// O modo atômico usa uma transação e exige um replica set (ou cluster shardeado)
+func (r *mongoProdutoRepository) BulkWrite(ctx context.Context, operations []BulkOperation, atomic bool) ([]BulkResult, error) {
+	results := make([]BulkResult, len(operations))
+	if !atomic {
+		for i, op := range operations {
+			results[i] = applyBulkOperation(ctx, r, op)
+		}
+		return results, nil
+	}
+
+	tx, cancel, err := database.StartTransaction(ctx, r.Collection.Database().Client())
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	defer tx.End()
+
+	failed := -1
+	err = tx.WithTransaction(func(sc mongo.SessionContext) error {
+		for i, op := range operations {
+			results[i] = applyBulkOperation(sc, transactionWriter{r}, op)
+			if results[i].Err != nil {
+				failed = i
+				return results[i].Err
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		abortBulkResults(results, failed)
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// transactionWriter executa as escritas de um lote atômico com uma única tentativa: um
+// erro transitório aborta a transação, e WithTransaction já repete o lote inteiro
+// Apenas Create usa o retry da aplicação; as demais escritas já têm uma tentativa
+type transactionWriter struct {
+	*mongoProdutoRepository
+}
+
+func (w transactionWriter) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
+	retryOpts := database.DefaultRetryOptions()
+	retryOpts.MaxAttempts = 1
+	return w.create(ctx, produto, retryOpts)
+}
+
+// notFoundOrConflict identifica por que uma alteração condicional não encontrou o produto:
+// se o produto ativo existe, a versão esperada não confere
+func (r *mongoProdutoRepository) notFoundOrConflict(ctx context.Context, id int, expectedVersion int) error {
+	if expectedVersion == AnyVersion {
+		return errors.New("not found")
+	}
+	if _, err := r.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return errors.New("version conflict")
+}
+
+// Restore desfaz o soft delete de um produto
+func (r *mongoProdutoRepository) Restore(ctx context.Context, id int) (model.Produto, error) {
 	retryOpts := database.DefaultRetryOptions()
-	err := database.Retry(ctx, func() error {
-		now := time.Now()
+	return database.RetryWithResult(ctx, func() (model.Produto, error) {
+		// Apenas produtos deletados podem ser restaurados
+		filter := bson.M{
+			"id":         id,
+			"deleted_at": bson.M{"$exists": true},
+		}
 		update := bson.M{
-			"$set": bson.M{
-				"deleted_at": now,
-				"updated_at": now,
-			},
+			"$unset": bson.M{"deleted_at": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$inc":   bson.M{"version": 1},
+		}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		var restored model.Produto
+		err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&restored)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return model.Produto{}, errors.New("not found")
+			}
+			return model.Produto{}, err
 		}
-		res, err := r.Collection.UpdateOne(ctx, bson.M{"id": id, "deleted_at": bson.M{"$exists": false}}, update)
+		return restored, nil
+	}, retryOpts)
+}
+
+// Purge remove definitivamente um produto, deletado ou não
+func (r *mongoProdutoRepository) Purge(ctx context.Context, id int) error {
+	retryOpts := database.DefaultRetryOptions()
+	return database.Retry(ctx, func() error {
+		res, err := r.Collection.DeleteOne(ctx, bson.M{"id": id})
 		if err != nil {
 			return err
 		}
-		if res.MatchedCount == 0 {
+		if res.DeletedCount == 0 {
 			return errors.New("not found")
 		}
 		return nil
 	}, retryOpts)
-	
-	return err
+}
+
+// PurgeDeletedBefore remove definitivamente os produtos deletados antes da data informada
+func (r *mongoProdutoRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	retryOpts := database.DefaultRetryOptions()
+	return database.RetryWithResult(ctx, func() (int64, error) {
+		res, err := r.Collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+		if err != nil {
+			return 0, err
+		}
+		return res.DeletedCount, nil
+	}, retryOpts)
 }
 
 // FindAllPaginated retorna produtos paginados com filtros e ordenação
-func (r *mongoProdutoRepository) FindAllPaginated(ctx context.Context, skip, limit int64, filter map[string]interface{}, sort bson.D) ([]model.Produto, error) {
-	// Converter filter para bson.M
-	mongoFilter := bson.M{}
-	if filter != nil {
-		mongoFilter = bson.M(filter)
-	}
-	// Filtrar produtos deletados (soft delete)
-	mongoFilter["deleted_at"] = bson.M{"$exists": false}
+func (r *mongoProdutoRepository) FindAllPaginated(ctx context.Context, skip, limit int64, filter map[string]interface{}, sort bson.D, projection []string) ([]model.Produto, error) {
+	// Copiar o filtro (o chamador pode reutilizá-lo), excluindo os deletados por padrão
+	mongoFilter := scopeDeleted(filter)
 
-	// Se sort estiver vazio, usar ordenação padrão por ID
-	if len(sort) == 0 {
-		sort = bson.D{{Key: "id", Value: 1}}
-	}
+	// Garantir ordenação determinística (ID como critério de desempate)
+	sort = withIDTieBreaker(sort)
 
 	// Opções de paginação
 	opts := options.Find().
 		SetSkip(skip).
 		SetLimit(limit).
 		SetSort(sort)
+	if len(projection) > 0 {
+		opts.SetProjection(toProjection(projection))
+	}
 
 	cursor, err := r.Collection.Find(ctx, mongoFilter, opts)
 	if err != nil {
@@ -202,15 +378,37 @@ func (r *mongoProdutoRepository) FindAllPaginated(ctx context.Context, skip, lim
 	return produtos, nil
 }
 
+// Stream percorre o cursor do MongoDB decodificando um documento por vez
+func (r *mongoProdutoRepository) Stream(ctx context.Context, filter map[string]interface{}, sort bson.D, fn func(model.Produto) error) error {
+	// Copiar o filtro (o chamador pode reutilizá-lo), excluindo os deletados por padrão
+	mongoFilter := scopeDeleted(filter)
+
+	opts := options.Find().
+		SetSort(withIDTieBreaker(sort)).
+		SetBatchSize(streamBatchSize)
+
+	cursor, err := r.Collection.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var produto model.Produto
+		if err := cursor.Decode(&produto); err != nil {
+			return err
+		}
+		if err := fn(produto); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 // Count retorna o total de documentos que correspondem ao filtro
 func (r *mongoProdutoRepository) Count(ctx context.Context, filter map[string]interface{}) (int64, error) {
-	// Converter filter para bson.M
-	mongoFilter := bson.M{}
-	if filter != nil {
-		mongoFilter = bson.M(filter)
-	}
-	// Filtrar produtos deletados (soft delete)
-	mongoFilter["deleted_at"] = bson.M{"$exists": false}
+	// Copiar o filtro (o chamador pode reutilizá-lo), excluindo os deletados por padrão
+	mongoFilter := scopeDeleted(filter)
 
 	count, err := r.Collection.CountDocuments(ctx, mongoFilter)
 	if err != nil {
@@ -218,3 +416,288 @@ func (r *mongoProdutoRepository) Count(ctx context.Context, filter map[string]in
 	}
 	return count, nil
 }
+
+// streamBatchSize é o número de documentos buscados por vez pelo cursor de Stream
+const streamBatchSize = 500
+
+// versionedFilter retorna o filtro de um produto ativo (não deletado), exigindo a
+// versão esperada quando informada
+func versionedFilter(id int, expectedVersion int) bson.M {
+	filter := bson.M{
+		"id":         id,
+		"deleted_at": bson.M{"$exists": false},
+	}
+	switch expectedVersion {
+	case AnyVersion:
+	case 0:
+		// Documentos anteriores ao controle de versão não têm o campo (lidos como 0);
+		// o $inc da alteração cria o campo com a versão 1
+		filter["version"] = bson.M{"$exists": false}
+	default:
+		filter["version"] = expectedVersion
+	}
+	return filter
+}
+
+// Search executa a busca textual ordenada por relevância (textScore)
+func (r *mongoProdutoRepository) Search(ctx context.Context, filter map[string]interface{}, skip, limit int64) ([]model.SearchResult, error) {
+	// Copiar o filtro (o chamador pode reutilizá-lo), excluindo os deletados por padrão
+	mongoFilter := scopeDeleted(filter)
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "id", Value: 1}}).
+		SetSkip(skip).
+		SetLimit(limit)
+
+	cursor, err := r.Collection.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []model.SearchResult
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Facets calcula as facetas com uma única agregação $facet sobre os produtos do filtro
+func (r *mongoProdutoRepository) Facets(ctx context.Context, filter map[string]interface{}, fields []string) (model.Facets, error) {
+	if err := validateFacets(fields); err != nil {
+		return model.Facets{}, err
+	}
+	if len(fields) == 0 {
+		return model.Facets{}, nil
+	}
+
+	// Cada faceta solicitada é um ramo do $facet
+	facetStages := bson.M{}
+	if hasFacet(fields, model.FacetPreco) {
+		boundaries := model.PrecoFacetBoundaries
+		facetStages["preco_stats"] = bson.A{
+			bson.M{"$group": bson.M{
+				"_id":   nil,
+				"min":   bson.M{"$min": "$preco"},
+				"max":   bson.M{"$max": "$preco"},
+				"count": bson.M{"$sum": 1},
+			}},
+		}
+		facetStages["preco_buckets"] = bson.A{
+			bson.M{"$bucket": bson.M{
+				"groupBy":    "$preco",
+				"boundaries": boundaries,
+				// Preços a partir do último limite formam a última faixa (sem limite superior)
+				"default": boundaries[len(boundaries)-1],
+				"output":  bson.M{"count": bson.M{"$sum": 1}},
+			}},
+		}
+	}
+	if hasFacet(fields, model.FacetNome) {
+		facetStages["nome_counts"] = bson.A{
+			bson.M{"$sortByCount": "$nome"},
+			// $sortByCount não desempata valores com a mesma quantidade
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": model.AttributeFacetLimit},
+		}
+	}
+
+	// $match vem primeiro para usar os índices (e permitir $text)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scopeDeleted(filter)}},
+		{{Key: "$facet", Value: facetStages}},
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return model.Facets{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		PrecoStats   []precoFacetStats     `bson:"preco_stats"`
+		PrecoBuckets []precoFacetBucket    `bson:"preco_buckets"`
+		NomeCounts   []attributeFacetCount `bson:"nome_counts"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return model.Facets{}, err
+	}
+
+	var facets model.Facets
+	if hasFacet(fields, model.FacetPreco) {
+		var stats precoFacetStats
+		var buckets []precoFacetBucket
+		if len(results) > 0 {
+			if len(results[0].PrecoStats) > 0 {
+				stats = results[0].PrecoStats[0]
+			}
+			buckets = results[0].PrecoBuckets
+		}
+		facets.Preco = newPriceFacet(stats, buckets)
+	}
+	if hasFacet(fields, model.FacetNome) {
+		var counts []attributeFacetCount
+		if len(results) > 0 {
+			counts = results[0].NomeCounts
+		}
+		facets.Nome = newAttributeFacet(counts)
+	}
+	return facets, nil
+}
+
+// Aggregate calcula as estatísticas dos produtos do filtro com um único $group
+// A média é derivada de sum e count (ver newProdutoStats)
+// Os percentis usam $percentile com o método aproximado (MongoDB 7.0 ou superior);
+// em servidores anteriores, são calculados por posição na ordenação por preço
+func (r *mongoProdutoRepository) Aggregate(ctx context.Context, filter map[string]interface{}) (model.ProdutoStats, error) {
+	match := scopeDeleted(filter)
+	withPercentile := !r.percentileUnsupported.Load()
+	res, err := r.groupStats(ctx, match, withPercentile)
+	if withPercentile && isUnknownOperatorError(err) {
+		// Detectado uma vez: as chamadas seguintes vão direto ao cálculo por posição
+		r.percentileUnsupported.Store(true)
+		withPercentile = false
+		res, err = r.groupStats(ctx, match, false)
+	}
+	if err != nil {
+		return model.ProdutoStats{}, err
+	}
+
+	// Sem produtos o $group não gera documento: estatísticas zeradas
+	if res.Count == 0 {
+		return model.ProdutoStats{}, nil
+	}
+	if !withPercentile {
+		if res.Percentiles, err = r.rankPercentiles(ctx, match, res.Count); err != nil {
+			return model.ProdutoStats{}, err
+		}
+	}
+	return newProdutoStats(res.Count, res.Sum, res.Min, res.Max, res.Percentiles), nil
+}
+
+// produtoStatsGroup é o documento gerado pelo $group de Aggregate
+type produtoStatsGroup struct {
+	Count       int64     `bson:"count"`
+	Sum         float64   `bson:"sum"`
+	Min         float64   `bson:"min"`
+	Max         float64   `bson:"max"`
+	Percentiles []float64 `bson:"percentiles"`
+}
+
+// groupStats executa o $group das estatísticas, com ou sem $percentile
+func (r *mongoProdutoRepository) groupStats(ctx context.Context, match bson.M, withPercentile bool) (produtoStatsGroup, error) {
+	group := bson.M{
+		"_id":   nil,
+		"count": bson.M{"$sum": 1},
+		"sum":   bson.M{"$sum": "$preco"},
+		"min":   bson.M{"$min": "$preco"},
+		"max":   bson.M{"$max": "$preco"},
+	}
+	if withPercentile {
+		group["percentiles"] = bson.M{"$percentile": bson.M{
+			"input":  "$preco",
+			"p":      model.StatsPercentiles,
+			"method": "approximate",
+		}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: group}},
+	}
+
+	cursor, err := r.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return produtoStatsGroup{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []produtoStatsGroup
+	if err = cursor.All(ctx, &results); err != nil {
+		return produtoStatsGroup{}, err
+	}
+	if len(results) == 0 {
+		return produtoStatsGroup{}, nil
+	}
+	return results[0], nil
+}
+
+// rankPercentiles calcula os percentis de model.StatsPercentiles pela posição
+// (nearest-rank) na ordenação por preço, lendo um único documento por percentil
+// Usado quando o servidor não tem $percentile (anterior ao MongoDB 7.0)
+func (r *mongoProdutoRepository) rankPercentiles(ctx context.Context, match bson.M, count int64) ([]float64, error) {
+	percentiles := make([]float64, 0, len(model.StatsPercentiles))
+	for _, p := range model.StatsPercentiles {
+		opts := options.FindOne().
+			SetSort(bson.D{{Key: "preco", Value: 1}, {Key: "id", Value: 1}}).
+			SetSkip(percentileRank(p, count) - 1).
+			SetProjection(bson.M{"_id": 0, "preco": 1})
+
+		var doc struct {
+			Preco float64 `bson:"preco"`
+		}
+		if err := r.Collection.FindOne(ctx, match, opts).Decode(&doc); err != nil {
+			return nil, err
+		}
+		percentiles = append(percentiles, doc.Preco)
+	}
+	return percentiles, nil
+}
+
+// isUnknownOperatorError verifica se o servidor rejeitou o pipeline por não conhecer
+// um operador (ex: $percentile antes do MongoDB 7.0)
+func isUnknownOperatorError(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	return serverErr.HasErrorCode(codeUnknownGroupOperator) || serverErr.HasErrorMessage("$percentile")
+}
+
+// copyFilter copia o filtro para bson.M
+// O mapa recebido não é alterado, pois o chamador pode reutilizá-lo (ex: Count e FindAllPaginated)
+func copyFilter(filter map[string]interface{}) bson.M {
+	result := make(bson.M, len(filter))
+	for k, v := range filter {
+		result[k] = v
+	}
+	return result
+}
+
+// scopeDeleted copia o filtro aplicando o padrão de soft delete: sem condição sobre
+// deleted_at, apenas produtos ativos; com model.IncludeDeleted, ativos e deletados
+func scopeDeleted(filter map[string]interface{}) bson.M {
+	result := copyFilter(filter)
+	cond, ok := result["deleted_at"]
+	switch {
+	case !ok:
+		result["deleted_at"] = bson.M{"$exists": false}
+	case cond == model.IncludeDeleted:
+		delete(result, "deleted_at")
+	}
+	return result
+}
+
+// toProjection converte a lista de campos para a projeção do MongoDB (sem _id)
+func toProjection(fields []string) bson.D {
+	projection := make(bson.D, 0, len(fields)+1)
+	projection = append(projection, bson.E{Key: "_id", Value: 0})
+	for _, field := range fields {
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+	return projection
+}
+
+// withIDTieBreaker adiciona o ID como último critério de ordenação, tornando a ordem
+// determinística quando há empates (ex: produtos com o mesmo preço)
+func withIDTieBreaker(sort bson.D) bson.D {
+	for _, e := range sort {
+		if e.Key == "id" {
+			return sort
+		}
+	}
+	result := make(bson.D, 0, len(sort)+1)
+	result = append(result, sort...)
+	return append(result, bson.E{Key: "id", Value: 1})
+}
@@ -1,10 +1,11 @@
 package repository
 
 import (
-	"context"
+	"fmt"
 	"testing"
 
-	"api-go-arquitetura/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // TestProdutoRepository_Interface verifica se mongoProdutoRepository implementa a interface
@@ -12,17 +13,7 @@ func TestProdutoRepository_Interface(t *testing.T) {
 	// Este teste verifica em tempo de compilação que a implementação
 	// está correta. Se houver algum método faltando, o código não compila.
 	var _ ProdutoRepository = (*mongoProdutoRepository)(nil)
-}
-
-// TestProdutoRepository_GetNextID_EmptyCollection testa getNextID quando não há produtos
-func TestProdutoRepository_GetNextID_EmptyCollection(t *testing.T) {
-	// Este é um teste de integração que requer MongoDB rodando
-	// Para testes unitários completos, seria necessário usar mocks do MongoDB
-	// ou usar uma biblioteca como mtest (requer MongoDB em memória)
-	
-	// Por enquanto, este teste serve como documentação
-	// de que a função getNextID deve retornar 1 quando não há produtos
-	t.Skip("Teste de integração - requer MongoDB rodando")
+	var _ produtoWriter = transactionWriter{}
 }
 
 // TestProdutoRepository_ErrorHandling testa tratamento de erros
@@ -43,3 +34,28 @@ func TestProdutoRepository_ErrorHandling(t *testing.T) {
 	})
 }
 
+// TestVersionedFilter verifica a condição de versão das alterações no MongoDB
+func TestVersionedFilter(t *testing.T) {
+	if _, ok := versionedFilter(1, AnyVersion)["version"]; ok {
+		t.Error("AnyVersion não deveria condicionar a versão")
+	}
+	if got := versionedFilter(1, 3)["version"]; got != 3 {
+		t.Errorf("Condição esperada 3, obtida %v", got)
+	}
+	// Documentos anteriores ao controle de versão não têm o campo (versão 0)
+	legacy, ok := versionedFilter(1, 0)["version"].(bson.M)
+	if !ok || legacy["$exists"] != false {
+		t.Errorf("Versão 0 deveria exigir a ausência do campo, obtido %v", versionedFilter(1, 0)["version"])
+	}
+}
+
+// TestIsUnknownOperatorError verifica a detecção de servidores sem $percentile
+func TestIsUnknownOperatorError(t *testing.T) {
+	unknown := mongo.CommandError{Code: codeUnknownGroupOperator, Message: "unknown group operator '$percentile'"}
+	if !isUnknownOperatorError(fmt.Errorf("aggregate: %w", unknown)) {
+		t.Error("Operador desconhecido deveria ser detectado")
+	}
+	if isUnknownOperatorError(mongo.CommandError{Code: 2, Message: "bad value"}) || isUnknownOperatorError(nil) {
+		t.Error("Outros erros não deveriam acionar o cálculo por posição")
+	}
+}
@@ -0,0 +1,1188 @@
+// Package repositorytest contém a suíte de conformidade que toda implementação
+// de repository.ProdutoRepository deve satisfazer
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"api-go-arquitetura/internal/dto"
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Factory cria um repositório vazio e isolado para um teste
+type Factory func(t *testing.T) repository.ProdutoRepository
+
+// RunProdutoRepositoryContract executa a suíte de conformidade contra a implementação criada pela factory
+// Cada subteste recebe um repositório novo, então a factory deve isolar os dados (ex: banco de dados por teste)
+func RunProdutoRepositoryContract(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.ProdutoRepository)
+	}{
+		{"Create atribui IDs únicos e timestamps", testCreate},
+		{"FindByID retorna produto existente", testFindByID},
+		{"FindByID retorna not found para produto inexistente ou deletado", testFindByIDNotFound},
+		{"Update substitui campos e preserva created_at", testUpdate},
+		{"Update retorna not found para produto inexistente ou deletado", testUpdateNotFound},
+		{"Patch altera apenas os campos informados e preserva created_at", testPatch},
+		{"Patch retorna not found para produto inexistente ou deletado", testPatchNotFound},
+		{"Delete faz soft delete", testDelete},
+		{"FindAll retorna produtos ativos", testFindAll},
+		{"FindAllPaginated aplica filtros de FilterRequest", testFindAllPaginatedFilters},
+		{"FindAllPaginated aplica skip e limit", testFindAllPaginatedSkipLimit},
+		{"FindAllPaginated ordena de forma estável", testFindAllPaginatedSort},
+		{"Count corresponde a FindAllPaginated", testCountMatchesFindAllPaginated},
+		{"Filtros informados não são alterados", testFilterNotMutated},
+		{"FindAllPaginated e Count respeitam o modo de deletados", testDeletedModes},
+		{"Consultas sem condição sobre deleted_at excluem deletados", testDeletedExcludedByDefault},
+		{"Restore desfaz o soft delete", testRestore},
+		{"Restore retorna not found para produto ativo ou inexistente", testRestoreNotFound},
+		{"Purge remove o produto definitivamente", testPurge},
+		{"PurgeDeletedBefore remove apenas deletados antes da data", testPurgeDeletedBefore},
+		{"FindAllPaginated aceita filtros de cursor (keyset)", testFindAllPaginatedCursor},
+		{"Alterações incrementam a versão", testVersionIncrements},
+		{"Alterações com versão divergente retornam version conflict", testVersionConflict},
+		{"Facets calcula a faceta de preço sobre o filtro", testFacets},
+		{"Facets conta os produtos por nome", testNomeFacet},
+		{"Aggregate calcula as estatísticas sobre o filtro", testAggregate},
+		{"Filtros de data e updatedSince com marcas de remoção", testDateFilters},
+		{"FindAllPaginated retorna apenas os campos da projeção", testFindAllPaginatedProjection},
+		{"Stream percorre o filtro na ordem informada", testStream},
+		{"BulkWrite retorna o resultado de cada operação", testBulkWrite},
+		{"BulkWrite upsert altera ou cria o produto com o ID informado", testBulkUpsert},
+		// No MongoDB, o modo atômico usa transações e exige um replica set
+		{"BulkWrite atômico desfaz o lote quando uma operação falha", testBulkWriteAtomic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, factory(t))
+		})
+	}
+}
+
+// seed cria os produtos de referência usados pelos testes
+func seed(t *testing.T, repo repository.ProdutoRepository) []model.Produto {
+	t.Helper()
+	produtos := []model.Produto{
+		{Nome: "Notebook Gamer", Preco: 5500.00, Descricao: "Notebook com placa de vídeo"},
+		{Nome: "Mouse", Preco: 50.00, Descricao: "Mouse sem fio"},
+		{Nome: "notebook básico", Preco: 2500.00, Descricao: "Para o dia a dia"},
+		{Nome: "Teclado", Preco: 150.00, Descricao: "Teclado mecânico"},
+		{Nome: "Mousepad", Preco: 50.00, Descricao: "Mousepad grande"},
+		{Nome: "Monitor", Preco: 1500.00, Descricao: "Monitor 27 polegadas"},
+	}
+	created := make([]model.Produto, 0, len(produtos))
+	for _, p := range produtos {
+		c, err := repo.Create(context.Background(), p)
+		if err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+		created = append(created, c)
+	}
+	return created
+}
+
+// activeFilter retorna o filtro padrão da API (apenas produtos ativos)
+func activeFilter() map[string]interface{} {
+	f := dto.FilterRequest{}
+	return f.ToMongoFilter()
+}
+
+// sameInstant compara timestamps com a precisão armazenada pelo MongoDB (milissegundos)
+func sameInstant(a, b time.Time) bool {
+	return a.UnixMilli() == b.UnixMilli()
+}
+
+func ids(produtos []model.Produto) []int {
+	result := make([]int, len(produtos))
+	for i, p := range produtos {
+		result[i] = p.ID
+	}
+	return result
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func expectNotFound(t *testing.T, err error) {
+	t.Helper()
+	if err == nil || err.Error() != "not found" {
+		t.Errorf("Esperado erro 'not found', obtido %v", err)
+	}
+}
+
+func testCreate(t *testing.T, repo repository.ProdutoRepository) {
+	created := seed(t, repo)
+
+	seen := make(map[int]bool)
+	for i, p := range created {
+		if p.ID <= 0 {
+			t.Errorf("ID inválido atribuído: %d", p.ID)
+		}
+		if seen[p.ID] {
+			t.Errorf("ID %d atribuído mais de uma vez", p.ID)
+		}
+		seen[p.ID] = true
+		if i > 0 && p.ID <= created[i-1].ID {
+			t.Errorf("IDs deveriam ser crescentes: %d após %d", p.ID, created[i-1].ID)
+		}
+		if p.CreatedAt.IsZero() || p.UpdatedAt.IsZero() {
+			t.Errorf("Timestamps não foram inicializados para o produto %d", p.ID)
+		}
+		if p.IsDeleted() {
+			t.Errorf("Produto %d não deveria estar deletado", p.ID)
+		}
+	}
+}
+
+func testFindByID(t *testing.T, repo repository.ProdutoRepository) {
+	created := seed(t, repo)
+	want := created[1]
+
+	got, err := repo.FindByID(context.Background(), want.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if got.ID != want.ID || got.Nome != want.Nome || got.Preco != want.Preco || got.Descricao != want.Descricao {
+		t.Errorf("Produto esperado %+v, obtido %+v", want, got)
+	}
+	if !sameInstant(got.CreatedAt, want.CreatedAt) {
+		t.Errorf("created_at esperado %v, obtido %v", want.CreatedAt, got.CreatedAt)
+	}
+}
+
+func testFindByIDNotFound(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	_, err := repo.FindByID(ctx, 9999)
+	expectNotFound(t, err)
+
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	_, err = repo.FindByID(ctx, created[0].ID)
+	expectNotFound(t, err)
+}
+
+func testUpdate(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	original := created[0]
+
+	time.Sleep(5 * time.Millisecond)
+	updated, err := repo.Update(ctx, original.ID, model.Produto{Nome: "Notebook Pro", Preco: 7000, Descricao: "Atualizado"}, repository.AnyVersion)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if updated.ID != original.ID || updated.Nome != "Notebook Pro" || updated.Preco != 7000 || updated.Descricao != "Atualizado" {
+		t.Errorf("Produto atualizado incorreto: %+v", updated)
+	}
+
+	stored, err := repo.FindByID(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if stored.Nome != "Notebook Pro" {
+		t.Errorf("Nome esperado Notebook Pro, obtido %s", stored.Nome)
+	}
+	if !sameInstant(stored.CreatedAt, original.CreatedAt) {
+		t.Errorf("created_at deveria ser preservado: esperado %v, obtido %v", original.CreatedAt, stored.CreatedAt)
+	}
+	if !stored.UpdatedAt.After(original.UpdatedAt) {
+		t.Errorf("updated_at deveria avançar: antes %v, depois %v", original.UpdatedAt, stored.UpdatedAt)
+	}
+}
+
+func testUpdateNotFound(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	_, err := repo.Update(ctx, 9999, model.Produto{Nome: "X", Preco: 1}, repository.AnyVersion)
+	expectNotFound(t, err)
+
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	_, err = repo.Update(ctx, created[0].ID, model.Produto{Nome: "X", Preco: 1}, repository.AnyVersion)
+	expectNotFound(t, err)
+}
+
+func testPatch(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	original := created[0]
+
+	time.Sleep(5 * time.Millisecond)
+	patched, err := repo.Patch(ctx, original.ID, map[string]interface{}{"preco": 4999.90}, repository.AnyVersion)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if patched.Preco != 4999.90 {
+		t.Errorf("Preço esperado 4999.90, obtido %v", patched.Preco)
+	}
+	if patched.Nome != original.Nome || patched.Descricao != original.Descricao {
+		t.Errorf("Campos não informados deveriam ser preservados: %+v", patched)
+	}
+	if !sameInstant(patched.CreatedAt, original.CreatedAt) {
+		t.Errorf("created_at deveria ser preservado: esperado %v, obtido %v", original.CreatedAt, patched.CreatedAt)
+	}
+	if !patched.UpdatedAt.After(original.UpdatedAt) {
+		t.Errorf("updated_at deveria avançar: antes %v, depois %v", original.UpdatedAt, patched.UpdatedAt)
+	}
+
+	stored, err := repo.FindByID(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if stored.Preco != 4999.90 || !sameInstant(stored.CreatedAt, original.CreatedAt) {
+		t.Errorf("Patch não foi persistido corretamente: %+v", stored)
+	}
+}
+
+func testPatchNotFound(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	_, err := repo.Patch(ctx, 9999, map[string]interface{}{"nome": "X"}, repository.AnyVersion)
+	expectNotFound(t, err)
+
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	_, err = repo.Patch(ctx, created[0].ID, map[string]interface{}{"nome": "X"}, repository.AnyVersion)
+	expectNotFound(t, err)
+}
+
+func testDelete(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	if err := repo.Delete(ctx, created[1].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	expectNotFound(t, repo.Delete(ctx, created[1].ID, repository.AnyVersion))
+	expectNotFound(t, repo.Delete(ctx, 9999, repository.AnyVersion))
+
+	count, err := repo.Count(ctx, activeFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != int64(len(created)-1) {
+		t.Errorf("Count esperado %d, obtido %d", len(created)-1, count)
+	}
+
+	produtos, err := repo.FindAllPaginated(ctx, 0, 100, activeFilter(), nil, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	for _, p := range produtos {
+		if p.ID == created[1].ID {
+			t.Errorf("Produto deletado %d não deveria ser listado", p.ID)
+		}
+	}
+}
+
+func testFindAll(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	produtos, err := repo.FindAll(ctx)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if got, want := ids(produtos), ids(created[1:]); !equalIDs(got, want) {
+		t.Errorf("FindAll deveria retornar apenas produtos ativos: esperado %v, obtido %v", want, got)
+	}
+}
+
+func testFindAllPaginatedFilters(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	nome := "notebook"
+	descricao := "MOUSE"
+	precoMin := 100.0
+	precoMax := 2500.0
+
+	tests := []struct {
+		name   string
+		filter dto.FilterRequest
+		want   []int
+	}{
+		{"nome case-insensitive", dto.FilterRequest{Nome: &nome}, []int{created[0].ID, created[2].ID}},
+		{"descrição case-insensitive", dto.FilterRequest{Descricao: &descricao}, []int{created[1].ID, created[4].ID}},
+		{"preço mínimo e máximo inclusivos", dto.FilterRequest{PrecoMin: &precoMin, PrecoMax: &precoMax}, []int{created[2].ID, created[3].ID, created[5].ID}},
+		{"nome e preço", dto.FilterRequest{Nome: &nome, PrecoMax: &precoMax}, []int{created[2].ID}},
+		{"expressão com E e OU", dto.FilterRequest{Expression: `preco=gt=100;nome=like=note*,descricao=="Mouse sem fio"`}, []int{created[0].ID, created[1].ID, created[2].ID}},
+		{"expressão com lista e diferença", dto.FilterRequest{Expression: fmt.Sprintf("id=in=(%d,%d,%d);nome!=Teclado", created[1].ID, created[3].ID, created[5].ID)}, []int{created[1].ID, created[5].ID}},
+		{"expressão combinada com os demais filtros", dto.FilterRequest{Nome: &nome, Expression: "preco=lt=3000"}, []int{created[2].ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			produtos, err := repo.FindAllPaginated(ctx, 0, 100, tt.filter.ToMongoFilter(), nil, nil)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if got := ids(produtos); !equalIDs(got, tt.want) {
+				t.Errorf("IDs esperados %v, obtidos %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func testFindAllPaginatedSkipLimit(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	page, err := repo.FindAllPaginated(ctx, 2, 3, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	want := ids(created[2:5])
+	if got := ids(page); !equalIDs(got, want) {
+		t.Errorf("IDs esperados %v, obtidos %v", want, got)
+	}
+
+	empty, err := repo.FindAllPaginated(ctx, 100, 10, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("Página além do fim deveria ser vazia, obtida %v", ids(empty))
+	}
+}
+
+func testFindAllPaginatedSort(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	// Mouse e Mousepad têm o mesmo preço: o empate deve ser resolvido pelo ID
+	asc, err := repo.FindAllPaginated(ctx, 0, 100, nil, bson.D{{Key: "preco", Value: 1}}, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	wantAsc := []int{created[1].ID, created[4].ID, created[3].ID, created[5].ID, created[2].ID, created[0].ID}
+	if got := ids(asc); !equalIDs(got, wantAsc) {
+		t.Errorf("Ordem ascendente esperada %v, obtida %v", wantAsc, got)
+	}
+
+	desc, err := repo.FindAllPaginated(ctx, 0, 100, nil, bson.D{{Key: "preco", Value: -1}}, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	wantDesc := []int{created[0].ID, created[2].ID, created[5].ID, created[3].ID, created[1].ID, created[4].ID}
+	if got := ids(desc); !equalIDs(got, wantDesc) {
+		t.Errorf("Ordem descendente esperada %v, obtida %v", wantDesc, got)
+	}
+
+	// Paginar sobre empates não pode repetir nem perder itens
+	var paged []int
+	for skip := int64(0); skip < int64(len(created)); skip += 2 {
+		page, err := repo.FindAllPaginated(ctx, skip, 2, nil, bson.D{{Key: "preco", Value: 1}}, nil)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		paged = append(paged, ids(page)...)
+	}
+	if !equalIDs(paged, wantAsc) {
+		t.Errorf("Paginação ordenada esperada %v, obtida %v", wantAsc, paged)
+	}
+}
+
+func testCountMatchesFindAllPaginated(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	if err := repo.Delete(ctx, created[3].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	nome := "mouse"
+	precoMin := 100.0
+	filters := []dto.FilterRequest{
+		{},
+		{Nome: &nome},
+		{PrecoMin: &precoMin},
+	}
+
+	for _, f := range filters {
+		filter := f.ToMongoFilter()
+		count, err := repo.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		produtos, err := repo.FindAllPaginated(ctx, 0, 100, filter, nil, nil)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if count != int64(len(produtos)) {
+			t.Errorf("Filtro %v: Count %d diferente de FindAllPaginated %d", filter, count, len(produtos))
+		}
+	}
+}
+
+func testFilterNotMutated(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	filter := map[string]interface{}{"preco": map[string]interface{}{"$gte": 100.0}}
+	if _, err := repo.Count(ctx, filter); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if _, err := repo.FindAllPaginated(ctx, 0, 10, filter, nil, nil); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(filter) != 1 {
+		t.Errorf("O filtro informado não deveria ser alterado, obtido %v", filter)
+	}
+
+	updates := map[string]interface{}{"nome": "Outro"}
+	if _, err := repo.Patch(ctx, created[0].ID, updates, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(updates) != 1 {
+		t.Errorf("O mapa de updates não deveria ser alterado, obtido %v", updates)
+	}
+}
+
+func testRestore(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	original := created[2]
+
+	if err := repo.Delete(ctx, original.ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	restored, err := repo.Restore(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if restored.IsDeleted() {
+		t.Error("Produto restaurado não deveria estar deletado")
+	}
+	if restored.Nome != original.Nome || !sameInstant(restored.CreatedAt, original.CreatedAt) {
+		t.Errorf("Produto restaurado incorreto: %+v", restored)
+	}
+
+	stored, err := repo.FindByID(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("Produto restaurado deveria ser encontrado: %v", err)
+	}
+	if stored.IsDeleted() {
+		t.Error("Produto restaurado não deveria estar deletado")
+	}
+
+	count, err := repo.Count(ctx, activeFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != int64(len(created)) {
+		t.Errorf("Count esperado %d, obtido %d", len(created), count)
+	}
+}
+
+func testRestoreNotFound(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	_, err := repo.Restore(ctx, created[0].ID)
+	expectNotFound(t, err)
+
+	_, err = repo.Restore(ctx, 9999)
+	expectNotFound(t, err)
+}
+
+func testPurge(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	// Produto ativo
+	if err := repo.Purge(ctx, created[0].ID); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	_, err := repo.FindByID(ctx, created[0].ID)
+	expectNotFound(t, err)
+	expectNotFound(t, repo.Purge(ctx, created[0].ID))
+
+	// Produto deletado (soft delete) não pode mais ser restaurado após o purge
+	if err := repo.Delete(ctx, created[1].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if err := repo.Purge(ctx, created[1].ID); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	_, err = repo.Restore(ctx, created[1].ID)
+	expectNotFound(t, err)
+
+	expectNotFound(t, repo.Purge(ctx, 9999))
+}
+
+func testDeletedModes(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	for _, p := range []model.Produto{created[1], created[3]} {
+		if err := repo.Delete(ctx, p.ID, repository.AnyVersion); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+	}
+
+	tests := []struct {
+		mode string
+		want []int
+	}{
+		{"", []int{created[0].ID, created[2].ID, created[4].ID, created[5].ID}},
+		{dto.DeletedExclude, []int{created[0].ID, created[2].ID, created[4].ID, created[5].ID}},
+		{dto.DeletedOnly, []int{created[1].ID, created[3].ID}},
+		{dto.DeletedInclude, ids(created)},
+	}
+
+	for _, tt := range tests {
+		t.Run("deleted="+tt.mode, func(t *testing.T) {
+			f := dto.FilterRequest{Deleted: tt.mode}
+			filter := f.ToMongoFilter()
+
+			produtos, err := repo.FindAllPaginated(ctx, 0, 100, filter, nil, nil)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if got := ids(produtos); !equalIDs(got, tt.want) {
+				t.Errorf("IDs esperados %v, obtidos %v", tt.want, got)
+			}
+			for _, p := range produtos {
+				deleted := p.ID == created[1].ID || p.ID == created[3].ID
+				if p.IsDeleted() != deleted {
+					t.Errorf("Produto %d: deleted_at inconsistente (%v)", p.ID, p.DeletedAt)
+				}
+			}
+
+			count, err := repo.Count(ctx, filter)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if count != int64(len(tt.want)) {
+				t.Errorf("Count esperado %d, obtido %d", len(tt.want), count)
+			}
+		})
+	}
+}
+
+func testDeletedExcludedByDefault(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	want := ids(created[1:])
+
+	// Filtro nil e filtro sem deleted_at: o padrão do repositório é excluir a lixeira
+	for _, filter := range []map[string]interface{}{nil, {"preco": bson.M{"$gte": 0}}} {
+		produtos, err := repo.FindAllPaginated(ctx, 0, 100, filter, nil, nil)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if got := ids(produtos); !equalIDs(got, want) {
+			t.Errorf("Filtro %v: IDs esperados %v, obtidos %v", filter, want, got)
+		}
+
+		count, err := repo.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if count != int64(len(want)) {
+			t.Errorf("Filtro %v: Count esperado %d, obtido %d", filter, len(want), count)
+		}
+
+		var streamed []model.Produto
+		if err := repo.Stream(ctx, filter, nil, func(p model.Produto) error {
+			streamed = append(streamed, p)
+			return nil
+		}); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if got := ids(streamed); !equalIDs(got, want) {
+			t.Errorf("Filtro %v: Stream esperado %v, obtido %v", filter, want, got)
+		}
+
+		stats, err := repo.Aggregate(ctx, filter)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if stats.Count != int64(len(want)) {
+			t.Errorf("Filtro %v: Aggregate esperado %d produtos, obtido %d", filter, len(want), stats.Count)
+		}
+	}
+
+	// Inclusão explícita dos deletados
+	count, err := repo.Count(ctx, map[string]interface{}{"deleted_at": model.IncludeDeleted})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != int64(len(created)) {
+		t.Errorf("Count com IncludeDeleted esperado %d, obtido %d", len(created), count)
+	}
+}
+
+func testPurgeDeletedBefore(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	if err := repo.Delete(ctx, created[0].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cutoff := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	if err := repo.Delete(ctx, created[1].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	purged, err := repo.PurgeDeletedBefore(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Quantidade removida esperada 1, obtida %d", purged)
+	}
+
+	// O produto deletado depois da data de corte continua restaurável
+	if _, err := repo.Restore(ctx, created[1].ID); err != nil {
+		t.Errorf("Produto deletado após a data de corte deveria ser mantido: %v", err)
+	}
+	_, err = repo.Restore(ctx, created[0].ID)
+	expectNotFound(t, err)
+
+	// Produtos ativos nunca são removidos
+	count, err := repo.Count(ctx, activeFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if count != int64(len(created)-1) {
+		t.Errorf("Count esperado %d, obtido %d", len(created)-1, count)
+	}
+}
+
+func testVersionIncrements(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)[0]
+	if created.Version != 1 {
+		t.Fatalf("Versão inicial esperada 1, obtida %d", created.Version)
+	}
+
+	updated, err := repo.Update(ctx, created.ID, model.Produto{Nome: "Notebook Pro", Preco: 7000}, created.Version)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("Versão após Update esperada 2, obtida %d", updated.Version)
+	}
+
+	patched, err := repo.Patch(ctx, created.ID, map[string]interface{}{"preco": 6500.0}, updated.Version)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if patched.Version != 3 {
+		t.Errorf("Versão após Patch esperada 3, obtida %d", patched.Version)
+	}
+
+	if err := repo.Delete(ctx, created.ID, patched.Version); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	restored, err := repo.Restore(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if restored.Version != 5 {
+		t.Errorf("Versão após Delete e Restore esperada 5, obtida %d", restored.Version)
+	}
+}
+
+func testVersionConflict(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)[0]
+
+	// Outro editor altera o produto, tornando a versão 1 obsoleta
+	if _, err := repo.Patch(ctx, created.ID, map[string]interface{}{"nome": "Outro editor"}, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	expectConflict := func(err error) {
+		t.Helper()
+		if err == nil || err.Error() != "version conflict" {
+			t.Errorf("Esperado erro 'version conflict', obtido %v", err)
+		}
+	}
+	_, err := repo.Update(ctx, created.ID, model.Produto{Nome: "X", Preco: 1}, created.Version)
+	expectConflict(err)
+	_, err = repo.Patch(ctx, created.ID, map[string]interface{}{"nome": "X"}, created.Version)
+	expectConflict(err)
+	expectConflict(repo.Delete(ctx, created.ID, created.Version))
+
+	// Nenhuma das alterações rejeitadas foi aplicada
+	current, err := repo.FindByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if current.Nome != "Outro editor" || current.Version != 2 {
+		t.Errorf("Produto não deveria ter sido alterado: %+v", current)
+	}
+
+	// Produto inexistente continua retornando not found, mesmo com versão informada
+	_, err = repo.Update(ctx, 9999, model.Produto{Nome: "X", Preco: 1}, 1)
+	expectNotFound(t, err)
+}
+
+func testFindAllPaginatedCursor(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	for _, field := range []string{"id", "nome", "preco", "descricao", "created_at", "updated_at"} {
+		for _, dir := range []int{1, -1} {
+			sort := bson.D{{Key: field, Value: dir}}
+
+			all, err := repo.FindAllPaginated(ctx, 0, 0, activeFilter(), sort, nil)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+
+			// Percorrer de dois em dois produtos usando o cursor do último item
+			var got []model.Produto
+			filter := activeFilter()
+			for len(got) < len(all) {
+				page, err := repo.FindAllPaginated(ctx, 0, 2, filter, sort, nil)
+				if err != nil {
+					t.Fatalf("%s %d: erro inesperado: %v", field, dir, err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, page...)
+				cursor, err := dto.NewCursor(sort, page[len(page)-1])
+				if err != nil {
+					t.Fatalf("Erro ao criar cursor: %v", err)
+				}
+				encoded, _ := cursor.Encode()
+				decoded, err := dto.DecodeCursor(encoded, sort)
+				if err != nil {
+					t.Fatalf("Erro ao decodificar cursor: %v", err)
+				}
+				filter = dto.WithCursor(activeFilter(), decoded)
+			}
+
+			if !equalIDs(ids(got), ids(all)) {
+				t.Errorf("%s %d: ordem esperada %v, obtida %v", field, dir, ids(all), ids(got))
+			}
+		}
+	}
+}
+
+func testFacets(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	// O limite inferior de uma faixa pertence a ela
+	if _, err := repo.Create(ctx, model.Produto{Nome: "Headset", Preco: 100.00, Descricao: "Headset USB"}); err != nil {
+		t.Fatalf("Erro ao criar produto: %v", err)
+	}
+	// Produtos deletados ficam fora do filtro padrão (Teclado, 150.00)
+	if err := repo.Delete(ctx, created[3].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao deletar produto: %v", err)
+	}
+
+	facets, err := repo.Facets(ctx, activeFilter(), []string{model.FacetPreco})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	preco := facets.Preco
+	if preco == nil {
+		t.Fatal("Faceta de preço esperada")
+	}
+	if preco.Min != 50.00 || preco.Max != 5500.00 || preco.Count != 6 {
+		t.Errorf("Resumo esperado min=50 max=5500 count=6, obtido min=%v max=%v count=%d", preco.Min, preco.Max, preco.Count)
+	}
+
+	want := []int64{2, 1, 0, 2, 1}
+	if len(preco.Buckets) != len(model.PrecoFacetBoundaries) {
+		t.Fatalf("Esperadas %d faixas, obtidas %d", len(model.PrecoFacetBoundaries), len(preco.Buckets))
+	}
+	for i, bucket := range preco.Buckets {
+		if bucket.Min != model.PrecoFacetBoundaries[i] || bucket.Count != want[i] {
+			t.Errorf("Faixa %d: esperado min=%v count=%d, obtido min=%v count=%d", i, model.PrecoFacetBoundaries[i], want[i], bucket.Min, bucket.Count)
+		}
+	}
+	if last := preco.Buckets[len(preco.Buckets)-1]; last.Max != nil {
+		t.Errorf("A última faixa não deveria ter limite superior, obtido %v", *last.Max)
+	}
+
+	// Filtro sem resultados: todas as faixas zeradas
+	nome := "inexistente"
+	empty := dto.FilterRequest{Nome: &nome}
+	facets, err = repo.Facets(ctx, empty.ToMongoFilter(), []string{model.FacetPreco})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if facets.Preco == nil || facets.Preco.Count != 0 || len(facets.Preco.Buckets) != len(model.PrecoFacetBoundaries) {
+		t.Errorf("Faceta vazia esperada, obtida %+v", facets.Preco)
+	}
+
+	// Sem facetas solicitadas nada é calculado
+	facets, err = repo.Facets(ctx, activeFilter(), nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if facets.Preco != nil || facets.Nome != nil {
+		t.Errorf("Nenhuma faceta esperada, obtida %+v", facets)
+	}
+}
+
+func testNomeFacet(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	for _, p := range []model.Produto{{Nome: "Mouse", Preco: 45.00}, {Nome: "Monitor", Preco: 900.00}, {Nome: "Mouse", Preco: 80.00}} {
+		if _, err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+	}
+	// Produtos deletados ficam fora do filtro padrão (Teclado)
+	if err := repo.Delete(ctx, created[3].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao deletar produto: %v", err)
+	}
+
+	facets, err := repo.Facets(ctx, activeFilter(), []string{model.FacetNome})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if facets.Preco != nil {
+		t.Errorf("Apenas a faceta de nome foi solicitada, obtida a de preço %+v", facets.Preco)
+	}
+	if facets.Nome == nil {
+		t.Fatal("Faceta de nome esperada")
+	}
+	// Mais frequentes primeiro; empates em ordem de valor
+	want := []model.AttributeCount{
+		{Value: "Mouse", Count: 3},
+		{Value: "Monitor", Count: 2},
+		{Value: "Mousepad", Count: 1},
+		{Value: "Notebook Gamer", Count: 1},
+		{Value: "notebook básico", Count: 1},
+	}
+	if !reflect.DeepEqual(facets.Nome.Values, want) {
+		t.Errorf("Contagens esperadas %v, obtidas %v", want, facets.Nome.Values)
+	}
+
+	// Filtro sem resultados: faceta sem valores
+	nome := "inexistente"
+	empty := dto.FilterRequest{Nome: &nome}
+	facets, err = repo.Facets(ctx, empty.ToMongoFilter(), []string{model.FacetNome})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if facets.Nome == nil || len(facets.Nome.Values) != 0 {
+		t.Errorf("Faceta vazia esperada, obtida %+v", facets.Nome)
+	}
+}
+
+func testAggregate(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	precoMax := 3000.0
+	f := dto.FilterRequest{PrecoMax: &precoMax}
+	stats, err := repo.Aggregate(ctx, f.ToMongoFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	// Preços filtrados: 50, 50, 150, 1500, 2500
+	preco := stats.Preco
+	if stats.Count != 5 || preco.Sum != 4250.00 || preco.Avg != 850.00 || preco.Min != 50.00 || preco.Max != 2500.00 {
+		t.Errorf("Estatísticas inesperadas: %+v", stats)
+	}
+	// Percentis são estimativas: apenas a ordem e os limites são garantidos
+	if preco.P50 < preco.Min || preco.P50 > preco.P90 || preco.P90 > preco.P99 || preco.P99 > preco.Max {
+		t.Errorf("Percentis fora de ordem ou dos limites: %+v", preco)
+	}
+
+	// Filtro sem resultados: estatísticas zeradas
+	nome := "inexistente"
+	empty := dto.FilterRequest{Nome: &nome}
+	stats, err = repo.Aggregate(ctx, empty.ToMongoFilter())
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if stats != (model.ProdutoStats{}) {
+		t.Errorf("Estatísticas zeradas esperadas, obtidas %+v", stats)
+	}
+}
+
+func testDateFilters(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	// Margens de alguns milissegundos: o MongoDB armazena datas com precisão de milissegundos
+	beforeSeed := time.Now().Add(-5 * time.Millisecond)
+	created := seed(t, repo)
+	time.Sleep(5 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(5 * time.Millisecond)
+
+	preco := 99.90
+	if _, err := repo.Patch(ctx, created[0].ID, map[string]interface{}{"preco": preco}, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao alterar produto: %v", err)
+	}
+	if err := repo.Delete(ctx, created[1].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao deletar produto: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter dto.FilterRequest
+		want   []int
+	}{
+		{"createdFrom antes da criação", dto.FilterRequest{CreatedFrom: &beforeSeed}, []int{1, 3, 4, 5, 6}},
+		{"createdTo antes da criação", dto.FilterRequest{CreatedTo: &beforeSeed}, []int{}},
+		{"createdFrom depois da criação", dto.FilterRequest{CreatedFrom: &since}, []int{}},
+		{"updatedSince inclui alterados e deletados", dto.FilterRequest{UpdatedSince: &since}, []int{1, 2}},
+		{"updatedSince respeita deleted explícito", dto.FilterRequest{UpdatedSince: &since, Deleted: dto.DeletedExclude}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			produtos, err := repo.FindAllPaginated(ctx, 0, 10, tt.filter.ToMongoFilter(), nil, nil)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if !equalIDs(ids(produtos), tt.want) {
+				t.Errorf("IDs esperados %v, obtidos %v", tt.want, ids(produtos))
+			}
+			for _, p := range produtos {
+				if p.ID == created[1].ID && !p.IsDeleted() {
+					t.Errorf("Produto %d deveria ser retornado como deletado (marca de remoção)", p.ID)
+				}
+			}
+		})
+	}
+}
+
+func testFindAllPaginatedProjection(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	produtos, err := repo.FindAllPaginated(ctx, 0, 100, activeFilter(), bson.D{{Key: "preco", Value: -1}}, []string{"id", "nome"})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(produtos) != len(created) || produtos[0].ID != created[0].ID {
+		t.Fatalf("Projeção não deveria alterar filtro e ordenação: %v", ids(produtos))
+	}
+	for _, p := range produtos {
+		if p.ID == 0 || p.Nome == "" {
+			t.Errorf("Campos projetados ausentes: %+v", p)
+		}
+		if p.Preco != 0 || p.Descricao != "" || !p.CreatedAt.IsZero() || p.Version != 0 {
+			t.Errorf("Campos fora da projeção deveriam estar vazios: %+v", p)
+		}
+	}
+}
+
+func testBulkWrite(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	staleVersion := created[3].Version + 1
+
+	operations := []repository.BulkOperation{
+		{Type: repository.BulkCreate, Produto: model.Produto{Nome: "Webcam", Preco: 300.00}},
+		{Type: repository.BulkUpdate, ID: created[0].ID, Produto: model.Produto{Nome: "Notebook Pro", Preco: 7000.00}},
+		{Type: repository.BulkPatch, ID: created[1].ID, Updates: map[string]interface{}{"preco": 45.00}, ExpectedVersion: &created[1].Version},
+		{Type: repository.BulkDelete, ID: created[2].ID},
+		{Type: repository.BulkPatch, ID: 9999, Updates: map[string]interface{}{"preco": 1.00}},
+		{Type: repository.BulkDelete, ID: created[3].ID, ExpectedVersion: &staleVersion},
+	}
+	results, err := repo.BulkWrite(ctx, operations, false)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if len(results) != len(operations) {
+		t.Fatalf("Esperados %d resultados, obtidos %d", len(operations), len(results))
+	}
+
+	for i := 0; i < 4; i++ {
+		if results[i].Err != nil {
+			t.Errorf("Operação %d: erro inesperado %v", i, results[i].Err)
+		}
+	}
+	if results[0].Produto.ID == 0 || results[0].Produto.Nome != "Webcam" {
+		t.Errorf("Create deveria retornar o produto criado, obtido %+v", results[0].Produto)
+	}
+	if results[1].Produto.Nome != "Notebook Pro" || results[2].Produto.Preco != 45.00 {
+		t.Errorf("Update e Patch deveriam retornar o produto alterado: %+v, %+v", results[1].Produto, results[2].Produto)
+	}
+	if results[4].Err == nil || results[4].Err.Error() != "not found" {
+		t.Errorf("Esperado erro 'not found', obtido %v", results[4].Err)
+	}
+	if results[5].Err == nil || results[5].Err.Error() != "version conflict" {
+		t.Errorf("Esperado erro 'version conflict', obtido %v", results[5].Err)
+	}
+
+	// As operações bem-sucedidas foram aplicadas mesmo com falhas no lote
+	if _, err := repo.FindByID(ctx, results[0].Produto.ID); err != nil {
+		t.Errorf("Produto criado no lote não encontrado: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, created[2].ID); err == nil {
+		t.Error("Produto deletado no lote não deveria ser encontrado")
+	}
+	if _, err := repo.FindByID(ctx, created[3].ID); err != nil {
+		t.Errorf("Produto com versão divergente não deveria ser deletado: %v", err)
+	}
+}
+
+func testBulkUpsert(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+	if err := repo.Delete(ctx, created[2].ID, repository.AnyVersion); err != nil {
+		t.Fatalf("Erro ao deletar produto: %v", err)
+	}
+
+	operations := []repository.BulkOperation{
+		{Type: repository.BulkUpsert, ID: created[0].ID, Produto: model.Produto{Nome: "Notebook Pro", Preco: 7000.00}},
+		{Type: repository.BulkUpsert, ID: 9999, Produto: model.Produto{Nome: "Webcam", Preco: 300.00}},
+		{Type: repository.BulkUpsert, ID: created[2].ID, Produto: model.Produto{Nome: "Teclado", Preco: 1.00}},
+	}
+	results, err := repo.BulkWrite(ctx, operations, false)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if results[0].Err != nil || results[0].Created || results[0].Produto.Nome != "Notebook Pro" || results[0].Produto.Version != created[0].Version+1 {
+		t.Errorf("Upsert de produto existente deveria alterá-lo, obtido %+v", results[0])
+	}
+	if !results[0].Produto.CreatedAt.Equal(created[0].CreatedAt) {
+		t.Errorf("Upsert não deveria alterar created_at: esperado %v, obtido %v", created[0].CreatedAt, results[0].Produto.CreatedAt)
+	}
+	if results[1].Err != nil || !results[1].Created || results[1].Produto.ID != 9999 || results[1].Produto.Nome != "Webcam" {
+		t.Errorf("Upsert de ID inexistente deveria criar o produto com esse ID, obtido %+v", results[1])
+	}
+	if results[1].Produto.CreatedAt.IsZero() || results[1].Produto.Version != 1 {
+		t.Errorf("Produto criado por upsert deveria ter created_at e versão 1, obtido %+v", results[1].Produto)
+	}
+	if results[2].Err == nil || results[2].Err.Error() != "deleted" {
+		t.Errorf("Esperado erro 'deleted', obtido %v", results[2].Err)
+	}
+
+	// O ID usado pelo upsert não é alocado para novos produtos
+	novo, err := repo.Create(ctx, model.Produto{Nome: "Monitor", Preco: 900.00})
+	if err != nil {
+		t.Fatalf("Erro ao criar produto após upsert: %v", err)
+	}
+	if novo.ID <= 9999 {
+		t.Errorf("Esperado ID maior que 9999, obtido %d", novo.ID)
+	}
+}
+
+func testBulkWriteAtomic(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	created := seed(t, repo)
+
+	t.Run("falha desfaz as operações anteriores", func(t *testing.T) {
+		operations := []repository.BulkOperation{
+			{Type: repository.BulkCreate, Produto: model.Produto{Nome: "Webcam", Preco: 300.00}},
+			{Type: repository.BulkPatch, ID: created[0].ID, Updates: map[string]interface{}{"preco": 1.00}},
+			{Type: repository.BulkDelete, ID: 9999},
+			{Type: repository.BulkDelete, ID: created[1].ID},
+		}
+		results, err := repo.BulkWrite(ctx, operations, true)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if results[2].Err == nil || results[2].Err.Error() != "not found" {
+			t.Errorf("Esperado erro 'not found' na operação que falhou, obtido %v", results[2].Err)
+		}
+		for _, i := range []int{0, 1, 3} {
+			if results[i].Err != repository.ErrBulkAborted {
+				t.Errorf("Operação %d: esperado ErrBulkAborted, obtido %v", i, results[i].Err)
+			}
+		}
+
+		count, _ := repo.Count(ctx, activeFilter())
+		if count != int64(len(created)) {
+			t.Errorf("Nenhum produto deveria ser criado ou deletado, total %d", count)
+		}
+		produto, err := repo.FindByID(ctx, created[0].ID)
+		if err != nil || produto.Preco != created[0].Preco || produto.Version != created[0].Version {
+			t.Errorf("Patch deveria ser desfeito, obtido %+v (%v)", produto, err)
+		}
+	})
+
+	t.Run("sucesso aplica todas as operações", func(t *testing.T) {
+		operations := []repository.BulkOperation{
+			{Type: repository.BulkCreate, Produto: model.Produto{Nome: "Webcam", Preco: 300.00}},
+			{Type: repository.BulkDelete, ID: created[1].ID},
+		}
+		results, err := repo.BulkWrite(ctx, operations, true)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		for i, r := range results {
+			if r.Err != nil {
+				t.Errorf("Operação %d: erro inesperado %v", i, r.Err)
+			}
+		}
+		count, _ := repo.Count(ctx, activeFilter())
+		if count != int64(len(created)) {
+			t.Errorf("Esperado total %d (um criado e um deletado), obtido %d", len(created), count)
+		}
+	})
+}
+
+func testStream(t *testing.T, repo repository.ProdutoRepository) {
+	ctx := context.Background()
+	seed(t, repo)
+
+	filter := activeFilter()
+	filter["preco"] = bson.M{"$lte": 1500.00}
+	sort := bson.D{{Key: "preco", Value: -1}}
+
+	var streamed []model.Produto
+	err := repo.Stream(ctx, filter, sort, func(p model.Produto) error {
+		streamed = append(streamed, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	// Mesmo resultado e ordem de FindAllPaginated
+	expected, _ := repo.FindAllPaginated(ctx, 0, 0, filter, sort, nil)
+	if got, want := ids(streamed), ids(expected); !equalIDs(got, want) || len(got) != 4 {
+		t.Errorf("Ordem esperada %v, obtida %v", want, got)
+	}
+
+	t.Run("erro de fn interrompe a leitura", func(t *testing.T) {
+		stop := fmt.Errorf("parar")
+		calls := 0
+		err := repo.Stream(ctx, filter, sort, func(p model.Produto) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("Esperado o erro de fn após 1 chamada, obtido %v após %d", err, calls)
+		}
+	})
+}
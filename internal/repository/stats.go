@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"math"
+
+	"api-go-arquitetura/internal/model"
+)
+
+// newProdutoStats monta as estatísticas a partir dos valores agregados
+// percentiles segue a ordem de model.StatsPercentiles (p50, p90, p99)
+func newProdutoStats(count int64, sum, min, max float64, percentiles []float64) model.ProdutoStats {
+	stats := model.ProdutoStats{
+		Count: count,
+		Preco: model.PrecoStats{
+			Sum: sum,
+			Min: min,
+			Max: max,
+		},
+	}
+	if count > 0 {
+		stats.Preco.Avg = sum / float64(count)
+	}
+	targets := []*float64{&stats.Preco.P50, &stats.Preco.P90, &stats.Preco.P99}
+	for i := 0; i < len(targets) && i < len(percentiles); i++ {
+		*targets[i] = percentiles[i]
+	}
+	return stats
+}
+
+// percentileRank retorna a posição (a partir de 1) do percentil p entre count valores
+// ordenados, pelo método nearest-rank
+func percentileRank(p float64, count int64) int64 {
+	rank := int64(math.Ceil(p * float64(count)))
+	if rank < 1 {
+		rank = 1
+	}
+	return rank
+}
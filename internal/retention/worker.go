@@ -0,0 +1,149 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"api-go-arquitetura/internal/logger"
+	"api-go-arquitetura/internal/metrics"
+	"api-go-arquitetura/internal/repository"
+)
+
+// Options configura o job de retenção de produtos deletados
+type Options struct {
+	Retention  time.Duration // Tempo que um produto deletado é mantido antes de ser removido
+	Interval   time.Duration // Intervalo entre execuções
+	DryRun     bool          // Apenas contar o que seria removido, sem remover
+	RunTimeout time.Duration // Tempo máximo de cada execução
+}
+
+// Worker remove periodicamente produtos deletados (soft delete) há mais tempo que a retenção
+type Worker struct {
+	repo     repository.ProdutoRepository
+	opts     Options
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewWorker cria um novo job de retenção
+func NewWorker(repo repository.ProdutoRepository, opts Options) *Worker {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.RunTimeout <= 0 {
+		opts.RunTimeout = time.Minute
+	}
+	return &Worker{
+		repo:     repo,
+		opts:     opts,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start inicia o job em background; a primeira execução acontece imediatamente
+func (w *Worker) Start() {
+	logger.WithFields(map[string]interface{}{
+		"retention": w.opts.Retention.String(),
+		"interval":  w.opts.Interval.String(),
+		"dry_run":   w.opts.DryRun,
+	}).Info("Job de retenção de produtos deletados iniciado")
+
+	w.wg.Add(1)
+	go w.loop()
+}
+
+// Stop encerra o job, aguardando a execução em andamento terminar
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+	})
+	w.wg.Wait()
+	logger.Info("Job de retenção de produtos deletados encerrado")
+}
+
+// loop executa o job periodicamente até Stop ser chamado
+func (w *Worker) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.runWithTimeout()
+
+		select {
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runWithTimeout executa o job com timeout, cancelando-o se Stop for chamado no meio
+func (w *Worker) runWithTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.RunTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-w.stopChan:
+			cancel()
+		case <-done:
+		}
+	}()
+
+	// Erros já são registrados em RunOnce; o job tenta novamente no próximo intervalo
+	_, _ = w.RunOnce(ctx)
+}
+
+// RunOnce executa uma rodada do job e retorna a quantidade de produtos removidos
+// (ou que seriam removidos, em dry-run)
+func (w *Worker) RunOnce(ctx context.Context) (int64, error) {
+	start := time.Now()
+	cutoff := start.Add(-w.opts.Retention)
+
+	mode := "purge"
+	if w.opts.DryRun {
+		mode = "dry_run"
+	}
+
+	var (
+		count int64
+		err   error
+	)
+	if w.opts.DryRun {
+		count, err = w.repo.Count(ctx, map[string]interface{}{
+			"deleted_at": map[string]interface{}{"$lt": cutoff},
+		})
+	} else {
+		count, err = w.repo.PurgeDeletedBefore(ctx, cutoff)
+	}
+
+	fields := map[string]interface{}{
+		"mode":        mode,
+		"cutoff":      cutoff.Format(time.RFC3339),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		metrics.RecordRetentionRun(mode, "error", 0)
+		fields["error"] = err.Error()
+		logger.WithFields(fields).Error("Erro ao executar job de retenção")
+		return 0, err
+	}
+
+	metrics.RecordRetentionRun(mode, "success", count)
+	fields["count"] = count
+	if w.opts.DryRun {
+		logger.WithFields(fields).Info("Retenção (dry-run): produtos que seriam removidos")
+	} else if count > 0 {
+		logger.WithFields(fields).Info("Retenção: produtos deletados removidos definitivamente")
+	} else {
+		logger.WithFields(fields).Debug("Retenção: nenhum produto a remover")
+	}
+
+	return count, nil
+}
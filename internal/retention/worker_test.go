@@ -0,0 +1,108 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
+)
+
+// seedDeleted cria produtos e deleta os dois primeiros
+func seedDeleted(t *testing.T, repo repository.ProdutoRepository) []model.Produto {
+	t.Helper()
+	ctx := context.Background()
+	var created []model.Produto
+	for _, nome := range []string{"Notebook", "Mouse", "Teclado"} {
+		p, err := repo.Create(ctx, model.Produto{Nome: nome, Preco: 100})
+		if err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+		created = append(created, p)
+	}
+	for _, p := range created[:2] {
+		if err := repo.Delete(ctx, p.ID, repository.AnyVersion); err != nil {
+			t.Fatalf("Erro ao deletar produto: %v", err)
+		}
+	}
+	return created
+}
+
+func TestWorker_RunOnce(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("deve remover produtos deletados além da retenção", func(t *testing.T) {
+		repo := repository.NewMemoryProdutoRepository()
+		created := seedDeleted(t, repo)
+		time.Sleep(10 * time.Millisecond)
+
+		w := NewWorker(repo, Options{Retention: 5 * time.Millisecond})
+		purged, err := w.RunOnce(ctx)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if purged != 2 {
+			t.Errorf("Quantidade removida esperada 2, obtida %d", purged)
+		}
+		if _, err := repo.Restore(ctx, created[0].ID); err == nil {
+			t.Error("Produto removido não deveria ser restaurável")
+		}
+		if _, err := repo.FindByID(ctx, created[2].ID); err != nil {
+			t.Errorf("Produto ativo não deveria ser removido: %v", err)
+		}
+	})
+
+	t.Run("deve manter produtos dentro da retenção", func(t *testing.T) {
+		repo := repository.NewMemoryProdutoRepository()
+		seedDeleted(t, repo)
+
+		w := NewWorker(repo, Options{Retention: time.Hour})
+		purged, err := w.RunOnce(ctx)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if purged != 0 {
+			t.Errorf("Nenhum produto deveria ser removido, removidos %d", purged)
+		}
+	})
+
+	t.Run("dry-run deve apenas contar", func(t *testing.T) {
+		repo := repository.NewMemoryProdutoRepository()
+		created := seedDeleted(t, repo)
+		time.Sleep(10 * time.Millisecond)
+
+		w := NewWorker(repo, Options{Retention: 5 * time.Millisecond, DryRun: true})
+		count, err := w.RunOnce(ctx)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("Quantidade esperada 2, obtida %d", count)
+		}
+		if _, err := repo.Restore(ctx, created[0].ID); err != nil {
+			t.Errorf("Dry-run não deveria remover produtos: %v", err)
+		}
+	})
+}
+
+func TestWorker_StartStop(t *testing.T) {
+	repo := repository.NewMemoryProdutoRepository()
+	seedDeleted(t, repo)
+
+	w := NewWorker(repo, Options{Retention: 0, Interval: time.Hour})
+	w.Start()
+
+	done := make(chan struct{})
+	go func() {
+		w.Stop()
+		w.Stop() // Deve ser seguro chamar mais de uma vez
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop não encerrou o job a tempo")
+	}
+}
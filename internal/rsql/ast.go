@@ -0,0 +1,151 @@
+// Package rsql implementa uma linguagem de consulta no estilo RSQL/FIQL para filtrar
+// produtos, por exemplo:
+//
+//	preco=gt=100;nome=like=note*,descricao==x
+//
+// A expressão é interpretada em uma árvore sintática (Parse), validada contra os
+// campos permitidos e seus tipos e convertida para um filtro MongoDB (Compile).
+//
+// Gramática:
+//
+//	or         = and { "," and }
+//	and        = constraint { ";" constraint }
+//	constraint = "(" or ")" | comparison
+//	comparison = selector operator arguments
+//	selector   = letra { letra | dígito | "_" | "." }
+//	operator   = "==" | "!=" | "=" nome "="
+//	arguments  = "(" value { "," value } ")" | value
+//	value      = texto sem caracteres reservados | texto entre aspas simples ou duplas
+//
+// ";" (E) tem precedência sobre "," (OU). Os caracteres reservados são
+// ' " ( ) ; , = ! ~ < > e espaços; para usá-los em um valor, coloque-o entre aspas
+// (\ escapa o caractere seguinte dentro das aspas).
+package rsql
+
+import "fmt"
+
+// Operadores de comparação
+const (
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+	OpGreater      = "=gt="
+	OpGreaterEqual = "=ge="
+	OpLess         = "=lt="
+	OpLessEqual    = "=le="
+	OpIn           = "=in="
+	OpOut          = "=out="
+	OpLike         = "=like=" // Valor com curinga * (ex: note*), sem diferenciar maiúsculas
+)
+
+// operators são os operadores de comparação reconhecidos
+var operators = map[string]bool{
+	OpEqual:        true,
+	OpNotEqual:     true,
+	OpGreater:      true,
+	OpGreaterEqual: true,
+	OpLess:         true,
+	OpLessEqual:    true,
+	OpIn:           true,
+	OpOut:          true,
+	OpLike:         true,
+}
+
+// LogicalOperator é o operador que combina expressões
+type LogicalOperator string
+
+// Operadores lógicos
+const (
+	And LogicalOperator = ";"
+	Or  LogicalOperator = ","
+)
+
+// Node é um nó da árvore sintática
+type Node interface {
+	// Position retorna a posição (a partir de 0) do nó na expressão
+	Position() int
+	String() string
+}
+
+// Logical combina duas ou mais expressões com E (;) ou OU (,)
+type Logical struct {
+	Operator LogicalOperator
+	Children []Node
+	Pos      int
+}
+
+// Position retorna a posição do nó na expressão
+func (l *Logical) Position() int { return l.Pos }
+
+// String retorna a expressão em forma canônica, com parênteses explícitos
+func (l *Logical) String() string {
+	s := "("
+	for i, child := range l.Children {
+		if i > 0 {
+			s += string(l.Operator)
+		}
+		s += child.String()
+	}
+	return s + ")"
+}
+
+// Argument é um valor de uma comparação
+type Argument struct {
+	Value string
+	Pos   int
+}
+
+// Comparison compara um campo com um ou mais valores
+type Comparison struct {
+	Field    string
+	Operator string
+	Args     []Argument
+	Pos      int // Posição do campo
+	OpPos    int // Posição do operador
+}
+
+// Position retorna a posição do nó na expressão
+func (c *Comparison) Position() int { return c.Pos }
+
+// String retorna a comparação em forma canônica (valores sempre entre aspas)
+func (c *Comparison) String() string {
+	s := c.Field + c.Operator
+	if len(c.Args) == 1 && c.Operator != OpIn && c.Operator != OpOut {
+		return s + quote(c.Args[0].Value)
+	}
+	s += "("
+	for i, arg := range c.Args {
+		if i > 0 {
+			s += ","
+		}
+		s += quote(arg.Value)
+	}
+	return s + ")"
+}
+
+// quote coloca o valor entre aspas duplas, escapando aspas e barras invertidas
+func quote(value string) string {
+	s := `"`
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' || value[i] == '\\' {
+			s += `\`
+		}
+		s += value[i : i+1]
+	}
+	return s + `"`
+}
+
+// Error é um erro de sintaxe ou de validação da expressão
+type Error struct {
+	Pos int // Posição (a partir de 0) na expressão
+	Msg string
+}
+
+// Error implementa a interface error
+func (e *Error) Error() string {
+	return fmt.Sprintf("posição %d: %s", e.Pos, e.Msg)
+}
+
+// errorf cria um Error na posição informada
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,188 @@
+package rsql
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxLikeWildcards é o número máximo de curingas (*) de um valor =like=; cada curinga
+// é um .* na expressão regular, e muitos deles tornam a busca cara (backtracking)
+const MaxLikeWildcards = 3
+
+// FieldType é o tipo de um campo filtrável, usado para converter e validar os valores
+type FieldType int
+
+// Tipos de campo
+const (
+	String  FieldType = iota // Texto
+	Number                   // Número decimal (ex: preco)
+	Integer                  // Número inteiro (ex: id)
+	Time                     // Data RFC3339
+)
+
+// String retorna o nome do tipo usado nas mensagens de erro
+func (t FieldType) String() string {
+	switch t {
+	case Number:
+		return "número"
+	case Integer:
+		return "inteiro"
+	case Time:
+		return "data RFC3339"
+	}
+	return "texto"
+}
+
+// Schema define os campos permitidos na expressão e seus tipos
+// O nome do campo na expressão é o mesmo do documento no MongoDB
+type Schema map[string]FieldType
+
+// fieldNames retorna os campos permitidos em ordem alfabética
+func (s Schema) fieldNames() string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Compile valida a árvore contra o schema e a converte para um filtro MongoDB
+// Os erros são do tipo *Error, com a posição do campo, operador ou valor inválido
+func Compile(node Node, schema Schema) (map[string]interface{}, error) {
+	switch n := node.(type) {
+	case *Logical:
+		conditions := make([]interface{}, 0, len(n.Children))
+		for _, child := range n.Children {
+			condition, err := Compile(child, schema)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+		}
+		op := "$and"
+		if n.Operator == Or {
+			op = "$or"
+		}
+		return map[string]interface{}{op: conditions}, nil
+	case *Comparison:
+		return compileComparison(n, schema)
+	}
+	return nil, errorf(0, "nó desconhecido na expressão")
+}
+
+// compileComparison converte uma comparação para a condição MongoDB do campo
+func compileComparison(c *Comparison, schema Schema) (map[string]interface{}, error) {
+	fieldType, ok := schema[c.Field]
+	if !ok {
+		return nil, errorf(c.Pos, "campo não permitido: %s. Campos permitidos: %s", c.Field, schema.fieldNames())
+	}
+
+	// Aridade e tipos aceitos por operador
+	multi := c.Operator == OpIn || c.Operator == OpOut
+	if !multi && len(c.Args) != 1 {
+		return nil, errorf(c.OpPos, "o operador %s aceita apenas um valor", c.Operator)
+	}
+	switch c.Operator {
+	case OpLike:
+		if fieldType != String {
+			return nil, errorf(c.OpPos, "o operador %s só se aplica a campos de texto (%s é %s)", c.Operator, c.Field, fieldType)
+		}
+		if n := strings.Count(collapseWildcards(c.Args[0].Value), "*"); n > MaxLikeWildcards {
+			return nil, errorf(c.Args[0].Pos, "o operador %s aceita no máximo %d curingas (*), obtidos %d", c.Operator, MaxLikeWildcards, n)
+		}
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+		if fieldType == String {
+			return nil, errorf(c.OpPos, "o operador %s não se aplica ao campo de texto %s", c.Operator, c.Field)
+		}
+	}
+
+	values := make([]interface{}, 0, len(c.Args))
+	for _, arg := range c.Args {
+		if c.Operator == OpLike {
+			values = append(values, arg.Value)
+			continue
+		}
+		value, err := convertValue(arg, c.Field, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	var condition interface{}
+	switch c.Operator {
+	case OpEqual:
+		condition = values[0]
+	case OpNotEqual:
+		condition = map[string]interface{}{"$ne": values[0]}
+	case OpGreater:
+		condition = map[string]interface{}{"$gt": values[0]}
+	case OpGreaterEqual:
+		condition = map[string]interface{}{"$gte": values[0]}
+	case OpLess:
+		condition = map[string]interface{}{"$lt": values[0]}
+	case OpLessEqual:
+		condition = map[string]interface{}{"$lte": values[0]}
+	case OpIn:
+		condition = map[string]interface{}{"$in": values}
+	case OpOut:
+		condition = map[string]interface{}{"$nin": values}
+	case OpLike:
+		condition = map[string]interface{}{
+			"$regex":   likePattern(values[0].(string)),
+			"$options": "i", // Case insensitive
+		}
+	default:
+		return nil, errorf(c.OpPos, "operador desconhecido: %s", c.Operator)
+	}
+	return map[string]interface{}{c.Field: condition}, nil
+}
+
+// convertValue converte o valor para o tipo do campo
+func convertValue(arg Argument, field string, fieldType FieldType) (interface{}, error) {
+	switch fieldType {
+	case Number:
+		v, err := strconv.ParseFloat(arg.Value, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errorf(arg.Pos, "valor inválido para o campo %s (%s): %s", field, fieldType, arg.Value)
+		}
+		return v, nil
+	case Integer:
+		v, err := strconv.Atoi(arg.Value)
+		if err != nil {
+			return nil, errorf(arg.Pos, "valor inválido para o campo %s (%s): %s", field, fieldType, arg.Value)
+		}
+		return v, nil
+	case Time:
+		v, err := time.Parse(time.RFC3339, arg.Value)
+		if err != nil {
+			return nil, errorf(arg.Pos, "valor inválido para o campo %s (%s): %s", field, fieldType, arg.Value)
+		}
+		return v, nil
+	}
+	return arg.Value, nil
+}
+
+// likePattern converte um valor com curingas (*) em uma expressão regular ancorada
+// O restante do valor é escapado e nunca interpretado como expressão
+// Curingas consecutivos equivalem a um só (evita .*.* e o backtracking que ele causa)
+func likePattern(value string) string {
+	parts := strings.Split(collapseWildcards(value), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+// collapseWildcards substitui sequências de curingas (**) por um único curinga
+func collapseWildcards(value string) string {
+	for strings.Contains(value, "**") {
+		value = strings.ReplaceAll(value, "**", "*")
+	}
+	return value
+}
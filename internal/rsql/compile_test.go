@@ -0,0 +1,101 @@
+package rsql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSchema reproduz os tipos de campo dos produtos
+var testSchema = Schema{
+	"id":         Integer,
+	"nome":       String,
+	"preco":      Number,
+	"created_at": Time,
+}
+
+func TestCompile(t *testing.T) {
+	date := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]interface{}
+	}{
+		{"igualdade de texto", "nome==Mouse", map[string]interface{}{"nome": "Mouse"}},
+		{"número convertido", "preco=gt=100", map[string]interface{}{"preco": map[string]interface{}{"$gt": 100.0}}},
+		{"inteiro convertido", "id!=3", map[string]interface{}{"id": map[string]interface{}{"$ne": 3}}},
+		{"data convertida", "created_at=le=2024-01-15T10:30:00Z", map[string]interface{}{"created_at": map[string]interface{}{"$lte": date}}},
+		{"lista", "id=in=(1,2)", map[string]interface{}{"id": map[string]interface{}{"$in": []interface{}{1, 2}}}},
+		{"lista negada", "id=out=(1)", map[string]interface{}{"id": map[string]interface{}{"$nin": []interface{}{1}}}},
+		{"like escapa o valor", "nome=like=a.b*", map[string]interface{}{"nome": map[string]interface{}{"$regex": `^a\.b.*$`, "$options": "i"}}},
+		{"like sem curinga é igualdade sem maiúsculas", "nome=like=mouse", map[string]interface{}{"nome": map[string]interface{}{"$regex": "^mouse$", "$options": "i"}}},
+		{"like agrupa curingas consecutivos", "nome=like=**a***b*", map[string]interface{}{"nome": map[string]interface{}{"$regex": "^.*a.*b.*$", "$options": "i"}}},
+		{"E e OU", "preco=ge=1;(nome==a,nome==b)", map[string]interface{}{"$and": []interface{}{
+			map[string]interface{}{"preco": map[string]interface{}{"$gte": 1.0}},
+			map[string]interface{}{"$or": []interface{}{
+				map[string]interface{}{"nome": "a"},
+				map[string]interface{}{"nome": "b"},
+			}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Erro de sintaxe inesperado: %v", err)
+			}
+			got, err := Compile(node, testSchema)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Esperado %#v, obtido %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+		msg   string
+	}{
+		{"campo fora da lista", "deleted_at==x", 0, "campo não permitido"},
+		{"campo fora da lista em expressão composta", "nome==a;senha==b", 8, "campo não permitido"},
+		{"número inválido", "preco=gt=abc", 9, "número"},
+		{"número infinito", "preco=lt=Inf", 9, "número"},
+		{"inteiro inválido", "id==1.5", 4, "inteiro"},
+		{"data inválida", "created_at=ge=ontem", 14, "data"},
+		{"valor inválido na lista", "id=in=(1,x)", 9, "inteiro"},
+		{"comparação em texto", "nome=gt=a", 4, "não se aplica"},
+		{"like em número", "preco=like=1*", 5, "texto"},
+		{"like com curingas demais", "nome=like=*a*b*c*", 10, "no máximo 3 curingas"},
+		{"vários valores sem =in=", "nome==(a,b)", 4, "apenas um valor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Erro de sintaxe inesperado: %v", err)
+			}
+			_, err = Compile(node, testSchema)
+			var compileErr *Error
+			if !errors.As(err, &compileErr) {
+				t.Fatalf("Esperado *Error, obtido %v", err)
+			}
+			if compileErr.Pos != tt.pos {
+				t.Errorf("Posição esperada %d, obtida %d (%v)", tt.pos, compileErr.Pos, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Mensagem deveria conter %q: %v", tt.msg, err)
+			}
+		})
+	}
+}
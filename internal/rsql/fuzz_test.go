@@ -0,0 +1,60 @@
+package rsql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// FuzzParse verifica que qualquer entrada é aceita ou rejeitada com *Error dentro dos
+// limites da expressão, e que a forma canônica é estável (Parse(String()) == String())
+// Execute com: go test ./internal/rsql -fuzz=FuzzParse
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"preco=gt=100;nome=like=note*,descricao==x",
+		"(nome==a,nome==b);id=in=(1,2,3)",
+		`descricao=="sem fio; (usb)"`,
+		"nome=='a\\'b'",
+		"created_at=ge=2024-01-15T10:30:00Z",
+		"((((a==b))))",
+		"a==",
+		"=gt=",
+		"(",
+		`"`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		node, err := Parse(input)
+		if err != nil {
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Erro de tipo inesperado: %T %v", err, err)
+			}
+			if parseErr.Pos < 0 || parseErr.Pos > len(input) {
+				t.Fatalf("Posição %d fora da expressão de %d caracteres", parseErr.Pos, len(input))
+			}
+			return
+		}
+
+		canonical := node.String()
+		reparsed, err := Parse(canonical)
+		if err != nil {
+			// A forma canônica pode exceder os limites por causa das aspas e dos
+			// parênteses explícitos de cada nó lógico
+			var parseErr *Error
+			if len(canonical) > MaxLength || (errors.As(err, &parseErr) && strings.Contains(parseErr.Msg, "aninhamento")) {
+				return
+			}
+			t.Fatalf("Forma canônica %q inválida: %v", canonical, err)
+		}
+		if got := reparsed.String(); got != canonical {
+			t.Fatalf("Forma canônica instável: %q -> %q", canonical, got)
+		}
+
+		// Compile nunca deve causar pânico, com ou sem campos permitidos
+		Compile(node, testSchema)
+	})
+}
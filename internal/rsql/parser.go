@@ -0,0 +1,264 @@
+package rsql
+
+import "strings"
+
+// MaxLength limita o tamanho da expressão
+const MaxLength = 1000
+
+// MaxDepth limita o aninhamento de parênteses
+const MaxDepth = 10
+
+// reserved são os caracteres que não podem aparecer em valores sem aspas
+const reserved = `"'();,=!~<> ` + "\t\r\n"
+
+// parser interpreta uma expressão por descida recursiva
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+// Parse interpreta a expressão e retorna a árvore sintática
+// Os erros são do tipo *Error, com a posição do problema
+func Parse(input string) (Node, error) {
+	if len(input) > MaxLength {
+		return nil, errorf(MaxLength, "expressão muito longa (máximo de %d caracteres)", MaxLength)
+	}
+
+	p := &parser{input: input}
+	p.skipSpaces()
+	if p.eof() {
+		return nil, errorf(p.pos, "expressão vazia")
+	}
+
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.eof() {
+		return nil, errorf(p.pos, "caractere inesperado %q", p.input[p.pos])
+	}
+	return node, nil
+}
+
+// parseOr interpreta expressões separadas por "," (OU)
+func (p *parser) parseOr() (Node, error) {
+	return p.parseLogical(Or, p.parseAnd)
+}
+
+// parseAnd interpreta expressões separadas por ";" (E)
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseLogical(And, p.parseConstraint)
+}
+
+// parseLogical interpreta uma ou mais expressões separadas pelo operador
+// Uma única expressão é retornada sem o nó lógico
+func (p *parser) parseLogical(op LogicalOperator, next func() (Node, error)) (Node, error) {
+	p.skipSpaces()
+	start := p.pos
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	children := []Node{first}
+	for {
+		p.skipSpaces()
+		if p.peek() != op[0] {
+			break
+		}
+		p.pos++
+		child, err := next()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &Logical{Operator: op, Children: children, Pos: start}, nil
+}
+
+// parseConstraint interpreta um grupo entre parênteses ou uma comparação
+func (p *parser) parseConstraint() (Node, error) {
+	p.skipSpaces()
+	if p.peek() != '(' {
+		return p.parseComparison()
+	}
+
+	open := p.pos
+	p.depth++
+	if p.depth > MaxDepth {
+		return nil, errorf(open, "aninhamento de parênteses muito profundo (máximo de %d níveis)", MaxDepth)
+	}
+	p.pos++
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.peek() != ')' {
+		return nil, errorf(p.pos, "esperado ')' para fechar o parêntese da posição %d", open)
+	}
+	p.pos++
+	p.depth--
+	return node, nil
+}
+
+// parseComparison interpreta campo, operador e valores
+func (p *parser) parseComparison() (Node, error) {
+	p.skipSpaces()
+	start := p.pos
+	field := p.parseSelector()
+	if field == "" {
+		if p.eof() {
+			return nil, errorf(p.pos, "esperado nome de campo, mas a expressão terminou")
+		}
+		return nil, errorf(p.pos, "esperado nome de campo, encontrado %q", p.input[p.pos])
+	}
+
+	p.skipSpaces()
+	opPos := p.pos
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseArguments()
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Field: field, Operator: op, Args: args, Pos: start, OpPos: opPos}, nil
+}
+
+// parseSelector lê o nome do campo: letra seguida de letras, dígitos, "_" ou "."
+func (p *parser) parseSelector() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.input[p.pos]
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		if !isLetter && (p.pos == start || !(c >= '0' && c <= '9' || c == '.')) {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// parseOperator lê o operador de comparação ("==", "!=" ou "=nome=")
+func (p *parser) parseOperator() (string, error) {
+	start := p.pos
+	switch {
+	case strings.HasPrefix(p.input[p.pos:], "=="), strings.HasPrefix(p.input[p.pos:], "!="):
+		p.pos += 2
+		return p.input[start:p.pos], nil
+	case p.peek() == '=':
+		p.pos++
+		for !p.eof() && p.input[p.pos] >= 'a' && p.input[p.pos] <= 'z' {
+			p.pos++
+		}
+		if p.peek() != '=' {
+			return "", errorf(start, "operador incompleto: esperado '=' após %q", p.input[start:p.pos])
+		}
+		p.pos++
+		op := p.input[start:p.pos]
+		if !operators[op] {
+			return "", errorf(start, "operador desconhecido: %s", op)
+		}
+		return op, nil
+	}
+	if p.eof() {
+		return "", errorf(p.pos, "esperado operador, mas a expressão terminou")
+	}
+	return "", errorf(p.pos, "esperado operador (==, !=, =gt=, =ge=, =lt=, =le=, =in=, =out=, =like=), encontrado %q", p.input[p.pos])
+}
+
+// parseArguments lê um valor ou uma lista de valores entre parênteses
+func (p *parser) parseArguments() ([]Argument, error) {
+	p.skipSpaces()
+	if p.peek() != '(' {
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return []Argument{arg}, nil
+	}
+
+	open := p.pos
+	p.pos++
+	var args []Argument
+	for {
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, errorf(p.pos, "esperado ',' ou ')' na lista de valores da posição %d", open)
+		}
+	}
+}
+
+// parseValue lê um valor sem aspas ou entre aspas simples ou duplas
+func (p *parser) parseValue() (Argument, error) {
+	p.skipSpaces()
+	start := p.pos
+	if quote := p.peek(); quote == '"' || quote == '\'' {
+		p.pos++
+		var value strings.Builder
+		for !p.eof() {
+			c := p.input[p.pos]
+			switch {
+			case c == '\\' && p.pos+1 < len(p.input):
+				value.WriteByte(p.input[p.pos+1])
+				p.pos += 2
+			case c == quote:
+				p.pos++
+				return Argument{Value: value.String(), Pos: start}, nil
+			default:
+				value.WriteByte(c)
+				p.pos++
+			}
+		}
+		return Argument{}, errorf(start, "texto entre aspas não terminado")
+	}
+
+	for !p.eof() && !strings.ContainsRune(reserved, rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		if p.eof() {
+			return Argument{}, errorf(p.pos, "esperado valor, mas a expressão terminou")
+		}
+		return Argument{}, errorf(p.pos, "esperado valor, encontrado %q", p.input[p.pos])
+	}
+	return Argument{Value: p.input[start:p.pos], Pos: start}, nil
+}
+
+// skipSpaces ignora espaços entre os elementos da expressão
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\r' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// peek retorna o caractere atual (0 no fim da expressão)
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// eof indica se a expressão terminou
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
@@ -0,0 +1,91 @@
+package rsql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // Forma canônica (Node.String)
+	}{
+		{"igualdade", "nome==Mouse", `nome=="Mouse"`},
+		{"diferença", "nome!=Mouse", `nome!="Mouse"`},
+		{"operadores FIQL", "preco=gt=100", `preco=gt="100"`},
+		{"like com curinga", "nome=like=note*", `nome=like="note*"`},
+		{"lista de valores", "id=in=(1,2, 3)", `id=in=("1","2","3")`},
+		{"lista com um valor", "id=out=(1)", `id=out=("1")`},
+		{"E", "preco=ge=10;preco=le=20", `(preco=ge="10";preco=le="20")`},
+		{"OU", "nome==a,nome==b", `(nome=="a",nome=="b")`},
+		{"E tem precedência sobre OU", "preco=gt=100;nome=like=note*,descricao==x", `((preco=gt="100";nome=like="note*"),descricao=="x")`},
+		{"parênteses alteram a precedência", "preco=gt=100;(nome=like=note*,descricao==x)", `(preco=gt="100";(nome=like="note*",descricao=="x"))`},
+		{"parênteses redundantes", "((nome==a))", `nome=="a"`},
+		{"aspas duplas com reservados", `descricao=="sem fio; (usb)"`, `descricao=="sem fio; (usb)"`},
+		{"aspas simples", "nome=='Mouse Gamer'", `nome=="Mouse Gamer"`},
+		{"escape dentro das aspas", `nome=="a\"b\\c"`, `nome=="a\"b\\c"`},
+		{"valor vazio entre aspas", `nome==""`, `nome==""`},
+		{"espaços entre elementos", " nome == a ; preco =gt= 1 ", `(nome=="a";preco=gt="1")`},
+		{"campo com ponto e dígitos", "a.b_2==x", `a.b_2=="x"`},
+		{"valor com acentos", "descricao==vídeo", `descricao=="vídeo"`},
+		{"data RFC3339", "created_at=ge=2024-01-15T10:30:00Z", `created_at=ge="2024-01-15T10:30:00Z"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if got := node.String(); got != tt.want {
+				t.Errorf("Esperado %s, obtido %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+		msg   string // Trecho esperado na mensagem
+	}{
+		{"expressão vazia", "", 0, "vazia"},
+		{"apenas espaços", "   ", 3, "vazia"},
+		{"sem operador", "nome", 4, "esperado operador"},
+		{"operador desconhecido", "preco=foo=1", 5, "desconhecido"},
+		{"operador incompleto", "preco=gt1", 5, "incompleto"},
+		{"sem valor", "nome==", 6, "esperado valor"},
+		{"valor reservado", "nome==;", 6, "esperado valor"},
+		{"campo ausente", "==a", 0, "esperado nome de campo"},
+		{"campo começando com dígito", "1a==b", 0, "esperado nome de campo"},
+		{"separador sem expressão", "nome==a;", 8, "esperado nome de campo"},
+		{"parêntese não fechado", "(nome==a", 8, "posição 0"},
+		{"parêntese extra", "nome==a)", 7, "inesperado"},
+		{"aspas não terminadas", `nome=="abc`, 6, "não terminado"},
+		{"lista não fechada", "id=in=(1,2", 10, "esperado ',' ou ')'"},
+		{"lista vazia", "id=in=()", 7, "esperado valor"},
+		{"valores sem separador", "nome==a b", 8, "inesperado"},
+		{"aninhamento profundo", strings.Repeat("(", MaxDepth+1) + "a==b" + strings.Repeat(")", MaxDepth+1), MaxDepth, "aninhamento"},
+		{"expressão muito longa", "nome==" + strings.Repeat("a", MaxLength), MaxLength, "muito longa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Esperado *Error, obtido %v", err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("Posição esperada %d, obtida %d (%v)", tt.pos, parseErr.Pos, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("Mensagem deveria conter %q: %v", tt.msg, err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"api-go-arquitetura/internal/dto"
 	"api-go-arquitetura/internal/model"
+	"api-go-arquitetura/internal/repository"
 )
 
 // ProdutoService define a interface para operações de produto
@@ -12,10 +13,32 @@ type ProdutoService interface {
 	Create(ctx context.Context, produto model.Produto) (model.Produto, error)
 	FindAll(ctx context.Context) ([]model.Produto, error)
 	FindByID(ctx context.Context, id int) (model.Produto, error)
-	Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error)
-	Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error)
-	Delete(ctx context.Context, id int) error
+	// FindCurrent retorna o produto direto do repositório, sem o cache, para a
+	// verificação de pré-condições (If-Match) de uma alteração
+	FindCurrent(ctx context.Context, id int) (model.Produto, error)
+	// expectedVersion condiciona a alteração à versão atual do produto
+	// (repository.AnyVersion = sem condição)
+	Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error)
+	Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error)
+	Delete(ctx context.Context, id int, expectedVersion int) error
+	// BulkWrite executa um lote de operações (ver repository.ProdutoRepository.BulkWrite)
+	// e retorna o resultado de cada uma, com erros mapeados para *errors.APIError
+	BulkWrite(ctx context.Context, operations []repository.BulkOperation, atomic bool) ([]repository.BulkResult, error)
+	// DryRunBulkWrite valida as operações e verifica os produtos alterados, sem gravar,
+	// retornando os resultados que BulkWrite teria (sem o produto resultante)
+	DryRunBulkWrite(ctx context.Context, operations []repository.BulkOperation) ([]repository.BulkResult, error)
 	// Novos métodos para paginação e filtros
-	FindAllPaginated(ctx context.Context, pagination dto.PaginationRequest, filter dto.FilterRequest, sort dto.SortRequest) ([]model.Produto, dto.PaginationResponse, error)
+	FindAllPaginated(ctx context.Context, pagination dto.PaginationRequest, filter dto.FilterRequest, sort dto.SortRequest, fields dto.FieldsRequest) ([]model.Produto, dto.PaginationResponse, error)
+	// Export percorre todos os produtos filtrados e ordenados, sem paginação, chamando fn para cada um
+	Export(ctx context.Context, filter dto.FilterRequest, sort dto.SortRequest, fn func(model.Produto) error) error
+	// Search executa a busca textual ordenada por relevância, com os mesmos filtros e paginação da listagem
+	Search(ctx context.Context, search dto.SearchRequest, pagination dto.PaginationRequest, filter dto.FilterRequest) ([]model.SearchResult, dto.PaginationResponse, error)
+	// Facets calcula as facetas solicitadas sobre o resultado da listagem (search nil) ou da busca textual
+	Facets(ctx context.Context, facets dto.FacetRequest, filter dto.FilterRequest, search *dto.SearchRequest) (*model.Facets, error)
+	// Stats calcula as estatísticas (contagem e preço) dos produtos filtrados
+	Stats(ctx context.Context, filter dto.FilterRequest) (model.ProdutoStats, error)
+	// Métodos para recuperar ou remover definitivamente produtos deletados
+	Restore(ctx context.Context, id int) (model.Produto, error)
+	Purge(ctx context.Context, id int) error
 }
 
@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
+	"strconv"
 	"time"
 
+	"api-go-arquitetura/internal/auth"
 	"api-go-arquitetura/internal/cache"
 	"api-go-arquitetura/internal/dto"
 	"api-go-arquitetura/internal/errors"
@@ -11,6 +14,8 @@ import (
 	"api-go-arquitetura/internal/metrics"
 	"api-go-arquitetura/internal/model"
 	"api-go-arquitetura/internal/repository"
+	"api-go-arquitetura/internal/rsql"
+	"api-go-arquitetura/internal/validator"
 )
 
 // produtoService implementa a lógica de negócio para produtos
@@ -61,6 +66,7 @@ func (s *produtoService) Create(ctx context.Context, produto model.Produto) (mod
 		// Por enquanto, o cache será invalidado naturalmente pelo TTL
 		logger.Debug("Cache de listas será invalidado pelo TTL após criação de produto")
 	}
+	s.invalidateAggregatesCache(ctx)
 
 	return result, nil
 }
@@ -131,8 +137,25 @@ func (s *produtoService) FindByID(ctx context.Context, id int) (model.Produto, e
 	return result, nil
 }
 
+// FindCurrent retorna o produto direto do repositório, ignorando o cache: uma
+// entrada desatualizada faria a verificação de If-Match falhar indevidamente ou
+// aceitar uma representação que não é mais a atual
+func (s *produtoService) FindCurrent(ctx context.Context, id int) (model.Produto, error) {
+	if id <= 0 {
+		return model.Produto{}, errors.ErrInvalidID
+	}
+	result, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if err.Error() == "not found" {
+			return model.Produto{}, errors.ErrProdutoNotFound
+		}
+		return model.Produto{}, errors.WrapError(err, errors.ErrDatabase)
+	}
+	return result, nil
+}
+
 // Update atualiza um produto completamente
-func (s *produtoService) Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error) {
+func (s *produtoService) Update(ctx context.Context, id int, produto model.Produto, expectedVersion int) (model.Produto, error) {
 	if id <= 0 {
 		return model.Produto{}, errors.ErrInvalidID
 	}
@@ -143,12 +166,9 @@ func (s *produtoService) Update(ctx context.Context, id int, produto model.Produ
 		return model.Produto{}, errors.ErrPrecoInvalido
 	}
 
-	result, err := s.repo.Update(ctx, id, produto)
+	result, err := s.repo.Update(ctx, id, produto, expectedVersion)
 	if err != nil {
-		if err.Error() == "not found" {
-			return model.Produto{}, errors.ErrProdutoNotFound
-		}
-		return model.Produto{}, errors.WrapError(err, errors.ErrDatabase)
+		return model.Produto{}, mapWriteError(err, expectedVersion)
 	}
 
 	// Invalidar cache do produto atualizado
@@ -164,12 +184,13 @@ func (s *produtoService) Update(ctx context.Context, id int, produto model.Produ
 		// Invalidar cache de listas também
 		logger.Debug("Cache invalidado após atualização de produto")
 	}
+	s.invalidateAggregatesCache(ctx)
 
 	return result, nil
 }
 
 // Patch atualiza um produto parcialmente
-func (s *produtoService) Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error) {
+func (s *produtoService) Patch(ctx context.Context, id int, updates map[string]interface{}, expectedVersion int) (model.Produto, error) {
 	if id <= 0 {
 		return model.Produto{}, errors.ErrInvalidID
 	}
@@ -182,12 +203,9 @@ func (s *produtoService) Patch(ctx context.Context, id int, updates map[string]i
 		return model.Produto{}, errors.ErrPrecoInvalido
 	}
 
-	result, err := s.repo.Patch(ctx, id, updates)
+	result, err := s.repo.Patch(ctx, id, updates, expectedVersion)
 	if err != nil {
-		if err.Error() == "not found" {
-			return model.Produto{}, errors.ErrProdutoNotFound
-		}
-		return model.Produto{}, errors.WrapError(err, errors.ErrDatabase)
+		return model.Produto{}, mapWriteError(err, expectedVersion)
 	}
 
 	// Invalidar cache do produto atualizado
@@ -203,22 +221,20 @@ func (s *produtoService) Patch(ctx context.Context, id int, updates map[string]i
 		// Invalidar cache de listas também
 		logger.Debug("Cache invalidado após patch de produto")
 	}
+	s.invalidateAggregatesCache(ctx)
 
 	return result, nil
 }
 
 // Delete remove um produto
-func (s *produtoService) Delete(ctx context.Context, id int) error {
+func (s *produtoService) Delete(ctx context.Context, id int, expectedVersion int) error {
 	if id <= 0 {
 		return errors.ErrInvalidID
 	}
 
-	err := s.repo.Delete(ctx, id)
+	err := s.repo.Delete(ctx, id, expectedVersion)
 	if err != nil {
-		if err.Error() == "not found" {
-			return errors.ErrProdutoNotFound
-		}
-		return errors.WrapError(err, errors.ErrDatabase)
+		return mapWriteError(err, expectedVersion)
 	}
 
 	// Invalidar cache do produto deletado
@@ -234,12 +250,281 @@ func (s *produtoService) Delete(ctx context.Context, id int) error {
 		// Invalidar cache de listas também
 		logger.Debug("Cache invalidado após deleção de produto")
 	}
+	s.invalidateAggregatesCache(ctx)
+
+	return nil
+}
+
+// Restore restaura um produto deletado (soft delete)
+func (s *produtoService) Restore(ctx context.Context, id int) (model.Produto, error) {
+	if id <= 0 {
+		return model.Produto{}, errors.ErrInvalidID
+	}
+
+	result, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		if err.Error() == "not found" {
+			return model.Produto{}, errors.ErrProdutoNotFound.WithDetails("Nenhum produto deletado com este ID")
+		}
+		return model.Produto{}, errors.WrapError(err, errors.ErrDatabase)
+	}
+
+	s.invalidateProdutoCache(ctx, id)
+	s.invalidateAggregatesCache(ctx)
+	logger.WithField("id", id).Info("Produto restaurado")
+
+	return result, nil
+}
+
+// BulkWrite executa um lote de operações, validando cada uma como as rotas individuais
+// (regras dos DTOs e de negócio). Operações inválidas não são executadas; em um lote
+// atômico, elas impedem a execução de todo o lote. Os erros dos resultados são sempre
+// *errors.APIError
+func (s *produtoService) BulkWrite(ctx context.Context, operations []repository.BulkOperation, atomic bool) ([]repository.BulkResult, error) {
+	if len(operations) == 0 || len(operations) > dto.MaxBatchSize {
+		return nil, errors.ErrInvalidInput.WithDetailsf("O lote deve conter entre 1 e %d operações", dto.MaxBatchSize)
+	}
+
+	results := make([]repository.BulkResult, len(operations))
+	valid := make([]repository.BulkOperation, 0, len(operations))
+	positions := make([]int, 0, len(operations))
+	invalid := -1
+	for i, op := range operations {
+		if err := validateBulkOperation(op); err != nil {
+			results[i].Err = err
+			invalid = i
+			continue
+		}
+		valid = append(valid, op)
+		positions = append(positions, i)
+	}
+	if atomic && invalid >= 0 {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = errors.ErrBatchAborted
+			}
+		}
+		return results, nil
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	written, err := s.repo.BulkWrite(ctx, valid, atomic)
+	if err != nil {
+		return nil, errors.WrapError(err, errors.ErrDatabase)
+	}
+
+	changed := false
+	for j, result := range written {
+		op := valid[j]
+		switch {
+		case result.Err == nil:
+			changed = true
+			if op.Type != repository.BulkCreate {
+				s.invalidateProdutoCache(ctx, op.ID)
+			}
+		case result.Err == repository.ErrBulkAborted:
+			result.Err = errors.ErrBatchAborted
+		default:
+			result.Err = mapWriteError(result.Err, op.Version())
+		}
+		results[positions[j]] = result
+	}
+	if changed {
+		s.invalidateAggregatesCache(ctx)
+	}
+	return results, nil
+}
+
+// DryRunBulkWrite retorna os resultados que BulkWrite teria, sem gravar: valida as
+// operações e verifica a existência e a versão dos produtos alterados. Cada operação é
+// verificada sobre o estado atual, sem considerar as anteriores do mesmo lote
+func (s *produtoService) DryRunBulkWrite(ctx context.Context, operations []repository.BulkOperation) ([]repository.BulkResult, error) {
+	if len(operations) == 0 || len(operations) > dto.MaxBatchSize {
+		return nil, errors.ErrInvalidInput.WithDetailsf("O lote deve conter entre 1 e %d operações", dto.MaxBatchSize)
+	}
+
+	results := make([]repository.BulkResult, len(operations))
+	ids := make([]interface{}, 0, len(operations))
+	for i, op := range operations {
+		if err := validateBulkOperation(op); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if op.Type != repository.BulkCreate {
+			ids = append(ids, op.ID)
+		}
+	}
+
+	// Estado atual dos produtos alterados, inclusive os deletados
+	current := make(map[int]model.Produto, len(ids))
+	if len(ids) > 0 {
+		filter := map[string]interface{}{
+			"id":         map[string]interface{}{"$in": ids},
+			"deleted_at": model.IncludeDeleted,
+		}
+		produtos, err := s.repo.FindAllPaginated(ctx, 0, 0, filter, nil, []string{"id", "deleted_at", "version"})
+		if err != nil {
+			return nil, errors.WrapError(err, errors.ErrDatabase)
+		}
+		for _, p := range produtos {
+			current[p.ID] = p
+		}
+	}
+
+	for i, op := range operations {
+		if results[i].Err != nil {
+			continue
+		}
+		p, exists := current[op.ID]
+		switch {
+		case op.Type == repository.BulkCreate:
+			results[i].Created = true
+		case op.Type == repository.BulkUpsert && !exists:
+			results[i].Created = true
+		case op.Type == repository.BulkUpsert && p.IsDeleted():
+			results[i].Err = mapWriteError(stderrors.New("deleted"), op.Version())
+		case !exists || p.IsDeleted():
+			results[i].Err = mapWriteError(stderrors.New("not found"), op.Version())
+		case op.ExpectedVersion != nil && *op.ExpectedVersion != p.Version:
+			results[i].Err = mapWriteError(stderrors.New("version conflict"), op.Version())
+		}
+	}
+	return results, nil
+}
+
+// validateBulkOperation aplica a uma operação do lote as validações da rota individual:
+// as do DTO do corpo (validator) e as de negócio
+func validateBulkOperation(op repository.BulkOperation) error {
+	switch op.Type {
+	case repository.BulkCreate:
+	case repository.BulkUpdate, repository.BulkPatch, repository.BulkDelete, repository.BulkUpsert:
+		if op.ID <= 0 {
+			return errors.ErrInvalidID
+		}
+	default:
+		return errors.ErrInvalidInput.WithDetailsf("Operação inválida: %s. Operações permitidas: create, update, patch, delete, upsert", op.Type)
+	}
+	if op.ExpectedVersion != nil && *op.ExpectedVersion < 0 {
+		return errors.ErrInvalidInput.WithDetails("O campo 'version' não pode ser negativo")
+	}
+
+	var request interface{}
+	switch op.Type {
+	case repository.BulkCreate, repository.BulkUpsert:
+		request = &dto.CreateProdutoRequest{Nome: op.Produto.Nome, Preco: op.Produto.Preco, Descricao: op.Produto.Descricao}
+	case repository.BulkUpdate:
+		request = &dto.UpdateProdutoRequest{Nome: op.Produto.Nome, Preco: op.Produto.Preco, Descricao: op.Produto.Descricao}
+	case repository.BulkPatch:
+		patch := dto.NewPatchProdutoRequest(op.Updates)
+		request = &patch
+	}
+	if request != nil {
+		if validationErrors := validator.Validate(request); len(validationErrors) > 0 {
+			return errors.ErrValidation.WithDetailsf("Erros de validação: %v", validationErrors)
+		}
+	}
+
+	switch op.Type {
+	case repository.BulkCreate, repository.BulkUpdate, repository.BulkUpsert:
+		if op.Produto.Nome == "" {
+			return errors.ErrNomeObrigatorio
+		}
+		if op.Produto.Preco <= 0 {
+			return errors.ErrPrecoInvalido
+		}
+	case repository.BulkPatch:
+		if nome, ok := op.Updates["nome"].(string); ok && nome == "" {
+			return errors.ErrNomeObrigatorio
+		}
+		if preco, ok := op.Updates["preco"].(float64); ok && preco <= 0 {
+			return errors.ErrPrecoInvalido
+		}
+	}
+	return nil
+}
+
+// Purge remove um produto definitivamente (deletado ou não)
+func (s *produtoService) Purge(ctx context.Context, id int) error {
+	if id <= 0 {
+		return errors.ErrInvalidID
+	}
+
+	if err := s.repo.Purge(ctx, id); err != nil {
+		if err.Error() == "not found" {
+			return errors.ErrProdutoNotFound
+		}
+		return errors.WrapError(err, errors.ErrDatabase)
+	}
+
+	s.invalidateProdutoCache(ctx, id)
+	s.invalidateAggregatesCache(ctx)
+	// Remoção irreversível: o log registra quem a solicitou
+	logger.WithFields(map[string]interface{}{
+		"id":      id,
+		"subject": auth.SubjectFromContext(ctx),
+	}).Warn("Produto removido definitivamente")
+
+	return nil
+}
+
+// mapWriteError converte os erros do repository em alterações (Update, Patch, Delete e upsert)
+func mapWriteError(err error, expectedVersion int) error {
+	switch err.Error() {
+	case "not found":
+		return errors.ErrProdutoNotFound
+	case "deleted":
+		return errors.ErrProdutoNotFound.WithDetails("O produto está deletado; restaure-o antes de alterá-lo")
+	case "version conflict":
+		return errors.ErrPreconditionFailed.WithDetailsf("Versão esperada %d não corresponde à versão atual", expectedVersion)
+	}
+	return errors.WrapError(err, errors.ErrDatabase)
+}
+
+// invalidateProdutoCache remove o produto do cache após uma alteração
+func (s *produtoService) invalidateProdutoCache(ctx context.Context, id int) {
+	if s.cache == nil {
+		return
+	}
+	cacheKey := cache.GenerateProdutoKey(id)
+	start := time.Now()
+	if err := s.cache.Delete(ctx, cacheKey); err != nil {
+		metrics.RecordCacheError("delete", time.Since(start))
+		logger.WithField("error", err).Warn("Erro ao invalidar cache do produto")
+		return
+	}
+	metrics.RecordCacheOperation("delete", "success", time.Since(start))
+}
 
+// Export percorre todos os produtos filtrados, na ordenação informada, chamando fn
+// para cada um. Não usa cache nem paginação: os produtos são lidos do repositório
+// sob demanda, então a memória usada não depende do total
+// Erros de fn são retornados sem alteração
+func (s *produtoService) Export(ctx context.Context, filter dto.FilterRequest, sort dto.SortRequest, fn func(model.Produto) error) error {
+	if err := sort.Validate(); err != nil {
+		return errors.ErrInvalidInput.WithDetails(err.Error())
+	}
+	if err := filter.Validate(); err != nil {
+		return invalidFilterError(err)
+	}
+
+	var fnErr error
+	err := s.repo.Stream(ctx, filter.ToMongoFilter(), sort.ToMongoSort(), func(p model.Produto) error {
+		fnErr = fn(p)
+		return fnErr
+	})
+	if err != nil {
+		if fnErr != nil {
+			return fnErr
+		}
+		return errors.WrapError(err, errors.ErrDatabase)
+	}
 	return nil
 }
 
 // FindAllPaginated retorna produtos paginados com filtros e ordenação
-func (s *produtoService) FindAllPaginated(ctx context.Context, pagination dto.PaginationRequest, filter dto.FilterRequest, sort dto.SortRequest) ([]model.Produto, dto.PaginationResponse, error) {
+func (s *produtoService) FindAllPaginated(ctx context.Context, pagination dto.PaginationRequest, filter dto.FilterRequest, sort dto.SortRequest, fields dto.FieldsRequest) ([]model.Produto, dto.PaginationResponse, error) {
 	// Validar paginação
 	pagination.Validate()
 
@@ -248,77 +533,269 @@ func (s *produtoService) FindAllPaginated(ctx context.Context, pagination dto.Pa
 		return nil, dto.PaginationResponse{}, errors.ErrInvalidInput.WithDetails(err.Error())
 	}
 
+	// Validar filtros
+	if err := filter.Validate(); err != nil {
+		return nil, dto.PaginationResponse{}, invalidFilterError(err)
+	}
+
+	// Validar campos (sparse fieldset)
+	if err := fields.Validate(); err != nil {
+		return nil, dto.PaginationResponse{}, errors.ErrInvalidInput.WithDetails(err.Error())
+	}
+
 	// Converter filtro para MongoDB
 	mongoFilter := filter.ToMongoFilter()
 
 	// Converter ordenação para MongoDB
 	mongoSort := sort.ToMongoSort()
 
-	// Gerar chave de cache para a lista
-	cacheKey := cache.GenerateProdutosListKey(pagination.Page, pagination.PageSize, mongoFilter)
+	// Paginação por cursor (keyset): buscar a partir do último produto da página anterior
+	// ao invés de pular documentos, evitando duplicatas e lacunas durante inserções
+	page := pagination.Page
+	queryFilter := mongoFilter
+	skip, limit := pagination.GetSkip(), pagination.GetLimit()
+	if pagination.IsCursor() {
+		cursor, err := dto.DecodeCursor(pagination.Cursor, mongoSort)
+		if err != nil {
+			return nil, dto.PaginationResponse{}, errors.ErrInvalidInput.WithDetails(err.Error())
+		}
+		queryFilter = dto.WithCursor(mongoFilter, cursor)
+		page = 0
+		// Buscar um produto a mais para saber se existe próxima página
+		skip, limit = 0, limit+1
+	}
 
-	// Tentar buscar do cache primeiro
-	if s.cache != nil {
-		start := time.Now()
-		cachedData, err := s.cache.Get(ctx, cacheKey)
-		duration := time.Since(start)
-		
-		if err == nil {
-			// Cache hit
-			var cachedResult struct {
-				Produtos []model.Produto
-				Total    int64
-			}
-			if err := cache.Decode(cachedData, &cachedResult); err == nil {
-				metrics.RecordCacheHit("get_list", duration)
-				logger.WithFields(map[string]interface{}{
-					"cache_key": cacheKey,
-					"page":       pagination.Page,
-				}).Debug("Cache hit para lista de produtos")
-				
-				paginationResp := dto.NewPaginationResponse(pagination.Page, pagination.PageSize, int(cachedResult.Total))
-				return cachedResult.Produtos, paginationResp, nil
-			}
-			metrics.RecordCacheError("get_list", duration)
-		} else {
-			metrics.RecordCacheMiss("get_list", duration)
+	// Gerar chave de cache para a lista (o filtro inclui a condição do cursor; a
+	// ordenação e os campos fazem parte da chave, pois mudam o conteúdo de cada página)
+	cacheKey := cache.GenerateProdutosListKey(page, pagination.PageSize, queryFilter, sort.String(), fields.String())
+
+	type listResult struct {
+		Produtos []model.Produto
+		Total    int64
+	}
+	result, err := cachedRead(ctx, s, cacheKey, "list", func() (listResult, error) {
+		// Contar total de documentos
+		totalItems, err := s.repo.Count(ctx, mongoFilter)
+		if err != nil {
+			return listResult{}, errors.WrapError(err, errors.ErrDatabase)
 		}
+
+		// Buscar produtos paginados, apenas com os campos solicitados (mais os da
+		// ordenação, usados pelo cursor)
+		produtos, err := s.repo.FindAllPaginated(ctx, skip, limit, queryFilter, mongoSort, fields.ToProjection(mongoSort))
+		if err != nil {
+			return listResult{}, errors.WrapError(err, errors.ErrDatabase)
+		}
+		return listResult{Produtos: produtos, Total: totalItems}, nil
+	})
+	if err != nil {
+		return nil, dto.PaginationResponse{}, err
 	}
 
-	// Contar total de documentos
+	return s.paginatedResult(pagination, sort, result.Produtos, result.Total)
+}
+
+// Search executa a busca textual ordenada por relevância
+func (s *produtoService) Search(ctx context.Context, search dto.SearchRequest, pagination dto.PaginationRequest, filter dto.FilterRequest) ([]model.SearchResult, dto.PaginationResponse, error) {
+	// Validar busca, paginação e filtros
+	if err := search.Validate(); err != nil {
+		return nil, dto.PaginationResponse{}, errors.ErrInvalidInput.WithDetails(err.Error())
+	}
+	pagination.Validate()
+	if pagination.IsCursor() {
+		// A ordem por relevância não tem uma chave estável para o cursor
+		return nil, dto.PaginationResponse{}, errors.ErrInvalidInput.WithDetails("a busca não suporta paginação por cursor, use page")
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, dto.PaginationResponse{}, invalidFilterError(err)
+	}
+
+	mongoFilter := dto.WithTextSearch(filter.ToMongoFilter(), search.Query)
+
 	totalItems, err := s.repo.Count(ctx, mongoFilter)
 	if err != nil {
 		return nil, dto.PaginationResponse{}, errors.WrapError(err, errors.ErrDatabase)
 	}
 
-	// Buscar produtos paginados
-	produtos, err := s.repo.FindAllPaginated(ctx, pagination.GetSkip(), pagination.GetLimit(), mongoFilter, mongoSort)
+	results, err := s.repo.Search(ctx, mongoFilter, pagination.GetSkip(), pagination.GetLimit())
 	if err != nil {
 		return nil, dto.PaginationResponse{}, errors.WrapError(err, errors.ErrDatabase)
 	}
 
+	logger.WithFields(map[string]interface{}{
+		"query":   search.Query,
+		"results": totalItems,
+	}).Debug("Busca textual executada")
+
+	return results, dto.NewPaginationResponse(pagination.Page, pagination.PageSize, int(totalItems)), nil
+}
+
+// Facets calcula as facetas solicitadas sobre os produtos da listagem ou da busca textual
+// O resultado não depende da página nem da ordenação, então é compartilhado entre as páginas
+func (s *produtoService) Facets(ctx context.Context, facets dto.FacetRequest, filter dto.FilterRequest, search *dto.SearchRequest) (*model.Facets, error) {
+	// Validar facetas e filtros
+	if err := facets.Validate(); err != nil {
+		return nil, errors.ErrInvalidInput.WithDetails(err.Error())
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, invalidFilterError(err)
+	}
+
+	mongoFilter := filter.ToMongoFilter()
+	if search != nil {
+		if err := search.Validate(); err != nil {
+			return nil, errors.ErrInvalidInput.WithDetails(err.Error())
+		}
+		mongoFilter = dto.WithTextSearch(mongoFilter, search.Query)
+	}
+
+	// Os filtros e a versão das agregações fazem parte da chave (ver invalidateAggregatesCache)
+	cacheKey := cache.GenerateProdutosFacetsKey(s.aggregatesVersion(ctx), mongoFilter, facets.String())
+	result, err := cachedRead(ctx, s, cacheKey, "facets", func() (model.Facets, error) {
+		result, err := s.repo.Facets(ctx, mongoFilter, facets.Fields)
+		if err != nil {
+			return model.Facets{}, errors.WrapError(err, errors.ErrDatabase)
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Stats calcula as estatísticas dos produtos filtrados
+func (s *produtoService) Stats(ctx context.Context, filter dto.FilterRequest) (model.ProdutoStats, error) {
+	// Validar filtros
+	if err := filter.Validate(); err != nil {
+		return model.ProdutoStats{}, invalidFilterError(err)
+	}
+
+	mongoFilter := filter.ToMongoFilter()
+
+	// Os filtros e a versão das agregações fazem parte da chave (ver invalidateAggregatesCache)
+	cacheKey := cache.GenerateProdutosStatsKey(s.aggregatesVersion(ctx), mongoFilter)
+	return cachedRead(ctx, s, cacheKey, "stats", func() (model.ProdutoStats, error) {
+		stats, err := s.repo.Aggregate(ctx, mongoFilter)
+		if err != nil {
+			return model.ProdutoStats{}, errors.WrapError(err, errors.ErrDatabase)
+		}
+		return stats, nil
+	})
+}
+
+// cachedRead retorna o valor da chave em cache ou, na ausência, o calculado por load,
+// armazenando-o com o TTL do serviço. operation identifica as métricas de cache
+// (get_<operation> e set_<operation>). Erros do cache nunca impedem a leitura
+func cachedRead[T any](ctx context.Context, s *produtoService, cacheKey, operation string, load func() (T, error)) (T, error) {
+	if s.cache == nil {
+		return load()
+	}
+
+	// Tentar buscar do cache primeiro
+	start := time.Now()
+	cachedData, err := s.cache.Get(ctx, cacheKey)
+	duration := time.Since(start)
+	if err == nil {
+		var cached T
+		if err := cache.Decode(cachedData, &cached); err == nil {
+			metrics.RecordCacheHit("get_"+operation, duration)
+			logger.WithField("cache_key", cacheKey).Debug("Cache hit")
+			return cached, nil
+		}
+		metrics.RecordCacheError("get_"+operation, duration)
+	} else {
+		metrics.RecordCacheMiss("get_"+operation, duration)
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
 	// Armazenar no cache
-	if s.cache != nil {
-		cachedResult := struct {
-			Produtos []model.Produto
-			Total    int64
-		}{
-			Produtos: produtos,
-			Total:    totalItems,
-		}
-		if cachedData, err := cache.Encode(cachedResult); err == nil {
-			start := time.Now()
-			if err := s.cache.Set(ctx, cacheKey, cachedData, s.ttl); err != nil {
-				metrics.RecordCacheError("set_list", time.Since(start))
-				logger.WithField("error", err).Warn("Erro ao armazenar lista no cache")
-			} else {
-				metrics.RecordCacheOperation("set_list", "success", time.Since(start))
-			}
+	if cachedData, err := cache.Encode(value); err == nil {
+		start := time.Now()
+		if err := s.cache.Set(ctx, cacheKey, cachedData, s.ttl); err != nil {
+			metrics.RecordCacheError("set_"+operation, time.Since(start))
+			logger.WithFields(map[string]interface{}{
+				"cache_key": cacheKey,
+				"error":     err,
+			}).Warn("Erro ao armazenar no cache")
+		} else {
+			metrics.RecordCacheOperation("set_"+operation, "success", time.Since(start))
+		}
+	}
+	return value, nil
+}
+
+// aggregatesVersion retorna a versão atual das facetas e estatísticas em cache
+func (s *produtoService) aggregatesVersion(ctx context.Context) string {
+	if s.cache == nil {
+		return ""
+	}
+	if version, err := s.cache.Get(ctx, cache.ProdutosAggregatesVersionKey); err == nil {
+		return string(version)
+	}
+	return s.invalidateAggregatesCache(ctx)
+}
+
+// invalidateAggregatesCache invalida as facetas e estatísticas em cache após uma escrita,
+// gravando uma nova versão (ver cache.ProdutosAggregatesVersionKey), e a retorna
+// Escolhida no lugar de um TTL curto para que contagens e estatísticas reflitam a
+// escrita imediatamente. A versão expira com o TTL do serviço: uma versão nova é
+// sempre única, então sua expiração apenas descarta as entradas anteriores. Remoções
+// feitas direto no repositório (ex: a limpeza da lixeira em retention) não passam pelo
+// serviço e são refletidas apenas após o TTL
+func (s *produtoService) invalidateAggregatesCache(ctx context.Context) string {
+	if s.cache == nil {
+		return ""
+	}
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.cache.Set(ctx, cache.ProdutosAggregatesVersionKey, []byte(version), s.ttl); err != nil {
+		logger.WithField("error", err).Warn("Erro ao invalidar facetas e estatísticas em cache")
+	}
+	return version
+}
+
+// invalidFilterError converte um erro de validação dos filtros em erro da API,
+// incluindo a posição quando o erro está na expressão filter
+func invalidFilterError(err error) error {
+	apiErr := errors.ErrInvalidInput.WithDetails(err.Error())
+	var exprErr *rsql.Error
+	if stderrors.As(err, &exprErr) {
+		return apiErr.WithPosition(exprErr.Pos)
+	}
+	return apiErr
+}
+
+// paginatedResult cria a resposta de paginação, incluindo o cursor da próxima página
+// Na paginação por cursor, produtos contém um item a mais que indica se há próxima página
+func (s *produtoService) paginatedResult(pagination dto.PaginationRequest, sort dto.SortRequest, produtos []model.Produto, totalItems int64) ([]model.Produto, dto.PaginationResponse, error) {
+	var paginationResp dto.PaginationResponse
+	if pagination.IsCursor() {
+		hasNext := len(produtos) > pagination.PageSize
+		if hasNext {
+			produtos = produtos[:pagination.PageSize]
 		}
+		paginationResp = dto.NewCursorPaginationResponse(pagination.PageSize, int(totalItems), hasNext)
+	} else {
+		paginationResp = dto.NewPaginationResponse(pagination.Page, pagination.PageSize, int(totalItems))
 	}
 
-	// Criar resposta de paginação
-	paginationResp := dto.NewPaginationResponse(pagination.Page, pagination.PageSize, int(totalItems))
+	// O cursor da próxima página aponta para o último produto retornado
+	if paginationResp.HasNext && len(produtos) > 0 {
+		cursor, err := dto.NewCursor(sort.ToMongoSort(), produtos[len(produtos)-1])
+		if err != nil {
+			return nil, dto.PaginationResponse{}, errors.WrapError(err, errors.ErrInternalServer)
+		}
+		encoded, err := cursor.Encode()
+		if err != nil {
+			return nil, dto.PaginationResponse{}, errors.WrapError(err, errors.ErrInternalServer)
+		}
+		paginationResp.NextCursor = encoded
+	}
 
 	return produtos, paginationResp, nil
 }
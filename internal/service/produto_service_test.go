@@ -2,91 +2,21 @@ package service
 
 import (
 	"context"
-	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
+	"api-go-arquitetura/internal/cache"
+	"api-go-arquitetura/internal/dto"
 	apiErrors "api-go-arquitetura/internal/errors"
 	"api-go-arquitetura/internal/model"
 	"api-go-arquitetura/internal/repository"
 )
 
-// MockRepository é um mock do ProdutoRepository para testes
-type MockRepository struct {
-	produtos []model.Produto
-	nextID   int
-}
-
-func NewMockRepository() repository.ProdutoRepository {
-	return &MockRepository{
-		produtos: make([]model.Produto, 0),
-		nextID:   1,
-	}
-}
-
-func (m *MockRepository) Create(ctx context.Context, produto model.Produto) (model.Produto, error) {
-	produto.ID = m.nextID
-	m.nextID++
-	m.produtos = append(m.produtos, produto)
-	return produto, nil
-}
-
-func (m *MockRepository) FindAll(ctx context.Context) ([]model.Produto, error) {
-	return m.produtos, nil
-}
-
-func (m *MockRepository) FindByID(ctx context.Context, id int) (model.Produto, error) {
-	for _, p := range m.produtos {
-		if p.ID == id {
-			return p, nil
-		}
-	}
-	return model.Produto{}, errors.New("not found")
-}
-
-func (m *MockRepository) Update(ctx context.Context, id int, produto model.Produto) (model.Produto, error) {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			produto.ID = id
-			m.produtos[i] = produto
-			return produto, nil
-		}
-	}
-	return model.Produto{}, errors.New("not found")
-}
-
-func (m *MockRepository) Patch(ctx context.Context, id int, updates map[string]interface{}) (model.Produto, error) {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			if nome, ok := updates["nome"].(string); ok {
-				p.Nome = nome
-			}
-			if preco, ok := updates["preco"].(float64); ok {
-				p.Preco = preco
-			}
-			if descricao, ok := updates["descricao"].(string); ok {
-				p.Descricao = descricao
-			}
-			m.produtos[i] = p
-			return p, nil
-		}
-	}
-	return model.Produto{}, errors.New("not found")
-}
-
-func (m *MockRepository) Delete(ctx context.Context, id int) error {
-	for i, p := range m.produtos {
-		if p.ID == id {
-			m.produtos = append(m.produtos[:i], m.produtos[i+1:]...)
-			return nil
-		}
-	}
-	return errors.New("not found")
-}
-
 func TestProdutoService_Create(t *testing.T) {
 	ctx := context.Background()
-	mockRepo := NewMockRepository()
-	service := NewProdutoService(mockRepo)
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
 
 	t.Run("deve criar produto com dados válidos", func(t *testing.T) {
 		produto := model.Produto{
@@ -150,8 +80,8 @@ func TestProdutoService_Create(t *testing.T) {
 
 func TestProdutoService_FindByID(t *testing.T) {
 	ctx := context.Background()
-	mockRepo := NewMockRepository()
-	service := NewProdutoService(mockRepo)
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
 
 	// Criar produto de teste
 	produto := model.Produto{
@@ -199,8 +129,8 @@ func TestProdutoService_FindByID(t *testing.T) {
 
 func TestProdutoService_Delete(t *testing.T) {
 	ctx := context.Background()
-	mockRepo := NewMockRepository()
-	service := NewProdutoService(mockRepo)
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
 
 	// Criar produto de teste
 	produto := model.Produto{
@@ -211,7 +141,7 @@ func TestProdutoService_Delete(t *testing.T) {
 	created, _ := service.Create(ctx, produto)
 
 	t.Run("deve deletar produto existente", func(t *testing.T) {
-		err := service.Delete(ctx, created.ID)
+		err := service.Delete(ctx, created.ID, repository.AnyVersion)
 		if err != nil {
 			t.Fatalf("Erro inesperado: %v", err)
 		}
@@ -224,7 +154,7 @@ func TestProdutoService_Delete(t *testing.T) {
 	})
 
 	t.Run("deve retornar erro quando produto não existe", func(t *testing.T) {
-		err := service.Delete(ctx, 999)
+		err := service.Delete(ctx, 999, repository.AnyVersion)
 		if err == nil {
 			t.Error("Esperado erro, mas nenhum erro foi retornado")
 		}
@@ -236,3 +166,345 @@ func TestProdutoService_Delete(t *testing.T) {
 	})
 }
 
+
+func TestProdutoService_RestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
+
+	created, _ := service.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00})
+
+	t.Run("deve restaurar produto deletado", func(t *testing.T) {
+		if err := service.Delete(ctx, created.ID, repository.AnyVersion); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+
+		restored, err := service.Restore(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if restored.IsDeleted() {
+			t.Error("Produto restaurado não deveria estar deletado")
+		}
+
+		if _, err := service.FindByID(ctx, created.ID); err != nil {
+			t.Errorf("Produto restaurado deveria ser encontrado: %v", err)
+		}
+	})
+
+	t.Run("deve retornar erro ao restaurar produto não deletado", func(t *testing.T) {
+		_, err := service.Restore(ctx, created.ID)
+		apiErr := apiErrors.AsAPIError(err)
+		if apiErr == nil || apiErr.Code != "PRODUTO_NOT_FOUND" {
+			t.Errorf("Código de erro esperado PRODUTO_NOT_FOUND, obtido %v", err)
+		}
+	})
+
+	t.Run("deve remover produto definitivamente", func(t *testing.T) {
+		if err := service.Purge(ctx, created.ID); err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+
+		_, err := service.Restore(ctx, created.ID)
+		apiErr := apiErrors.AsAPIError(err)
+		if apiErr == nil || apiErr.Code != "PRODUTO_NOT_FOUND" {
+			t.Errorf("Código de erro esperado PRODUTO_NOT_FOUND, obtido %v", err)
+		}
+	})
+}
+
+// collectWithCursor percorre todas as páginas seguindo nextCursor
+func collectWithCursor(t *testing.T, service ProdutoService, sort dto.SortRequest, pageSize int) []int {
+	t.Helper()
+	ctx := context.Background()
+
+	// A primeira página usa page/pageSize; as seguintes, o cursor
+	pagination := dto.PaginationRequest{Page: 1, PageSize: pageSize}
+	var ids []int
+	for i := 0; i < 100; i++ {
+		produtos, resp, err := service.FindAllPaginated(ctx, pagination, dto.FilterRequest{}, sort, dto.FieldsRequest{})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		for _, p := range produtos {
+			ids = append(ids, p.ID)
+		}
+		if !resp.HasNext {
+			if resp.NextCursor != "" {
+				t.Error("Última página não deveria ter nextCursor")
+			}
+			return ids
+		}
+		if resp.NextCursor == "" {
+			t.Fatal("Página com próxima página deveria ter nextCursor")
+		}
+		pagination = dto.PaginationRequest{PageSize: pageSize, Cursor: resp.NextCursor}
+	}
+	t.Fatal("Paginação por cursor não terminou")
+	return nil
+}
+
+func TestProdutoService_BulkWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
+	created, _ := service.Create(ctx, model.Produto{Nome: "Notebook", Preco: 3500.00})
+
+	negative := -1
+	operations := []repository.BulkOperation{
+		{Type: repository.BulkCreate, Produto: model.Produto{Nome: "Mouse", Preco: 50.00}},
+		{Type: repository.BulkCreate, Produto: model.Produto{Nome: strings.Repeat("a", 101), Preco: 50.00}},
+		{Type: repository.BulkPatch, ID: created.ID, Updates: map[string]interface{}{"descricao": strings.Repeat("a", 501)}},
+		{Type: repository.BulkDelete, ID: created.ID, ExpectedVersion: &negative},
+		{Type: repository.BulkDelete},
+		{Type: "rename", ID: created.ID},
+	}
+	expected := []string{"", "VALIDATION_ERROR", "VALIDATION_ERROR", "INVALID_INPUT", "INVALID_ID", "INVALID_INPUT"}
+
+	codes := func(results []repository.BulkResult) []string {
+		got := make([]string, len(results))
+		for i, result := range results {
+			if apiErr := apiErrors.AsAPIError(result.Err); apiErr != nil {
+				got[i] = apiErr.Code
+			}
+		}
+		return got
+	}
+
+	t.Run("deve validar cada operação com as regras da rota individual", func(t *testing.T) {
+		results, err := service.BulkWrite(ctx, operations, false)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if got := codes(results); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Códigos esperados %v, obtidos %v", expected, got)
+		}
+	})
+
+	t.Run("lote atômico com operação inválida não deve ser executado", func(t *testing.T) {
+		before, _ := service.FindAll(ctx)
+		results, err := service.BulkWrite(ctx, operations, true)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if got := codes(results); got[0] != "BATCH_ABORTED" || !reflect.DeepEqual(got[1:], expected[1:]) {
+			t.Errorf("Códigos esperados [BATCH_ABORTED %v], obtidos %v", expected[1:], got)
+		}
+		if after, _ := service.FindAll(ctx); len(after) != len(before) {
+			t.Errorf("Nenhum produto deveria ser criado: %d antes, %d depois", len(before), len(after))
+		}
+	})
+}
+
+func TestProdutoService_FindAllPaginated_Cursor(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, nil)
+
+	// Preços e nomes repetidos para exercitar o desempate por ID
+	for _, p := range []model.Produto{
+		{Nome: "Mouse", Preco: 50, Descricao: "b"},
+		{Nome: "Teclado", Preco: 150, Descricao: "a"},
+		{Nome: "Mouse", Preco: 50, Descricao: "c"},
+		{Nome: "Monitor", Preco: 1500, Descricao: "a"},
+		{Nome: "Notebook", Preco: 5500, Descricao: "d"},
+		{Nome: "Cabo", Preco: 50, Descricao: "b"},
+		{Nome: "Webcam", Preco: 300, Descricao: "e"},
+	} {
+		if _, err := service.Create(ctx, p); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+	}
+
+	for _, field := range []string{"", "id", "nome", "preco", "descricao", "created_at", "updated_at"} {
+		for _, order := range []string{"asc", "desc"} {
+			sort := dto.GetSortFromQuery(field, order)
+			t.Run(field+" "+order, func(t *testing.T) {
+				all, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 100}, dto.FilterRequest{}, sort, dto.FieldsRequest{})
+				if err != nil {
+					t.Fatalf("Erro inesperado: %v", err)
+				}
+
+				got := collectWithCursor(t, service, sort, 2)
+				if len(got) != len(all) {
+					t.Fatalf("Quantidade esperada %d, obtida %d (%v)", len(all), len(got), got)
+				}
+				for i, p := range all {
+					if got[i] != p.ID {
+						t.Fatalf("Ordem esperada igual à paginação por offset, posição %d: esperado %d, obtido %d", i, p.ID, got[i])
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("inserções durante a paginação não causam duplicatas", func(t *testing.T) {
+		sort := dto.GetSortFromQuery("preco", "asc")
+		_, resp, err := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 3}, dto.FilterRequest{}, sort, dto.FieldsRequest{})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+
+		// Produto mais barato que todos os já vistos é inserido antes da próxima página
+		if _, err := service.Create(ctx, model.Produto{Nome: "Adesivo", Preco: 1}); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+
+		produtos, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{PageSize: 3, Cursor: resp.NextCursor}, dto.FilterRequest{}, sort, dto.FieldsRequest{})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		for _, p := range produtos {
+			if p.Preco < 150 {
+				t.Errorf("Produto %d já estava em uma página anterior ou antes do cursor", p.ID)
+			}
+		}
+	})
+
+	t.Run("deve rejeitar cursor inválido ou de outra ordenação", func(t *testing.T) {
+		_, resp, _ := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 2}, dto.FilterRequest{}, dto.GetSortFromQuery("nome", ""), dto.FieldsRequest{})
+
+		for _, tt := range []struct {
+			cursor string
+			sort   dto.SortRequest
+		}{
+			{"nao-e-um-cursor", dto.SortRequest{}},
+			{resp.NextCursor, dto.GetSortFromQuery("preco", "")},
+			{resp.NextCursor, dto.GetSortFromQuery("nome:desc", "")},
+		} {
+			_, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{PageSize: 2, Cursor: tt.cursor}, dto.FilterRequest{}, tt.sort, dto.FieldsRequest{})
+			apiErr := apiErrors.AsAPIError(err)
+			if apiErr == nil || apiErr.Code != "INVALID_INPUT" {
+				t.Errorf("Código de erro esperado INVALID_INPUT, obtido %v", err)
+			}
+		}
+	})
+}
+
+func TestProdutoService_FindAllPaginated_MultiSort(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryProdutoRepository()
+	service := NewProdutoService(repo, cache.NewMemoryCache())
+
+	for _, p := range []model.Produto{
+		{Nome: "Mouse", Preco: 50},
+		{Nome: "Teclado", Preco: 150},
+		{Nome: "Cabo", Preco: 50},
+		{Nome: "Adaptador", Preco: 150},
+	} {
+		if _, err := service.Create(ctx, p); err != nil {
+			t.Fatalf("Erro ao criar produto: %v", err)
+		}
+	}
+
+	list := func(sortParam string) []int {
+		t.Helper()
+		produtos, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 10}, dto.FilterRequest{}, dto.GetSortFromQuery(sortParam, ""), dto.FieldsRequest{})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		result := make([]int, 0, len(produtos))
+		for _, p := range produtos {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{"preco:desc,nome:asc", []int{4, 2, 3, 1}},
+		{"preco:desc,nome:desc", []int{2, 4, 1, 3}},
+		{"preco,id:desc", []int{3, 1, 4, 2}},
+		{"preco", []int{1, 3, 2, 4}}, // Desempate por ID
+	}
+	for _, tt := range tests {
+		// Cada ordenação deve usar a própria entrada de cache
+		got := list(tt.sort)
+		if len(got) != len(tt.want) {
+			t.Fatalf("sort=%s: esperado %v, obtido %v", tt.sort, tt.want, got)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("sort=%s: esperado %v, obtido %v", tt.sort, tt.want, got)
+				break
+			}
+		}
+	}
+
+	t.Run("deve rejeitar campo inválido ou repetido", func(t *testing.T) {
+		for _, sortParam := range []string{"preco:desc,senha", "preco,preco:desc", "preco:desc:nome"} {
+			_, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 10}, dto.FilterRequest{}, dto.GetSortFromQuery(sortParam, ""), dto.FieldsRequest{})
+			apiErr := apiErrors.AsAPIError(err)
+			if apiErr == nil || apiErr.Code != "INVALID_INPUT" {
+				t.Errorf("sort=%s: código de erro esperado INVALID_INPUT, obtido %v", sortParam, err)
+			}
+		}
+	})
+}
+
+func TestProdutoService_FindAllPaginated_FieldsCacheKey(t *testing.T) {
+	ctx := context.Background()
+	service := NewProdutoService(repository.NewMemoryProdutoRepository(), cache.NewMemoryCache())
+	if _, err := service.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50, Descricao: "Mouse sem fio"}); err != nil {
+		t.Fatalf("Erro ao criar produto: %v", err)
+	}
+
+	list := func(fieldsParam string) model.Produto {
+		t.Helper()
+		produtos, _, err := service.FindAllPaginated(ctx, dto.PaginationRequest{Page: 1, PageSize: 10}, dto.FilterRequest{}, dto.SortRequest{}, dto.GetFieldsFromQuery(fieldsParam))
+		if err != nil || len(produtos) != 1 {
+			t.Fatalf("Resultado inesperado: %v %v", produtos, err)
+		}
+		return produtos[0]
+	}
+
+	// A lista parcial em cache não pode ser reutilizada pela lista completa
+	if p := list("id,nome"); p.Descricao != "" {
+		t.Errorf("Descrição fora da projeção deveria estar vazia: %+v", p)
+	}
+	if p := list(""); p.Descricao != "Mouse sem fio" || p.Preco != 50 {
+		t.Errorf("Lista completa esperada, obtido %+v", p)
+	}
+}
+
+func TestProdutoService_AggregatesCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	service := NewProdutoService(repository.NewMemoryProdutoRepository(), cache.NewMemoryCache())
+	mouse, _ := service.Create(ctx, model.Produto{Nome: "Mouse", Preco: 50})
+
+	counts := func() (int64, int) {
+		t.Helper()
+		stats, err := service.Stats(ctx, dto.FilterRequest{})
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		facets, err := service.Facets(ctx, dto.GetFacetsFromQuery("nome"), dto.FilterRequest{}, nil)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		return stats.Count, len(facets.Nome.Values)
+	}
+
+	// Primeira leitura armazena as agregações no cache
+	if stats, nomes := counts(); stats != 1 || nomes != 1 {
+		t.Fatalf("Esperados 1 produto e 1 nome, obtidos %d e %d", stats, nomes)
+	}
+
+	// As escritas invalidam as agregações em cache
+	service.Create(ctx, model.Produto{Nome: "Teclado", Preco: 150})
+	if stats, nomes := counts(); stats != 2 || nomes != 2 {
+		t.Errorf("Após criação, esperados 2 produtos e 2 nomes, obtidos %d e %d", stats, nomes)
+	}
+	service.Delete(ctx, mouse.ID, repository.AnyVersion)
+	if stats, nomes := counts(); stats != 1 || nomes != 1 {
+		t.Errorf("Após deleção, esperados 1 produto e 1 nome, obtidos %d e %d", stats, nomes)
+	}
+	service.BulkWrite(ctx, []repository.BulkOperation{
+		{Type: repository.BulkCreate, Produto: model.Produto{Nome: "Monitor", Preco: 900}},
+	}, false)
+	if stats, nomes := counts(); stats != 2 || nomes != 2 {
+		t.Errorf("Após lote, esperados 2 produtos e 2 nomes, obtidos %d e %d", stats, nomes)
+	}
+}